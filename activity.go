@@ -0,0 +1,307 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// DailyActivity stores the highest (or lowest) activity average of a calendar day and its onset
+type DailyActivity struct {
+	// Date is the date (midnight) of the day
+	Date time.Time
+	// Value is the highest (or lowest) activity average of the day
+	Value float64
+	// Onset is the start of the window with the highest (or lowest) activity average
+	Onset time.Time
+}
+
+// DailyActivitySummary stores the daily results of the HigherActivity (e.g. M10) or LowerActivity (e.g. L5)
+// and their summary across the days
+type DailyActivitySummary struct {
+	// Days stores the result of each day (the days without any window are not included)
+	Days []DailyActivity
+	// Mean is the average of the daily values
+	Mean float64
+	// SD is the standard deviation of the daily values (zero when there is only one day)
+	SD float64
+	// OnsetMean is the circular mean of the onset clock times (elapsed since midnight), so 23:30 and 00:30 average to midnight
+	OnsetMean time.Duration
+	// OnsetR is the length of the mean resultant vector of the onset clock times (from 0, spread, to 1, same clock time)
+	OnsetR float64
+	// OnsetSD is the circular standard deviation of the onset clock times (math.MaxInt64 when OnsetR is zero)
+	OnsetSD time.Duration
+}
+
+// Returns the clock time (elapsed since midnight) of the time passed by parameter
+func clockTime(dateTime time.Time) time.Duration {
+	return time.Duration(dateTime.Hour())*time.Hour + time.Duration(dateTime.Minute())*time.Minute +
+		time.Duration(dateTime.Second())*time.Second + time.Duration(dateTime.Nanosecond())
+}
+
+// Calculates the circular mean, the mean resultant length and the circular standard deviation of the clock times
+func circularClockTime(clockTimes []time.Duration) (mean time.Duration, r float64, sd time.Duration) {
+
+	day := float64(24 * time.Hour)
+
+	sumSin := 0.0
+	sumCos := 0.0
+	for _, value := range clockTimes {
+		angle := 2.0 * math.Pi * float64(value) / day
+		sumSin += math.Sin(angle)
+		sumCos += math.Cos(angle)
+	}
+	sumSin /= float64(len(clockTimes))
+	sumCos /= float64(len(clockTimes))
+
+	r = math.Min(1.0, math.Hypot(sumSin, sumCos))
+	if r <= 1e-12 {
+		r = 0
+	}
+
+	angle := math.Atan2(sumSin, sumCos)
+	if angle < 0 {
+		angle += 2.0 * math.Pi
+	}
+	mean = time.Duration(angle*day/(2.0*math.Pi)) % (24 * time.Hour)
+
+	// The mean direction is not defined when the resultant length is zero (e.g. 00:00 and 12:00)
+	if r > 1e-12 {
+		sd = time.Duration(math.Sqrt(-2.0*math.Log(r)) * day / (2.0 * math.Pi))
+	} else {
+		sd = time.Duration(math.MaxInt64)
+	}
+	return
+}
+
+// Finds the highest (or lowest) activity average of each calendar day. The windows start within the day
+// but they can end in the following day (e.g. the L5 starting at 23:00)
+func (ts *TimeSeries) dailyActivity(function string, hours int, higher bool) (summary DailyActivitySummary, err error) {
+
+	// Check the parameters
+	if err = ts.checkActivity(function, hours); err != nil {
+		return
+	}
+
+	positions, dates := ts.days()
+	onsets, averages, covered := ts.windowAverages(hours)
+
+	// Position of the result of each day in the summary (-1 when the day does not have any window)
+	days := make([]int, len(dates))
+	for index := range days {
+		days[index] = -1
+	}
+
+	for index, currentActivity := range averages {
+
+		// Windows below the minimum coverage are not used
+		if !covered[index] {
+			continue
+		}
+
+		day := positions[index]
+		if days[day] < 0 {
+			days[day] = len(summary.Days)
+			summary.Days = append(summary.Days, DailyActivity{Date: dates[day], Value: roundPlus(currentActivity, 4), Onset: onsets[index]})
+			continue
+		}
+
+		result := &summary.Days[days[day]]
+		if (higher && currentActivity > result.Value) || (!higher && currentActivity < result.Value) {
+			result.Value = roundPlus(currentActivity, 4)
+			result.Onset = onsets[index]
+		}
+	}
+
+	n := len(summary.Days)
+	if n == 0 {
+		err = newError(function, ts.uncoveredError())
+		return
+	}
+
+	var values []float64
+	var clockTimes []time.Duration
+	for _, result := range summary.Days {
+		values = append(values, result.Value)
+		clockTimes = append(clockTimes, clockTime(result.Onset))
+	}
+
+	summary.Mean = average(values)
+	if n > 1 {
+		for _, value := range values {
+			summary.SD += (value - summary.Mean) * (value - summary.Mean)
+		}
+		summary.SD = math.Sqrt(summary.SD / float64(n-1))
+	}
+	summary.OnsetMean, summary.OnsetR, summary.OnsetSD = circularClockTime(clockTimes)
+
+	return
+}
+
+// DailyHigherActivity finds the highest activity average of the followed X hours (defined by parameter) of each calendar day,
+// and summarizes the days (mean, standard deviation and circular statistics of the onset clock times).
+// The windows start within the day but they can end in the following day. The masked records, the NaN values
+// and the windows below the minimum coverage are not used
+func (ts *TimeSeries) DailyHigherActivity(hours int) (summary DailyActivitySummary, err error) {
+	return ts.dailyActivity("DailyHigherActivity", hours, true)
+}
+
+// DailyLowerActivity finds the lowest activity average of the followed X hours (defined by parameter) of each calendar day,
+// and summarizes the days (mean, standard deviation and circular statistics of the onset clock times).
+// The windows start within the day but they can end in the following day. The masked records, the NaN values
+// and the windows below the minimum coverage are not used
+func (ts *TimeSeries) DailyLowerActivity(hours int) (summary DailyActivitySummary, err error) {
+	return ts.dailyActivity("DailyLowerActivity", hours, false)
+}
+
+// DailyM10 finds the highest activity average of the followed 10 hours of each calendar day
+func (ts *TimeSeries) DailyM10() (summary DailyActivitySummary, err error) {
+	return ts.DailyHigherActivity(10)
+}
+
+// DailyL5 finds the lowest activity average of the followed 5 hours of each calendar day
+func (ts *TimeSeries) DailyL5() (summary DailyActivitySummary, err error) {
+	return ts.DailyLowerActivity(5)
+}
+
+// DailyHigherActivity finds the highest activity average of the followed X hours of each calendar day
+func DailyHigherActivity(hours int, dateTime []time.Time, data []float64) (summary DailyActivitySummary, err error) {
+	ts, err := wrapTimeSeries("DailyHigherActivity", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.DailyHigherActivity(hours)
+}
+
+// DailyLowerActivity finds the lowest activity average of the followed X hours of each calendar day
+func DailyLowerActivity(hours int, dateTime []time.Time, data []float64) (summary DailyActivitySummary, err error) {
+	ts, err := wrapTimeSeries("DailyLowerActivity", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.DailyLowerActivity(hours)
+}
+
+// DailyM10 finds the highest activity average of the followed 10 hours of each calendar day
+func DailyM10(dateTime []time.Time, data []float64) (summary DailyActivitySummary, err error) {
+	return DailyHigherActivity(10, dateTime, data)
+}
+
+// DailyL5 finds the lowest activity average of the followed 5 hours of each calendar day
+func DailyL5(dateTime []time.Time, data []float64) (summary DailyActivitySummary, err error) {
+	return DailyLowerActivity(5, dateTime, data)
+}
+
+// Finds the highest (or lowest) activity average of the followed X hours on the average day, wrapping around
+// midnight (e.g. the L5 from 23:00 to 04:00). The onset is the clock time (elapsed since midnight)
+func (ts *TimeSeries) averageDayActivity(function string, hours int, higher bool) (activity float64, onset time.Duration, err error) {
+
+	// Check the parameters
+	if hours <= 0 || hours >= 24 {
+		err = newError(function, ErrInvalidHours)
+		return
+	}
+	if err = ts.check(function); err != nil {
+		return
+	}
+
+	averageDay, err := ts.AverageDay()
+	if err != nil {
+		err = newError(function, err)
+		return
+	}
+
+	epoch := time.Duration(averageDay.Epoch) * time.Second
+	duration := time.Duration(hours) * time.Hour
+	points := averageDay.Len()
+	window := int(duration / epoch)
+
+	// Prefix sums of the average day repeated twice, so the windows can wrap around midnight
+	sums := make([]float64, 2*points+1)
+	counts := make([]int, 2*points+1)
+	for index := 0; index < 2*points; index++ {
+		sums[index+1] = sums[index]
+		counts[index+1] = counts[index]
+		if value := averageDay.Data[index%points]; !math.IsNaN(value) {
+			sums[index+1] += value
+			counts[index+1]++
+		}
+	}
+
+	found := false
+	for index := 0; index < points; index++ {
+
+		// Windows below the minimum coverage are not used
+		count := counts[index+window] - counts[index]
+		if !averageDay.covered(count, duration) {
+			continue
+		}
+		currentActivity := (sums[index+window] - sums[index]) / float64(count)
+
+		if !found || (higher && currentActivity > activity) || (!higher && currentActivity < activity) {
+			activity = roundPlus(currentActivity, 4)
+			onset = time.Duration(index) * epoch
+			found = true
+		}
+	}
+
+	if !found {
+		err = newError(function, averageDay.uncoveredError())
+		return
+	}
+
+	// The average day starts at the clock time of the first record
+	onset = (clockTime(averageDay.DateTime[0]) + onset) % (24 * time.Hour)
+	return
+}
+
+// AverageDayHigherActivity finds the highest activity average of the followed X hours (defined by parameter) on the average day,
+// wrapping around midnight (van Someren et al., 1999). The onset is returned as the clock time (elapsed since midnight).
+// The gaps, the masked records, the NaN values and the days below the minimum coverage are not used
+func (ts *TimeSeries) AverageDayHigherActivity(hours int) (higherActivity float64, onsetHigherActivity time.Duration, err error) {
+	return ts.averageDayActivity("AverageDayHigherActivity", hours, true)
+}
+
+// AverageDayLowerActivity finds the lowest activity average of the followed X hours (defined by parameter) on the average day,
+// wrapping around midnight (van Someren et al., 1999). The onset is returned as the clock time (elapsed since midnight).
+// The gaps, the masked records, the NaN values and the days below the minimum coverage are not used
+func (ts *TimeSeries) AverageDayLowerActivity(hours int) (lowerActivity float64, onsetLowerActivity time.Duration, err error) {
+	return ts.averageDayActivity("AverageDayLowerActivity", hours, false)
+}
+
+// AverageDayM10 finds the highest activity average of the followed 10 hours on the average day
+func (ts *TimeSeries) AverageDayM10() (higherActivity float64, onsetHigherActivity time.Duration, err error) {
+	return ts.AverageDayHigherActivity(10)
+}
+
+// AverageDayL5 finds the lowest activity average of the followed 5 hours on the average day
+func (ts *TimeSeries) AverageDayL5() (lowerActivity float64, onsetLowerActivity time.Duration, err error) {
+	return ts.AverageDayLowerActivity(5)
+}
+
+// AverageDayHigherActivity finds the highest activity average of the followed X hours on the average day, wrapping around midnight
+func AverageDayHigherActivity(hours int, dateTime []time.Time, data []float64) (higherActivity float64, onsetHigherActivity time.Duration, err error) {
+	ts, err := wrapTimeSeries("AverageDayHigherActivity", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.AverageDayHigherActivity(hours)
+}
+
+// AverageDayLowerActivity finds the lowest activity average of the followed X hours on the average day, wrapping around midnight
+func AverageDayLowerActivity(hours int, dateTime []time.Time, data []float64) (lowerActivity float64, onsetLowerActivity time.Duration, err error) {
+	ts, err := wrapTimeSeries("AverageDayLowerActivity", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.AverageDayLowerActivity(hours)
+}
+
+// AverageDayM10 finds the highest activity average of the followed 10 hours on the average day
+func AverageDayM10(dateTime []time.Time, data []float64) (higherActivity float64, onsetHigherActivity time.Duration, err error) {
+	return AverageDayHigherActivity(10, dateTime, data)
+}
+
+// AverageDayL5 finds the lowest activity average of the followed 5 hours on the average day
+func AverageDayL5(dateTime []time.Time, data []float64) (lowerActivity float64, onsetLowerActivity time.Duration, err error) {
+	return AverageDayLowerActivity(5, dateTime, data)
+}
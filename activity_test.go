@@ -0,0 +1,175 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCircularClockTime(t *testing.T) {
+
+	var tTests = []struct {
+		clockTimes []time.Duration
+		mean       time.Duration
+		r          float64
+	}{
+		{[]time.Duration{23*time.Hour + 30*time.Minute, 30 * time.Minute}, 0, math.Cos(math.Pi / 24.0)},
+		{[]time.Duration{23 * time.Hour, time.Hour, 0}, 0, (1.0 + 2.0*math.Cos(math.Pi/12.0)) / 3.0},
+		{[]time.Duration{2 * time.Hour, 4 * time.Hour}, 3 * time.Hour, math.Cos(math.Pi / 12.0)},
+		{[]time.Duration{21 * time.Hour}, 21 * time.Hour, 1.0},
+	}
+
+	for _, test := range tTests {
+		mean, r, sd := circularClockTime(test.clockTimes)
+		if math.Abs((mean-test.mean).Seconds()) > 1e-3 && math.Abs((mean-test.mean).Seconds()) < 86400-1e-3 {
+			t.Error("Expected: ", test.mean, "Received: ", mean)
+		}
+		if !floatEquals(r, test.r) {
+			t.Error("Expected: ", test.r, "Received: ", r)
+		}
+		if math.Abs(sd.Hours()-math.Sqrt(-2.0*math.Log(test.r))*12.0/math.Pi) > 1e-6 {
+			t.Error("Unexpected circular SD: ", sd)
+		}
+	}
+
+	// Opposite clock times do not have a mean direction
+	if _, r, sd := circularClockTime([]time.Duration{0, 12 * time.Hour}); r > 1e-9 || sd != time.Duration(math.MaxInt64) {
+		t.Error("Expected: R = 0, Received: ", r, sd)
+	}
+}
+
+func TestDailyActivity(t *testing.T) {
+
+	// 4 days and 6 hours with 5 hours of low activity per day (starting at 02:00, 03:00, 04:00 and 22:00)
+	// and 10 hours of high activity (starting at 09:00, 10:00, 11:00 and 12:00)
+	var data []float64
+	for index := 0; index < 4*1440+6*60; index++ {
+		day := index / 1440
+		minute := index % 1440
+		lowStart := (2 + day) * 60
+		if day == 3 {
+			lowStart = 22 * 60
+		}
+		highStart := (9 + day) * 60
+		switch {
+		case minute >= lowStart && minute < lowStart+5*60,
+			day == 4 && minute < 3*60:
+			data = append(data, 10.0)
+		case day < 4 && minute >= highStart && minute < highStart+10*60:
+			data = append(data, 500.0)
+		default:
+			data = append(data, 100.0+20.0*noise(index))
+		}
+	}
+	ts := minuteSeries(data)
+
+	l5, err := ts.DailyL5()
+	if err != nil || len(l5.Days) != 5 {
+		t.Fatal("Expected: 5 days, Received: ", len(l5.Days), err)
+	}
+
+	// Table tests
+	var tTests = []struct {
+		day   int
+		value float64
+		onset time.Duration
+	}{
+		{0, 10.0, 2 * time.Hour},
+		{1, 10.0, 3 * time.Hour},
+		{2, 10.0, 4 * time.Hour},
+		{3, 10.0, 22 * time.Hour},
+	}
+
+	for _, test := range tTests {
+		result := l5.Days[test.day]
+		if !floatEquals(result.Value, test.value) || clockTime(result.Onset) != test.onset || result.Date.Day() != test.day+1 {
+			t.Error("Expected: ", test.value, test.onset, "Received: ", result.Value, result.Onset)
+		}
+	}
+
+	// The last day (until 06:00) only has windows starting until 01:00
+	if clockTime(l5.Days[4].Onset) != 0 || l5.Days[4].Value <= 10.0 {
+		t.Error("Unexpected last day: ", l5.Days[4])
+	}
+
+	m10, err := DailyM10(ts.DateTime, ts.Data)
+	if err != nil || len(m10.Days) != 4 || !floatEquals(m10.Mean, 500.0) || !floatEquals(m10.SD, 0.0) {
+		t.Fatal("Unexpected M10: ", m10, err)
+	}
+	for day, result := range m10.Days {
+		if clockTime(result.Onset) != time.Duration(9+day)*time.Hour {
+			t.Error("Expected: ", time.Duration(9+day)*time.Hour, "Received: ", result.Onset)
+		}
+	}
+	if math.Abs((m10.OnsetMean-10*time.Hour-30*time.Minute).Seconds()) > 1e-3 || m10.OnsetR > 1.0 || m10.OnsetR < 0.9 {
+		t.Error("Expected: 10h30m, Received: ", m10.OnsetMean, m10.OnsetR)
+	}
+
+	// The same results as HigherActivity when the time series has only one day
+	day, _ := ts.FilterDataByDateTime(ts.DateTime[0], ts.DateTime[1439])
+	daily, err := day.DailyHigherActivity(3)
+	higherActivity, onsetHigherActivity, _ := day.HigherActivity(3)
+	if err != nil || len(daily.Days) != 1 || !floatEquals(daily.Days[0].Value, higherActivity) || !daily.Days[0].Onset.Equal(onsetHigherActivity) {
+		t.Error("Expected: ", higherActivity, onsetHigherActivity, "Received: ", daily.Days, err)
+	}
+	if _, err = DailyLowerActivity(30, ts.DateTime[:1440], ts.Data[:1440]); !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+
+	// Only the masked records
+	day.Mask = repeatMask(true, day.Len())
+	if _, err = day.DailyLowerActivity(1); !errors.Is(err, ErrMasked) {
+		t.Error("Expected: ErrMasked, Received: ", err)
+	}
+}
+
+func TestAverageDayActivity(t *testing.T) {
+
+	// 3 days starting at 12:00 with low activity from 23:00 to 04:00 and high activity from 09:00 to 19:00
+	utc, _ := time.LoadLocation("UTC")
+	var dateTime []time.Time
+	var data []float64
+	for index := 0; index < 3*1440; index++ {
+		current := time.Date(2015, 1, 1, 12, index, 0, 0, utc)
+		hour := current.Hour()
+		dateTime = append(dateTime, current)
+		switch {
+		case hour >= 23 || hour < 4:
+			data = append(data, 10.0)
+		case hour >= 9 && hour < 19:
+			data = append(data, 500.0)
+		default:
+			data = append(data, 100.0+20.0*noise(index))
+		}
+	}
+
+	l5, onsetL5, err := AverageDayL5(dateTime, data)
+	if err != nil || !floatEquals(l5, 10.0) || onsetL5 != 23*time.Hour {
+		t.Error("Expected: 10 23h0m0s, Received: ", l5, onsetL5, err)
+	}
+	m10, onsetM10, err := AverageDayM10(dateTime, data)
+	if err != nil || !floatEquals(m10, 500.0) || onsetM10 != 9*time.Hour {
+		t.Error("Expected: 500 9h0m0s, Received: ", m10, onsetM10, err)
+	}
+
+	// The gaps and the masked records are not used
+	ts, _ := NewTimeSeries(append(dateTime[:600:600], dateTime[700:]...), append(data[:600:600], data[700:]...))
+	ts.Mask = make([]bool, ts.Len())
+	for index := 1000; index < 1200; index++ {
+		ts.Data[index] = 1000.0
+		ts.Mask[index] = true
+	}
+	lowerActivity, onsetLowerActivity, err := ts.AverageDayLowerActivity(2)
+	if err != nil || !floatEquals(lowerActivity, 10.0) || onsetLowerActivity != 23*time.Hour {
+		t.Error("Expected: 10 23h0m0s, Received: ", lowerActivity, onsetLowerActivity, err)
+	}
+
+	// Invalid parameters
+	if _, _, err = ts.AverageDayHigherActivity(24); !errors.Is(err, ErrInvalidHours) {
+		t.Error("Expected: ErrInvalidHours, Received: ", err)
+	}
+	if _, _, err = AverageDayHigherActivity(10, dateTime[:1000], data[:1000]); !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+}
@@ -2,7 +2,6 @@
 package chronobiology
 
 import (
-	"errors"
 	"math"
 	"time"
 )
@@ -77,8 +76,8 @@ func floatEquals(a, b float64) bool {
 	return false
 }
 
-// Function used to decrease the epoch
-func decrease(dateTime []time.Time, data []float64, currentEpoch int, newEpoch int) (newDateTime []time.Time, newData []float64) {
+// Function used to decrease the epoch (the mask can be nil when all the records are valid)
+func decrease(dateTime []time.Time, data []float64, mask []bool, currentEpoch int, newEpoch int) (newDateTime []time.Time, newData []float64, newMask []bool) {
 
 	startDateTime := dateTime[0]
 	// The start time must be the same start time of the current recorded data
@@ -90,16 +89,21 @@ func decrease(dateTime []time.Time, data []float64, currentEpoch int, newEpoch i
 			startDateTime = startDateTime.Add(time.Duration(newEpoch) * time.Second)
 			newDateTime = append(newDateTime, startDateTime)
 			newData = append(newData, data[index1])
+			if mask != nil {
+				newMask = append(newMask, mask[index1])
+			}
 		}
 	}
 
 	return
 }
 
-// Function used to increase the epoch
-func increase(dateTime []time.Time, data []float64, currentEpoch int, newEpoch int) (newDateTime []time.Time, newData []float64) {
+// Function used to increase the epoch (the mask can be nil when all the records are valid).
+// The masked records are not used, and a new record is masked (and its value is NaN) when all its records are masked
+func increase(dateTime []time.Time, data []float64, mask []bool, currentEpoch int, newEpoch int) (newDateTime []time.Time, newData []float64, newMask []bool) {
 
 	var tempEpoch int
+	var validEpoch int
 	var tempData float64
 
 	startDateTime := dateTime[0]
@@ -108,17 +112,28 @@ func increase(dateTime []time.Time, data []float64, currentEpoch int, newEpoch i
 
 	for index1 := 0; index1 < len(dateTime); index1++ {
 		tempEpoch += currentEpoch
-		tempData += data[index1]
+		if mask == nil || !mask[index1] {
+			validEpoch += currentEpoch
+			tempData += data[index1]
+		}
 
 		if tempEpoch >= newEpoch {
 			startDateTime = startDateTime.Add(time.Duration(newEpoch) * time.Second)
 			newDateTime = append(newDateTime, startDateTime)
 
-			tempData = tempData / (float64(newEpoch) / float64(currentEpoch))
-			tempData = roundPlus(tempData, 4)
+			if validEpoch > 0 {
+				tempData = roundPlus(tempData/(float64(validEpoch)/float64(currentEpoch)), 4)
+			} else {
+				// The missing data is not counted as zero
+				tempData = math.NaN()
+			}
 			newData = append(newData, tempData)
+			if mask != nil {
+				newMask = append(newMask, validEpoch == 0)
+			}
 
 			tempEpoch = 0
+			validEpoch = 0
 			tempData = 0.0
 		}
 	}
@@ -128,18 +143,20 @@ func increase(dateTime []time.Time, data []float64, currentEpoch int, newEpoch i
 
 // Function used in the IS analysis to normalize the data to a specific epoch passed as parameter
 func normalizeDataIS(dateTime []time.Time, data []float64, minutes int) (temporaryDateTime []time.Time, temporaryData []float64, err error) {
+	temporaryDateTime, temporaryData, _, err = normalizeMaskedIS(dateTime, data, nil, minutes)
+	return
+}
+
+// Normalizes the data to a specific epoch (minutes) ignoring the masked records (the mask can be nil).
+// A new record is masked when all its records are masked
+func normalizeMaskedIS(dateTime []time.Time, data []float64, mask []bool, minutes int) (temporaryDateTime []time.Time, temporaryData []float64, temporaryMask []bool, err error) {
 
 	// Check the parameters
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
-		return
-	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
+	if err = checkSlices("normalizeDataIS", dateTime, data); err != nil {
 		return
 	}
 	if minutes <= 0 {
-		err = errors.New("MinutesInvalid")
+		err = newError("normalizeDataIS", ErrInvalidMinutes)
 		return
 	}
 
@@ -147,6 +164,7 @@ func normalizeDataIS(dateTime []time.Time, data []float64, minutes int) (tempora
 	if minutes == 1 {
 		temporaryDateTime = dateTime
 		temporaryData = data
+		temporaryMask = mask
 		return
 	}
 
@@ -169,13 +187,22 @@ func normalizeDataIS(dateTime []time.Time, data []float64, minutes int) (tempora
 		count := 0
 
 		for tempIndex := index; tempIndex < index+minutes; tempIndex++ {
-			tempData += data[tempIndex]
-			count++
+			if mask == nil || !mask[tempIndex] {
+				tempData += data[tempIndex]
+				count++
+			}
 		}
 
 		currentDateTime = currentDateTime.Add(time.Duration(minutes) * time.Minute)
 		temporaryDateTime = append(temporaryDateTime, currentDateTime)
-		temporaryData = append(temporaryData, (tempData / float64(count)))
+		if count > 0 {
+			temporaryData = append(temporaryData, (tempData / float64(count)))
+		} else {
+			temporaryData = append(temporaryData, 0.0)
+		}
+		if mask != nil {
+			temporaryMask = append(temporaryMask, count == 0)
+		}
 	}
 
 	return
@@ -197,113 +224,20 @@ func average(data []float64) float64 {
 
 // HigherActivity is responsible for find the highest activity average of the followed X hours (defined by parameter)
 func HigherActivity(hours int, dateTime []time.Time, data []float64) (higherActivity float64, onsetHigherActivity time.Time, err error) {
-
-	// Check the parameters
-	if hours == 0 {
-		err = errors.New("InvalidHours")
-		return
-	}
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
-		return
-	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
-		return
-	}
-	if dateTime[0].Add(time.Duration(hours) * time.Hour).After(dateTime[len(dateTime)-1]) {
-		err = errors.New("HoursHigher")
+	ts, err := wrapTimeSeries("HigherActivity", dateTime, data)
+	if err != nil {
 		return
 	}
-
-	for index := 0; index < len(dateTime); index++ {
-
-		startDateTime := dateTime[index]
-		finalDateTime := startDateTime.Add(time.Duration(hours) * time.Hour)
-		tempDateTime := startDateTime
-
-		if finalDateTime.After(dateTime[len(dateTime)-1]) {
-			break
-		}
-
-		currentActivity := 0.0
-		tempIndex := index
-		count := 0
-
-		for tempDateTime.Before(finalDateTime) {
-			currentActivity += data[tempIndex]
-			count += 1
-			tempIndex += 1
-
-			tempDateTime = dateTime[tempIndex]
-		}
-
-		currentActivity /= float64(count)
-
-		if currentActivity > higherActivity || floatEquals(higherActivity, 0.0) {
-			higherActivity = roundPlus(currentActivity, 4)
-			onsetHigherActivity = startDateTime
-		}
-	}
-
-	return
+	return ts.HigherActivity(hours)
 }
 
 // LowerActivity function is responsible for find the lowest activity average of the followed X hours (defined by parameter)
 func LowerActivity(hours int, dateTime []time.Time, data []float64) (lowerActivity float64, onsetLowerActivity time.Time, err error) {
-
-	// Check the parameters
-	if hours == 0 {
-		err = errors.New("InvalidHours")
-		return
-	}
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
-		return
-	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
+	ts, err := wrapTimeSeries("LowerActivity", dateTime, data)
+	if err != nil {
 		return
 	}
-	if dateTime[0].Add(time.Duration(hours) * time.Hour).After(dateTime[len(dateTime)-1]) {
-		err = errors.New("HoursHigher")
-		return
-	}
-
-	firstTime := true
-
-	for index := 0; index < len(dateTime); index++ {
-
-		startDateTime := dateTime[index]
-		finalDateTime := startDateTime.Add(time.Duration(hours) * time.Hour)
-		tempDateTime := startDateTime
-
-		if finalDateTime.After(dateTime[len(dateTime)-1]) {
-			break
-		}
-
-		currentActivity := 0.0
-		tempIndex := index
-		count := 0
-
-		for tempDateTime.Before(finalDateTime) {
-			currentActivity += data[tempIndex]
-			count += 1
-			tempIndex += 1
-
-			tempDateTime = dateTime[tempIndex]
-		}
-
-		currentActivity /= float64(count)
-
-		if currentActivity < lowerActivity || firstTime == true {
-			lowerActivity = roundPlus(currentActivity, 4)
-			onsetLowerActivity = startDateTime
-			firstTime = false
-		}
-	}
-
-	return
+	return ts.LowerActivity(hours)
 }
 
 // M10 - Function that finds the highest activity average of the followed 10 hours
@@ -321,7 +255,7 @@ func L5(dateTime []time.Time, data []float64) (lowerActivity float64, onsetLower
 // RelativeAmplitude is responsible for calculate the relative amplitude based on the formula (M10-L5)/(M10+L5)
 func RelativeAmplitude(highestAverage float64, lowestAverage float64) (RA float64, err error) {
 	if highestAverage == 0.0 && lowestAverage == 0.0 {
-		err = errors.New("NullValues")
+		err = newError("RelativeAmplitude", ErrNullValues)
 		return
 	}
 
@@ -332,74 +266,17 @@ func RelativeAmplitude(highestAverage float64, lowestAverage float64) (RA float6
 
 // IntradailyVariability is responsible for calculate the intradaily variability
 func IntradailyVariability(dateTime []time.Time, data []float64) (iv []float64, err error) {
-
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
-		return
-	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
+	ts, err := wrapTimeSeries("IntradailyVariability", dateTime, data)
+	if err != nil {
 		return
 	}
-	if secondsTo(dateTime[0], dateTime[len(dateTime)-1]) < (2 * 60 * 60) {
-		err = errors.New("LessThan2Hours")
-	}
-
-	// The zero position is allocated to store the average value of the iv vector
-	iv = append(iv, 0.0)
-
-	for mainIndex := 1; mainIndex <= 60; mainIndex++ {
-
-		_, tempData, err := ConvertDataBasedOnEpoch(dateTime, data, (mainIndex * 60))
-
-		if err != nil {
-			err = errors.New("ConvertDataBasedOnEpoch error")
-			return nil, err
-		}
-
-		if len(tempData) > 0 {
-
-			average := average(tempData)
-
-			// Calculates the numerator
-			var numerator float64
-			for index := 1; index < len(tempData); index++ {
-				tempValue := tempData[index] - tempData[index-1]
-				numerator += math.Pow(tempValue, 2)
-			}
-			numerator = numerator * float64(len(tempData))
-
-			// Calculates the denominator
-			var denominator float64
-			for index := 0; index < len(tempData); index++ {
-				tempValue := average - tempData[index]
-				denominator += math.Pow(tempValue, 2)
-			}
-			denominator = denominator * (float64(len(tempData)) - 1.0)
-
-			result := roundPlus((numerator / denominator), 4)
-			iv = append(iv, result)
-
-		} else {
-			iv = append(iv, 0.0)
-		}
-	}
-
-	// Calculates the IV average
-	var average float64
-	for index := 1; index < len(iv); index++ {
-		average += iv[index]
-	}
-	average = average / float64(len(iv)-1)
-	iv[0] = average
-
-	return
+	return ts.IntradailyVariability()
 }
 
 // FindEpoch is used to find the epoch of the time series (in seconds)
 func FindEpoch(dateTime []time.Time) (epoch int) {
 
-	if len(dateTime) == 0 {
+	if len(dateTime) < 2 {
 		return
 	}
 
@@ -427,303 +304,83 @@ func FindEpoch(dateTime []time.Time) (epoch int) {
 
 // ConvertDataBasedOnEpoch convert the data and dateTime slices to the new epoch passed by parameter
 func ConvertDataBasedOnEpoch(dateTime []time.Time, data []float64, newEpoch int) (newDateTime []time.Time, newData []float64, err error) {
-
-	// Check the parameters
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
-		return
-	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
-		return
-	}
-	if newEpoch == 0 {
-		err = errors.New("InvalidEpoch")
+	ts, err := wrapTimeSeries("ConvertDataBasedOnEpoch", dateTime, data)
+	if err != nil {
 		return
 	}
-
-	currentEpoch := FindEpoch(dateTime)
-
-	// Could not find the epoch
-	if currentEpoch == 0 {
-		err = errors.New("InvalidEpoch")
+	newTs, err := ts.ConvertDataBasedOnEpoch(newEpoch)
+	if err != nil {
 		return
 	}
-	if newEpoch == currentEpoch {
-		return dateTime, data, nil
-	}
-
-	// If the new Epoch is not divisible or multipliable by the currentEpoch
-	// It needs to be decreased to 1 second to then increase to the newEpoch
-	if (newEpoch > currentEpoch && newEpoch%currentEpoch != 0) ||
-		(currentEpoch > newEpoch && currentEpoch%newEpoch != 0) {
-
-		// Decrease to 1 second
-		dateTime, data = decrease(dateTime, data, currentEpoch, 1)
-
-		// Increase to the newEpoch
-		newDateTime, newData = increase(dateTime, data, 1, newEpoch)
-
-	} else {
-		// Increase
-		if newEpoch > currentEpoch {
-			newDateTime, newData = increase(dateTime, data, currentEpoch, newEpoch)
-
-			// Decrease
-		} else {
-			newDateTime, newData = decrease(dateTime, data, currentEpoch, newEpoch)
-		}
-	}
-
-	return
+	return newTs.DateTime, newTs.Data, nil
 }
 
 // FilterDataByDateTime was created to filter the data based on the startTime and endTime passed as parameter
 func FilterDataByDateTime(dateTime []time.Time, data []float64, startTime time.Time, endTime time.Time) (newDateTime []time.Time, newData []float64, err error) {
-
-	// Check the parameters
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
+	ts, err := wrapTimeSeries("FilterDataByDateTime", dateTime, data)
+	if err != nil {
 		return
 	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
+	newTs, err := ts.FilterDataByDateTime(startTime, endTime)
+	if err != nil {
 		return
 	}
-	if endTime.Before(startTime) {
-		err = errors.New("InvalidTimeRange")
-		return
-	}
-
-	// Filter the data based on the startTime and endTime
-	for index := 0; index < len(dateTime); index++ {
-
-		if (dateTime[index].After(startTime) || dateTime[index].Equal(startTime)) &&
-			(dateTime[index].Before(endTime) || dateTime[index].Equal(endTime)) {
-
-			newDateTime = append(newDateTime, dateTime[index])
-			newData = append(newData, data[index])
-		}
-	}
-
-	return
+	return newTs.DateTime, newTs.Data, nil
 }
 
 // InterdailyStability calculates the interdaily stability
 func InterdailyStability(dateTime []time.Time, data []float64) (is []float64, err error) {
-
-	// Check the parameters
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
-		return
-	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
-		return
-	}
-	if secondsTo(dateTime[0], dateTime[len(dateTime)-1]) < (48 * 60 * 60) {
-		err = errors.New("LessThan2Days")
+	ts, err := wrapTimeSeries("InterdailyStability", dateTime, data)
+	if err != nil {
 		return
 	}
+	return ts.InterdailyStability()
+}
 
-	currentEpoch := FindEpoch(dateTime)
-
-	// Could not find the epoch
-	if currentEpoch == 0 {
-		err = errors.New("InvalidEpoch")
+// InterdailyStabilityPeriod calculates the interdaily stability using the period passed by parameter (DefaultPeriod when zero) as the "day"
+func InterdailyStabilityPeriod(period time.Duration, dateTime []time.Time, data []float64) (is []float64, err error) {
+	ts, err := wrapTimeSeries("InterdailyStabilityPeriod", dateTime, data)
+	if err != nil {
 		return
 	}
-
-	if currentEpoch != 60 {
-		newDateTime, newData, _ := ConvertDataBasedOnEpoch(dateTime, data, 60)
-
-		dateTime = newDateTime
-		data = newData
-	}
-
-	// The data should be divisible by 1440 (entire day)
-	for len(dateTime)%1440 != 0 {
-		// Remove the last data
-		dateTime = dateTime[:len(dateTime)-1]
-		data = data[:len(data)-1]
-	}
-
-	// The zero position is allocated to store the average value of the IS vector
-	is = append(is, 0.0)
-
-	// Calculate all 60 IS values
-	for isIndex := 1; isIndex <= 60; isIndex++ {
-
-		if 1440%isIndex == 0 {
-
-			// Normalizes data to the new epoch (minutes)
-			temporaryDateTime, temporaryData, _ := normalizeDataIS(dateTime, data, isIndex)
-
-			// Calculate the average day
-			_, averageDayData, _ := AverageDay(temporaryDateTime, temporaryData)
-
-			// Get the new N (length)
-			n := len(temporaryData)
-
-			// Calculate the number of points per day
-			p := len(averageDayData)
-			//p := 1440 / isIndex
-
-			// Calculate the new average (Xm)
-			average := average(temporaryData)
-
-			numerator := 0.0
-			denominator := 0.0
-
-			// The "h" value represents the same "h" from the IS calculation formula
-			for h := 0; h < p; h++ {
-				numerator += math.Pow((averageDayData[h] - average), 2)
-			}
-
-			// The "i" value represents the same "i" from the IS calculation formula
-			for i := 0; i < n; i++ {
-				denominator += math.Pow((temporaryData[i] - average), 2)
-			}
-
-			numerator = float64(n) * numerator
-			denominator = float64(p) * denominator
-
-			// Prevent NaN
-			if denominator == 0 {
-				is = append(is, -1.0)
-			} else {
-				is = append(is, (numerator / denominator))
-			}
-		} else {
-			// Append -1 in the positions that will not be used
-			is = append(is, -1.0)
-		}
-	}
-
-	// Calculates the IS average of all "valid" values
-	average := 0.0
-	count := 0
-
-	for index := 1; index < len(is); index++ {
-		if is[index] > -1.0 {
-			average += is[index]
-			count++
-		}
-	}
-
-	if count > 0 {
-		is[0] = average / float64(count)
-	} else {
-		is[0] = -1.0
-	}
-
-	return
+	return ts.InterdailyStabilityPeriod(period)
 }
 
 // FillGapsInData is responsible for searches for gaps in the time series and fills it with a specific value passed as parameter (usually zero)
 func FillGapsInData(dateTime []time.Time, data []float64, value float64) (newDateTime []time.Time, newData []float64, err error) {
-
-	// Check the parameters
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
+	ts, err := wrapTimeSeries("FillGapsInData", dateTime, data)
+	if err != nil {
 		return
 	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
+	newTs, err := ts.FillGapsInData(value)
+	if err != nil {
 		return
 	}
-
-	currentEpoch := FindEpoch(dateTime)
-
-	// Could not find the epoch
-	if currentEpoch == 0 {
-		err = errors.New("InvalidEpoch")
-		return
-	}
-
-	for index := 0; index < len(dateTime)-1; index++ {
-
-		newDateTime = append(newDateTime, dateTime[index])
-		newData = append(newData, data[index])
-
-		// If this condition is true, then this is a gap
-		if secondsTo(dateTime[index], dateTime[index+1]) >= (currentEpoch * 2) {
-
-			tempDateTime := dateTime[index]
-			count := (secondsTo(dateTime[index], dateTime[index+1]) / currentEpoch) - 1
-
-			for tempIndex := 0; tempIndex < count; tempIndex++ {
-				tempDateTime = tempDateTime.Add(time.Duration(currentEpoch) * time.Second)
-				newDateTime = append(newDateTime, tempDateTime)
-				newData = append(newData, value)
-			}
-		}
-	}
-
-	newDateTime = append(newDateTime, dateTime[len(dateTime)-1])
-	newData = append(newData, data[len(dateTime)-1])
-
-	return
+	return newTs.DateTime, newTs.Data, nil
 }
 
 // AverageDay creates an average day based on the time series.
 func AverageDay(dateTime []time.Time, data []float64) (newDateTime []time.Time, newData []float64, err error) {
-
-	// Check the parameters
-	if len(dateTime) == 0 || len(data) == 0 {
-		err = errors.New("Empty")
+	ts, err := wrapTimeSeries("AverageDay", dateTime, data)
+	if err != nil {
 		return
 	}
-	if len(dateTime) != len(data) {
-		err = errors.New("DifferentSize")
+	newTs, err := ts.AverageDay()
+	if err != nil {
 		return
 	}
+	return newTs.DateTime, newTs.Data, nil
+}
 
-	currentEpoch := FindEpoch(dateTime)
-
-	// Could not find the epoch
-	if currentEpoch == 0 {
-		err = errors.New("InvalidEpoch")
+// AverageCycle creates an average cycle folding the time series modulo the period passed by parameter (DefaultPeriod when zero)
+func AverageCycle(period time.Duration, dateTime []time.Time, data []float64) (newDateTime []time.Time, newData []float64, err error) {
+	ts, err := wrapTimeSeries("AverageCycle", dateTime, data)
+	if err != nil {
 		return
 	}
-
-	if secondsTo(dateTime[0], dateTime[len(dateTime)-1]) < (24 * 60 * 60) {
-		err = errors.New("LessThan1Day")
+	newTs, err := ts.AverageCycle(period)
+	if err != nil {
 		return
 	}
-
-	gapValue := -999.999
-	dateTime, data, _ = FillGapsInData(dateTime, data, gapValue)
-
-	pointsPerDay := (60 * 1440) / currentEpoch
-
-	var countPoints []int
-
-	for index := 0; index < pointsPerDay; index++ {
-		newData = append(newData, 0.0)
-		countPoints = append(countPoints, 0)
-	}
-
-	pointIndex := 0
-	for index := 0; index < len(data); index++ {
-		if pointIndex >= pointsPerDay {
-			pointIndex = 0
-		}
-
-		if !floatEquals(data[index], gapValue) {
-			newData[pointIndex] += data[index]
-			countPoints[pointIndex] += 1
-		}
-
-		pointIndex++
-	}
-
-	tempDateTime := dateTime[0]
-	for index := 0; index < len(newData); index++ {
-		newDateTime = append(newDateTime, tempDateTime)
-		tempDateTime = tempDateTime.Add(time.Duration(currentEpoch) * time.Second)
-		newData[index] = roundPlus((newData[index] / float64(countPoints[index])), 4)
-	}
-
-	return
+	return newTs.DateTime, newTs.Data, nil
 }
@@ -930,10 +930,10 @@ func TestAverageDay(t *testing.T) {
 		newData     []float64
 		err         error
 	}{
-		{nil, nil, nil, nil, errors.New("Empty")},
-		{tempDateTime, tempData, nil, nil, errors.New("LessThan1Day")},
-		{[]time.Time{currentDateTime}, []float64{35.5, 35.5}, nil, nil, errors.New("DifferentSize")},
-		{[]time.Time{currentDateTime, currentDateTime}, []float64{35.5, 35.5}, nil, nil, errors.New("InvalidEpoch")},
+		{nil, nil, nil, nil, ErrEmpty},
+		{tempDateTime, tempData, nil, nil, ErrInsufficientDuration},
+		{[]time.Time{currentDateTime}, []float64{35.5, 35.5}, nil, nil, ErrDifferentSize},
+		{[]time.Time{currentDateTime, currentDateTime}, []float64{35.5, 35.5}, nil, nil, ErrInvalidEpoch},
 		{dateTime1, data1, newDateTime1, newData1, nil},
 		{dateTime2, data2, newDateTime2, newData2, nil},
 	}
@@ -955,10 +955,10 @@ func TestAverageDay(t *testing.T) {
 				"Expected error = nil",
 				"Received error = ", err,
 			)
-		} else if table.err != nil && err == nil {
+		} else if table.err != nil && !errors.Is(err, table.err) {
 			t.Error(
 				"Expected error = ", table.err,
-				"Received error = nil",
+				"Received error = ", err,
 			)
 		}
 	}
@@ -0,0 +1,413 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultPeriod is the period used by the rhythm analyses when the period passed by parameter is zero
+const DefaultPeriod = 24 * time.Hour
+
+// Confidence level of the confidence intervals
+const confidenceLevel = 0.95
+
+// CosinorResult stores the parameters and statistics of a single-component cosinor fit:
+// data = MESOR + Amplitude * cos(2 * pi * t / Period + Acrophase)
+type CosinorResult struct {
+	// Period is the period used by the fit
+	Period time.Duration
+	// MESOR is the Midline Estimating Statistic Of Rhythm (rhythm adjusted mean)
+	MESOR float64
+	// Amplitude is half the difference between the peak and the trough of the fitted cosine
+	Amplitude float64
+	// Acrophase is the phase of the peak in degrees, from 0 to -360 (-360 degrees is the whole period)
+	Acrophase float64
+	// AcrophaseTime is the time of the peak elapsed since midnight (the clock time when the period is 24 hours)
+	AcrophaseTime time.Duration
+	// MESORSE, AmplitudeSE and AcrophaseSE (degrees) are the standard errors of the parameters
+	MESORSE, AmplitudeSE, AcrophaseSE float64
+	// MESORCI, AmplitudeCI and AcrophaseCI (degrees) are the 95% confidence intervals (lower and upper limits)
+	MESORCI, AmplitudeCI, AcrophaseCI [2]float64
+	// F is the statistic of the zero-amplitude test and P its p-value
+	F, P float64
+	// RSquared is the fraction of the variance explained by the fit
+	RSquared float64
+	// N is the number of records used by the fit
+	N int
+}
+
+// Returns the time (seconds) of each timestamp elapsed since the midnight of the first timestamp,
+// so the phases are related to the clock time
+func elapsedSinceMidnight(dateTime []time.Time) (seconds []float64) {
+	year, month, day := dateTime[0].Date()
+	midnight := time.Date(year, month, day, 0, 0, 0, 0, dateTime[0].Location())
+
+	seconds = make([]float64, len(dateTime))
+	for index := 0; index < len(dateTime); index++ {
+		seconds[index] = dateTime[index].Sub(midnight).Seconds()
+	}
+	return
+}
+
+// Converts the cosine and sine coefficients to amplitude and acrophase (radians, from 0 to -2pi)
+func amplitudeAcrophase(beta float64, gamma float64) (amplitude float64, acrophase float64) {
+	amplitude = math.Hypot(beta, gamma)
+	acrophase = math.Atan2(-gamma, beta)
+	if acrophase > 0 {
+		acrophase -= 2.0 * math.Pi
+	}
+	return
+}
+
+// Converts an acrophase (degrees) to the time elapsed since the start of the period
+func acrophaseTime(acrophase float64, period time.Duration) time.Duration {
+	return time.Duration(-acrophase / 360.0 * float64(period))
+}
+
+// Cosinor fits a single-component cosinor (least squares on the cosine and sine terms) with the period passed by parameter
+// (DefaultPeriod when zero). The masked records and the NaN values are not used, and the sampling does not need to be regular
+func (ts *TimeSeries) Cosinor(period time.Duration) (result CosinorResult, err error) {
+
+	// Check the parameters
+	if err = ts.check("Cosinor"); err != nil {
+		return
+	}
+	if period < 0 {
+		err = newError("Cosinor", ErrInvalidPeriod)
+		return
+	}
+	if period == 0 {
+		period = DefaultPeriod
+	}
+
+	omega := 2.0 * math.Pi / period.Seconds()
+	seconds := elapsedSinceMidnight(ts.DateTime)
+
+	var design [][]float64
+	var data []float64
+	for index := 0; index < len(ts.Data); index++ {
+		if ts.valid(index) {
+			design = append(design, []float64{1.0, math.Cos(omega * seconds[index]), math.Sin(omega * seconds[index])})
+			data = append(data, ts.Data[index])
+		}
+	}
+
+	n := len(data)
+	if n < 4 {
+		err = newError("Cosinor", ErrInsufficientData)
+		return
+	}
+
+	coefficients, inverse, rss, ok := leastSquares(design, data)
+	if !ok {
+		err = newError("Cosinor", ErrInsufficientData)
+		return
+	}
+
+	mesor, beta, gamma := coefficients[0], coefficients[1], coefficients[2]
+	amplitude, acrophase := amplitudeAcrophase(beta, gamma)
+
+	// Total sum of squares
+	mean := average(data)
+	tss := 0.0
+	for _, value := range data {
+		tss += (value - mean) * (value - mean)
+	}
+
+	df := float64(n - 3)
+	variance := rss / df
+
+	// Covariance of the coefficients and standard errors (delta method)
+	c11, c12, c22 := variance*inverse[1][1], variance*inverse[1][2], variance*inverse[2][2]
+	mesorSE := math.Sqrt(variance * inverse[0][0])
+	amplitudeSE := math.Sqrt(beta*beta*c11+2.0*beta*gamma*c12+gamma*gamma*c22) / amplitude
+	acrophaseSE := math.Sqrt(gamma*gamma*c11-2.0*beta*gamma*c12+beta*beta*c22) / (amplitude * amplitude)
+
+	quantile := studentQuantile(1.0-(1.0-confidenceLevel)/2.0, df)
+	degrees := 180.0 / math.Pi
+
+	result = CosinorResult{
+		Period:        period,
+		MESOR:         mesor,
+		Amplitude:     amplitude,
+		Acrophase:     acrophase * degrees,
+		AcrophaseTime: acrophaseTime(acrophase*degrees, period),
+		MESORSE:       mesorSE,
+		AmplitudeSE:   amplitudeSE,
+		AcrophaseSE:   acrophaseSE * degrees,
+		MESORCI:       [2]float64{mesor - quantile*mesorSE, mesor + quantile*mesorSE},
+		AmplitudeCI:   [2]float64{amplitude - quantile*amplitudeSE, amplitude + quantile*amplitudeSE},
+		AcrophaseCI:   [2]float64{(acrophase - quantile*acrophaseSE) * degrees, (acrophase + quantile*acrophaseSE) * degrees},
+		N:             n,
+	}
+
+	if tss > 0 {
+		result.RSquared = 1.0 - rss/tss
+	}
+
+	// Zero-amplitude test (F with 2 and n - 3 degrees of freedom)
+	if rss > 0 {
+		result.F = ((tss - rss) / 2.0) / variance
+		result.P = 1.0 - fisherCDF(result.F, 2.0, df)
+	} else {
+		result.F = math.Inf(1)
+	}
+
+	return
+}
+
+// Cosinor fits a single-component cosinor with the period passed by parameter (DefaultPeriod when zero)
+func Cosinor(period time.Duration, dateTime []time.Time, data []float64) (result CosinorResult, err error) {
+	ts, err := wrapTimeSeries("Cosinor", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.Cosinor(period)
+}
+
+// Criterion represents an information criterion used to select a model
+type Criterion int
+
+// Information criteria used to select a model (the lowest value is the best model)
+const (
+	// AIC is the Akaike Information Criterion
+	AIC Criterion = iota
+	// BIC is the Bayesian Information Criterion
+	BIC
+)
+
+// Number of points used to find the orthophase and the bathyphase of a multi-component cosinor
+const phaseSteps = 14400
+
+// CosinorComponent stores the parameters of a component of a multi-component cosinor
+type CosinorComponent struct {
+	// Period is the period of the component
+	Period time.Duration
+	// Amplitude is half the difference between the peak and the trough of the component
+	Amplitude float64
+	// Acrophase is the phase of the peak of the component in degrees, from 0 to -360
+	Acrophase float64
+	// AcrophaseTime is the time of the peak of the component elapsed since midnight
+	AcrophaseTime time.Duration
+}
+
+// MultiCosinorResult stores the parameters and statistics of a multi-component cosinor fit:
+// data = MESOR + sum(Amplitude * cos(2 * pi * t / Period + Acrophase))
+type MultiCosinorResult struct {
+	// MESOR is the Midline Estimating Statistic Of Rhythm (rhythm adjusted mean)
+	MESOR float64
+	// Components stores the parameters of each component (in the same order as the periods)
+	Components []CosinorComponent
+	// Orthophase is the time of the peak of the fitted curve elapsed since midnight (searched within the longest period)
+	Orthophase time.Duration
+	// Bathyphase is the time of the trough of the fitted curve elapsed since midnight (searched within the longest period)
+	Bathyphase time.Duration
+	// PeakToTrough is the difference between the peak and the trough of the fitted curve
+	PeakToTrough float64
+	// F is the statistic of the zero-amplitude test (all components) and P its p-value
+	F, P float64
+	// RSquared is the fraction of the variance explained by the fit
+	RSquared float64
+	// RSS is the residual sum of squares
+	RSS float64
+	// AIC and BIC are the information criteria of the fit
+	AIC, BIC float64
+	// N is the number of records used by the fit
+	N int
+}
+
+// Evaluates the fitted curve of a multi-component cosinor at the time (seconds) passed by parameter
+func (result *MultiCosinorResult) evaluate(seconds float64) (value float64) {
+	value = result.MESOR
+	for _, component := range result.Components {
+		omega := 2.0 * math.Pi / component.Period.Seconds()
+		value += component.Amplitude * math.Cos(omega*seconds+component.Acrophase*math.Pi/180.0)
+	}
+	return
+}
+
+// Criterion returns the value of the information criterion passed by parameter
+func (result *MultiCosinorResult) Criterion(criterion Criterion) float64 {
+	if criterion == BIC {
+		return result.BIC
+	}
+	return result.AIC
+}
+
+// MultiCosinor fits a multi-component cosinor with the periods passed by parameter (e.g. 24, 12 and 8 hours).
+// When no period is passed, DefaultPeriod is used. The masked records and the NaN values are not used,
+// and the sampling does not need to be regular
+func (ts *TimeSeries) MultiCosinor(periods ...time.Duration) (result MultiCosinorResult, err error) {
+
+	// Check the parameters
+	if err = ts.check("MultiCosinor"); err != nil {
+		return
+	}
+	if len(periods) == 0 {
+		periods = []time.Duration{DefaultPeriod}
+	}
+	longest := periods[0]
+	for _, period := range periods {
+		if period <= 0 {
+			err = newError("MultiCosinor", ErrInvalidPeriod)
+			return
+		}
+		if period > longest {
+			longest = period
+		}
+	}
+
+	seconds := elapsedSinceMidnight(ts.DateTime)
+
+	var design [][]float64
+	var data []float64
+	for index := 0; index < len(ts.Data); index++ {
+		if ts.valid(index) {
+			row := []float64{1.0}
+			for _, period := range periods {
+				omega := 2.0 * math.Pi / period.Seconds()
+				row = append(row, math.Cos(omega*seconds[index]), math.Sin(omega*seconds[index]))
+			}
+			design = append(design, row)
+			data = append(data, ts.Data[index])
+		}
+	}
+
+	n := len(data)
+	k := 1 + 2*len(periods)
+	if n <= k {
+		err = newError("MultiCosinor", ErrInsufficientData)
+		return
+	}
+
+	coefficients, _, rss, ok := leastSquares(design, data)
+	if !ok {
+		err = newError("MultiCosinor", ErrInsufficientData)
+		return
+	}
+
+	result = MultiCosinorResult{MESOR: coefficients[0], RSS: rss, N: n}
+
+	for index, period := range periods {
+		amplitude, acrophase := amplitudeAcrophase(coefficients[1+2*index], coefficients[2+2*index])
+		result.Components = append(result.Components, CosinorComponent{
+			Period:        period,
+			Amplitude:     amplitude,
+			Acrophase:     acrophase * 180.0 / math.Pi,
+			AcrophaseTime: acrophaseTime(acrophase*180.0/math.Pi, period),
+		})
+	}
+
+	// Peak and trough of the fitted curve
+	step := longest.Seconds() / phaseSteps
+	peak, trough := math.Inf(-1), math.Inf(1)
+	for index := 0; index < phaseSteps; index++ {
+		value := result.evaluate(float64(index) * step)
+		if value > peak {
+			peak = value
+			result.Orthophase = time.Duration(float64(index) * step * float64(time.Second))
+		}
+		if value < trough {
+			trough = value
+			result.Bathyphase = time.Duration(float64(index) * step * float64(time.Second))
+		}
+	}
+	result.PeakToTrough = peak - trough
+
+	// Total sum of squares
+	mean := average(data)
+	tss := 0.0
+	for _, value := range data {
+		tss += (value - mean) * (value - mean)
+	}
+	if tss > 0 {
+		result.RSquared = 1.0 - rss/tss
+	}
+
+	// Zero-amplitude test (F with 2m and n - 2m - 1 degrees of freedom)
+	df1, df2 := float64(k-1), float64(n-k)
+	if rss > 0 {
+		result.F = ((tss - rss) / df1) / (rss / df2)
+		result.P = 1.0 - fisherCDF(result.F, df1, df2)
+	} else {
+		result.F = math.Inf(1)
+	}
+
+	// Information criteria (Gaussian likelihood)
+	likelihood := float64(n) * math.Log(rss/float64(n))
+	result.AIC = likelihood + 2.0*float64(k)
+	result.BIC = likelihood + float64(k)*math.Log(float64(n))
+
+	return
+}
+
+// CosinorHarmonics fits a multi-component cosinor with the fundamental period passed by parameter (DefaultPeriod when zero)
+// and its harmonics, e.g. 3 harmonics of 24 hours are the periods 24, 12 and 8 hours
+func (ts *TimeSeries) CosinorHarmonics(period time.Duration, harmonics int) (result MultiCosinorResult, err error) {
+
+	if period < 0 {
+		err = newError("CosinorHarmonics", ErrInvalidPeriod)
+		return
+	}
+	if period == 0 {
+		period = DefaultPeriod
+	}
+	if harmonics <= 0 {
+		err = newError("CosinorHarmonics", ErrInvalidHarmonics)
+		return
+	}
+
+	var periods []time.Duration
+	for harmonic := 1; harmonic <= harmonics; harmonic++ {
+		periods = append(periods, period/time.Duration(harmonic))
+	}
+	return ts.MultiCosinor(periods...)
+}
+
+// SelectHarmonics fits the multi-component cosinors with 1 up to maxHarmonics harmonics of the fundamental period
+// (DefaultPeriod when zero) and returns the best fit according to the information criterion passed by parameter
+func (ts *TimeSeries) SelectHarmonics(period time.Duration, maxHarmonics int, criterion Criterion) (result MultiCosinorResult, err error) {
+
+	if criterion != AIC && criterion != BIC {
+		err = newError("SelectHarmonics", ErrInvalidCriterion)
+		return
+	}
+	if maxHarmonics <= 0 {
+		err = newError("SelectHarmonics", ErrInvalidHarmonics)
+		return
+	}
+
+	for harmonics := 1; harmonics <= maxHarmonics; harmonics++ {
+		current, fitErr := ts.CosinorHarmonics(period, harmonics)
+		if fitErr != nil {
+			// The first model must be fitted, the others are limited by the number of records
+			if harmonics == 1 {
+				return result, fitErr
+			}
+			break
+		}
+		if harmonics == 1 || current.Criterion(criterion) < result.Criterion(criterion) {
+			result = current
+		}
+	}
+
+	return
+}
+
+// MultiCosinor fits a multi-component cosinor with the periods passed by parameter (DefaultPeriod when empty)
+func MultiCosinor(periods []time.Duration, dateTime []time.Time, data []float64) (result MultiCosinorResult, err error) {
+	ts, err := wrapTimeSeries("MultiCosinor", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.MultiCosinor(periods...)
+}
+
+// SelectHarmonics finds the best number of harmonics (up to maxHarmonics) of the fundamental period according to the criterion
+func SelectHarmonics(period time.Duration, maxHarmonics int, criterion Criterion, dateTime []time.Time, data []float64) (result MultiCosinorResult, err error) {
+	ts, err := wrapTimeSeries("SelectHarmonics", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.SelectHarmonics(period, maxHarmonics, criterion)
+}
@@ -0,0 +1,226 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Creates a deterministic noise between -1 and 1
+func noise(index int) float64 {
+	value := math.Sin(float64(index)*12.9898) * 43758.5453
+	return 2.0*(value-math.Floor(value)) - 1.0
+}
+
+// Creates 3 days of irregular samples of a cosine (period of 24 hours) with peak at 15h
+func cosineData(mesor float64, amplitude float64, noiseLevel float64) (dateTime []time.Time, data []float64) {
+	utc, _ := time.LoadLocation("UTC")
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	for index := 0; index < 3*48; index++ {
+		// Irregular sampling: some records are missing and the others are shifted
+		if index%7 == 3 {
+			continue
+		}
+		current := start.Add(time.Duration(index)*30*time.Minute + time.Duration(index%5)*time.Minute)
+		hours := current.Sub(start).Hours()
+		dateTime = append(dateTime, current)
+		data = append(data, mesor+amplitude*math.Cos(2.0*math.Pi*(hours-15.0)/24.0)+noiseLevel*noise(index))
+	}
+	return
+}
+
+func TestCosinor(t *testing.T) {
+
+	// Without noise the fit is exact
+	dateTime, data := cosineData(100, 30, 0)
+	result, err := Cosinor(0, dateTime, data)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if math.Abs(result.MESOR-100) > 1e-6 || math.Abs(result.Amplitude-30) > 1e-6 ||
+		math.Abs(result.Acrophase+225) > 1e-6 || (result.AcrophaseTime-15*time.Hour).Seconds() > 1e-3 ||
+		result.Period != 24*time.Hour || result.N != len(data) || result.P != 0 {
+		t.Error("Unexpected result: ", result)
+	}
+
+	// With noise the confidence intervals contain the parameters
+	dateTime, data = cosineData(100, 30, 10)
+	ts, _ := NewTimeSeries(dateTime, data)
+	result, err = ts.Cosinor(24 * time.Hour)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	// Table tests
+	var tTests = []struct {
+		name     string
+		value    float64
+		ci       [2]float64
+		se       float64
+		expected float64
+	}{
+		{"MESOR", result.MESOR, result.MESORCI, result.MESORSE, 100},
+		{"Amplitude", result.Amplitude, result.AmplitudeCI, result.AmplitudeSE, 30},
+		{"Acrophase", result.Acrophase, result.AcrophaseCI, result.AcrophaseSE, -225},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if table.ci[0] > table.expected || table.ci[1] < table.expected || table.se <= 0 ||
+			!floatEquals(roundPlus(table.ci[1]-table.value, 6), roundPlus(table.value-table.ci[0], 6)) {
+			t.Error(
+				"Parameter: ", table.name,
+				"Expected: ", table.expected,
+				"Received: ", table.value, table.ci,
+			)
+		}
+	}
+	if result.P > 0.001 || result.F < 100 || result.RSquared < 0.8 {
+		t.Error("Expected a significant rhythm. Received: ", result.F, result.P, result.RSquared)
+	}
+
+	// Without rhythm
+	dateTime, data = cosineData(100, 0, 10)
+	result, _ = Cosinor(0, dateTime, data)
+	if result.P < 0.05 {
+		t.Error("Expected a non-significant rhythm. Received: ", result.P)
+	}
+
+	// The masked records are not used
+	ts.Mask = make([]bool, ts.Len())
+	for index := 0; index < ts.Len()-3; index++ {
+		ts.Mask[index] = true
+	}
+	if _, err = ts.Cosinor(0); !errors.Is(err, ErrInsufficientData) {
+		t.Error("Expected: ErrInsufficientData, Received: ", err)
+	}
+
+	if _, err = ts.Cosinor(-time.Hour); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = Cosinor(0, nil, nil); !errors.Is(err, ErrEmpty) {
+		t.Error("Expected: ErrEmpty, Received: ", err)
+	}
+}
+
+// Creates 3 days of samples (15 minutes) of a sum of cosines: 24 hours with peak at 14h and 12 hours with peak at 4h
+func bimodalData(noiseLevel float64) (dateTime []time.Time, data []float64, curve func(hours float64) float64) {
+	utc, _ := time.LoadLocation("UTC")
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	curve = func(hours float64) float64 {
+		return 50.0 + 20.0*math.Cos(2.0*math.Pi*(hours-14.0)/24.0) + 10.0*math.Cos(2.0*math.Pi*(hours-4.0)/12.0)
+	}
+
+	for index := 0; index < 3*96; index++ {
+		current := start.Add(time.Duration(index) * 15 * time.Minute)
+		dateTime = append(dateTime, current)
+		data = append(data, curve(current.Sub(start).Hours())+noiseLevel*noise(index))
+	}
+	return
+}
+
+func TestMultiCosinor(t *testing.T) {
+
+	dateTime, data, curve := bimodalData(0)
+
+	result, err := MultiCosinor([]time.Duration{24 * time.Hour, 12 * time.Hour}, dateTime, data)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	// Table tests
+	var tTests = []struct {
+		component     int
+		amplitude     float64
+		acrophase     float64
+		acrophaseTime time.Duration
+	}{
+		{0, 20, -210, 14 * time.Hour},
+		{1, 10, -120, 4 * time.Hour},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		component := result.Components[table.component]
+		if math.Abs(component.Amplitude-table.amplitude) > 1e-6 || math.Abs(component.Acrophase-table.acrophase) > 1e-6 ||
+			math.Abs((component.AcrophaseTime-table.acrophaseTime).Seconds()) > 1e-3 {
+			t.Error(
+				"Component: ", table.component,
+				"Expected: ", table.amplitude, table.acrophase, table.acrophaseTime,
+				"Received: ", component.Amplitude, component.Acrophase, component.AcrophaseTime,
+			)
+		}
+	}
+
+	// Peak and trough of the curve (brute force with a resolution of 6 seconds)
+	var peak, trough float64
+	var orthophase, bathyphase time.Duration
+	for step := 0; step < 14400; step++ {
+		value := curve(float64(step) / 600.0)
+		if step == 0 || value > peak {
+			peak, orthophase = value, time.Duration(step)*6*time.Second
+		}
+		if step == 0 || value < trough {
+			trough, bathyphase = value, time.Duration(step)*6*time.Second
+		}
+	}
+	if !floatEquals(roundPlus(result.MESOR, 6), 50) || result.Orthophase != orthophase || result.Bathyphase != bathyphase ||
+		!floatEquals(roundPlus(result.PeakToTrough, 6), roundPlus(peak-trough, 6)) {
+		t.Error(
+			"Expected: ", orthophase, bathyphase, peak-trough,
+			"Received: ", result.Orthophase, result.Bathyphase, result.PeakToTrough,
+		)
+	}
+
+	// A single cosine does not explain the afternoon dip
+	single, _ := Cosinor(0, dateTime, data)
+	if single.RSquared > 0.9 || result.RSquared < 0.999 {
+		t.Error("Unexpected R squared: ", single.RSquared, result.RSquared)
+	}
+
+	if _, err = MultiCosinor([]time.Duration{24 * time.Hour, 0}, dateTime, data); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+}
+
+func TestSelectHarmonics(t *testing.T) {
+
+	dateTime, data, _ := bimodalData(2)
+	ts, _ := NewTimeSeries(dateTime, data)
+
+	// Table tests
+	var tTests = []struct {
+		criterion Criterion
+		harmonics int
+	}{
+		{AIC, 2},
+		{BIC, 2},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		result, err := ts.SelectHarmonics(24*time.Hour, 5, table.criterion)
+		if err != nil || len(result.Components) != table.harmonics {
+			t.Error(
+				"Criterion: ", table.criterion,
+				"Expected: ", table.harmonics,
+				"Received: ", len(result.Components), err,
+			)
+		}
+	}
+
+	harmonics, err := ts.CosinorHarmonics(0, 3)
+	if err != nil || len(harmonics.Components) != 3 || harmonics.Components[2].Period != 8*time.Hour || harmonics.P > 0.001 {
+		t.Error("Unexpected harmonics: ", harmonics, err)
+	}
+
+	if _, err = ts.SelectHarmonics(0, 0, AIC); !errors.Is(err, ErrInvalidHarmonics) {
+		t.Error("Expected: ErrInvalidHarmonics, Received: ", err)
+	}
+	if _, err = SelectHarmonics(0, 3, Criterion(5), dateTime, data); !errors.Is(err, ErrInvalidCriterion) {
+		t.Error("Expected: ErrInvalidCriterion, Received: ", err)
+	}
+}
@@ -0,0 +1,204 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Default parameters of the detrended fluctuation analysis
+const (
+	defaultDFAOrder  = 1  // Order of the detrending polynomial (DFA1)
+	defaultDFAScales = 30 // Number of window sizes
+)
+
+// ScalingRange defines the range of window sizes used to fit a scaling exponent
+type ScalingRange struct {
+	// Min is the shortest window size
+	Min time.Duration
+	// Max is the longest window size
+	Max time.Duration
+}
+
+// DefaultScalingRanges are the ranges used by Hu et al. (2009) to find the scaling exponents
+// of the activity (alpha1 below 1.5 hours and alpha2 from 2 to 10 hours)
+var DefaultScalingRanges = []ScalingRange{
+	{Min: time.Minute, Max: 90 * time.Minute},
+	{Min: 2 * time.Hour, Max: 10 * time.Hour},
+}
+
+// DFAOptions defines the parameters of the detrended fluctuation analysis
+type DFAOptions struct {
+	// Order is the order of the polynomial removed from each window (1 when zero)
+	Order int
+	// MinWindow is the shortest window size (order + 3 epochs when zero)
+	MinWindow time.Duration
+	// MaxWindow is the longest window size (a quarter of the data when zero)
+	MaxWindow time.Duration
+	// Scales is the number of window sizes, logarithmically spaced from MinWindow to MaxWindow (30 when zero)
+	Scales int
+	// Ranges defines the ranges of window sizes used to fit the scaling exponents (DefaultScalingRanges when nil)
+	Ranges []ScalingRange
+}
+
+// DFAResult stores the fluctuation function and the scaling exponents of the detrended fluctuation analysis
+type DFAResult struct {
+	// Windows stores the window sizes (n)
+	Windows []time.Duration
+	// Fluctuation stores the fluctuation function F(n) of each window size
+	Fluctuation []float64
+	// Ranges stores the ranges of window sizes used to fit the scaling exponents
+	Ranges []ScalingRange
+	// Exponents stores the scaling exponent (alpha) of each range, the slope of log F(n) versus log n
+	// (NaN when the range has less than 2 window sizes)
+	Exponents []float64
+}
+
+// Calculates the fluctuation F(n) of the profile for windows with the size (records) passed by parameter. The windows
+// are taken from the start and from the end of the profile, so all the data is used (Peng et al., 1995)
+func fluctuation(profile []float64, size int, order int) float64 {
+
+	// The design matrix is the same for all the windows (the positions are scaled from -1 to 1)
+	design := make([][]float64, size)
+	for index := 0; index < size; index++ {
+		x := 2.0*float64(index)/float64(size-1) - 1.0
+		design[index] = make([]float64, order+1)
+		design[index][0] = 1.0
+		for power := 1; power <= order; power++ {
+			design[index][power] = design[index][power-1] * x
+		}
+	}
+
+	windows := len(profile) / size
+	sum := 0.0
+	count := 0
+	for window := 0; window < windows; window++ {
+		for _, start := range []int{window * size, len(profile) - (window+1)*size} {
+			_, _, rss, ok := leastSquares(design, profile[start:start+size])
+			if ok {
+				sum += rss / float64(size)
+				count++
+			}
+		}
+	}
+
+	if count == 0 {
+		return math.NaN()
+	}
+	return math.Sqrt(sum / float64(count))
+}
+
+// DFA calculates the detrended fluctuation analysis of order n (Peng et al., 1995) of the activity, returning the fluctuation
+// function F(n) of logarithmically spaced window sizes and the scaling exponents of the ranges defined by the options
+// (e.g. alpha1 and alpha2 proposed by Hu et al., 2009). The time series should be evenly sampled (e.g. the output of
+// ConvertDataBasedOnEpoch). The masked records and the NaN values are not used (the valid records are concatenated)
+func (ts *TimeSeries) DFA(options DFAOptions) (result DFAResult, err error) {
+
+	// Check the parameters
+	if err = ts.check("DFA"); err != nil {
+		return
+	}
+	if ts.Epoch == 0 {
+		err = newError("DFA", ErrInvalidEpoch)
+		return
+	}
+
+	order := options.Order
+	if order == 0 {
+		order = defaultDFAOrder
+	}
+	if order < 0 {
+		err = newError("DFA", ErrInvalidOrder)
+		return
+	}
+	scales := options.Scales
+	if scales == 0 {
+		scales = defaultDFAScales
+	}
+	result.Ranges = options.Ranges
+	if result.Ranges == nil {
+		result.Ranges = append([]ScalingRange(nil), DefaultScalingRanges...)
+	}
+
+	// Profile: the cumulative sum of the valid data minus its average
+	var data []float64
+	for index := 0; index < ts.Len(); index++ {
+		if ts.valid(index) {
+			data = append(data, ts.Data[index])
+		}
+	}
+
+	epoch := time.Duration(ts.Epoch) * time.Second
+	minSize := order + 3
+	if options.MinWindow > 0 {
+		minSize = int(options.MinWindow / epoch)
+	}
+	maxSize := len(data) / 4
+	if options.MaxWindow > 0 {
+		maxSize = int(options.MaxWindow / epoch)
+	}
+
+	if scales < 0 || options.MinWindow < 0 || options.MaxWindow < 0 || minSize < order+2 || (options.MaxWindow > 0 && maxSize < minSize) {
+		err = newError("DFA", ErrInvalidScale)
+		return
+	}
+	if maxSize < minSize || len(data) < maxSize {
+		err = newError("DFA", ErrInsufficientData)
+		return
+	}
+
+	mean := average(data)
+	profile := make([]float64, len(data))
+	sum := 0.0
+	for index, value := range data {
+		sum += value - mean
+		profile[index] = sum
+	}
+
+	// Window sizes logarithmically spaced (the repeated sizes are removed)
+	var sizes []int
+	for scale := 0; scale < scales; scale++ {
+		size := minSize
+		if scales > 1 {
+			size = int(math.Floor(float64(minSize)*math.Pow(float64(maxSize)/float64(minSize), float64(scale)/float64(scales-1)) + 0.5))
+		}
+		if len(sizes) == 0 || size != sizes[len(sizes)-1] {
+			sizes = append(sizes, size)
+		}
+	}
+
+	for _, size := range sizes {
+		result.Windows = append(result.Windows, time.Duration(size)*epoch)
+		result.Fluctuation = append(result.Fluctuation, fluctuation(profile, size, order))
+	}
+
+	// Scaling exponents: slope of the linear regression of log F(n) versus log n
+	for _, scalingRange := range result.Ranges {
+		var design [][]float64
+		var logFluctuation []float64
+		for index, window := range result.Windows {
+			if window >= scalingRange.Min && window <= scalingRange.Max && result.Fluctuation[index] > 0 {
+				design = append(design, []float64{1.0, math.Log10(float64(window / epoch))})
+				logFluctuation = append(logFluctuation, math.Log10(result.Fluctuation[index]))
+			}
+		}
+
+		exponent := math.NaN()
+		if len(design) >= 2 {
+			if coefficients, _, _, ok := leastSquares(design, logFluctuation); ok {
+				exponent = coefficients[1]
+			}
+		}
+		result.Exponents = append(result.Exponents, exponent)
+	}
+
+	return
+}
+
+// DFA calculates the detrended fluctuation analysis of the activity using the options passed by parameter
+func DFA(options DFAOptions, dateTime []time.Time, data []float64) (result DFAResult, err error) {
+	ts, err := wrapTimeSeries("DFA", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.DFA(options)
+}
@@ -0,0 +1,91 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDFA(t *testing.T) {
+
+	// 10 days of white noise and of a random walk (1 minute epoch)
+	var whiteNoise []float64
+	var randomWalk []float64
+	sum := 0.0
+	for index := 0; index < 10*1440; index++ {
+		whiteNoise = append(whiteNoise, 100.0+50.0*noise(index))
+		sum += noise(index)
+		randomWalk = append(randomWalk, sum)
+	}
+
+	ranges := []ScalingRange{{Min: 10 * time.Minute, Max: 10 * time.Hour}}
+
+	// Table tests
+	var tTests = []struct {
+		name  string
+		data  []float64
+		order int
+		alpha float64
+	}{
+		{"White noise (DFA1)", whiteNoise, 1, 0.5},
+		{"White noise (DFA2)", whiteNoise, 2, 0.5},
+		{"Random walk (DFA1)", randomWalk, 1, 1.5},
+		{"Random walk (DFA2)", randomWalk, 2, 1.5},
+	}
+
+	for _, test := range tTests {
+		result, err := minuteSeries(test.data).DFA(DFAOptions{Order: test.order, Ranges: ranges})
+		if err != nil || len(result.Exponents) != 1 || math.Abs(result.Exponents[0]-test.alpha) > 0.1 {
+			t.Error(test.name, "Expected: ", test.alpha, "Received: ", result.Exponents, err)
+		}
+		if len(result.Windows) != 30 || len(result.Fluctuation) != 30 || result.Windows[0] != time.Duration(test.order+3)*time.Minute ||
+			result.Windows[29] != 3600*time.Minute {
+			t.Error(test.name, "Unexpected windows: ", result.Windows)
+		}
+	}
+
+	// Default ranges (Hu et al., 2009) and window sizes
+	result, err := DFA(DFAOptions{MinWindow: 3 * time.Minute, MaxWindow: 12 * time.Hour, Scales: 10}, minuteSeries(whiteNoise).DateTime, whiteNoise)
+	if err != nil || len(result.Exponents) != 2 || result.Ranges[1].Max != 10*time.Hour || result.Windows[9] != 12*time.Hour {
+		t.Error("Unexpected result: ", result, err)
+	}
+
+	// The default ranges are copied to the result
+	result.Ranges[1].Max = time.Hour
+	if DefaultScalingRanges[1].Max != 10*time.Hour {
+		t.Error("Expected: 10h0m0s, Received: ", DefaultScalingRanges[1].Max)
+	}
+
+	// The polynomial of order 2 removes the trend of the profile of a linear signal
+	var linear []float64
+	for index := 0; index < 1000; index++ {
+		linear = append(linear, float64(index))
+	}
+	result, err = minuteSeries(linear).DFA(DFAOptions{Order: 2, Scales: 5})
+	for _, value := range result.Fluctuation {
+		if err != nil || value > 1e-6 {
+			t.Error("Expected: 0, Received: ", value, err)
+		}
+	}
+
+	// The masked records are not used
+	ts := minuteSeries(append(linear, 5000, 6000, 7000))
+	ts.Mask = repeatMask(false, ts.Len())
+	copy(ts.Mask[1000:], []bool{true, true, true})
+	masked, err := ts.DFA(DFAOptions{Order: 2, Scales: 5, MaxWindow: 250 * time.Minute})
+	if err != nil || !sliceFloatEquals(masked.Fluctuation, result.Fluctuation) {
+		t.Error("Expected: ", result.Fluctuation, "Received: ", masked.Fluctuation, err)
+	}
+
+	// Invalid parameters
+	if _, err = ts.DFA(DFAOptions{Order: -1}); !errors.Is(err, ErrInvalidOrder) {
+		t.Error("Expected: ErrInvalidOrder, Received: ", err)
+	}
+	if _, err = ts.DFA(DFAOptions{MinWindow: 2 * time.Minute}); !errors.Is(err, ErrInvalidScale) {
+		t.Error("Expected: ErrInvalidScale, Received: ", err)
+	}
+	if _, err = ts.DFA(DFAOptions{MaxWindow: 2000 * time.Minute}); !errors.Is(err, ErrInsufficientData) {
+		t.Error("Expected: ErrInsufficientData, Received: ", err)
+	}
+}
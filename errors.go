@@ -0,0 +1,108 @@
+package chronobiology
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Sentinel errors returned by the package (they can be checked using errors.Is)
+var (
+	// ErrEmpty is returned when the dateTime or data slices are empty
+	ErrEmpty = errors.New("Empty")
+	// ErrDifferentSize is returned when the dateTime and data slices have different sizes
+	ErrDifferentSize = errors.New("DifferentSize")
+	// ErrInvalidEpoch is returned when the epoch could not be found or the new epoch is invalid
+	ErrInvalidEpoch = errors.New("InvalidEpoch")
+	// ErrInvalidHours is returned when the number of hours passed by parameter is invalid
+	ErrInvalidHours = errors.New("InvalidHours")
+	// ErrInvalidMinutes is returned when the number of minutes passed by parameter is invalid
+	ErrInvalidMinutes = errors.New("MinutesInvalid")
+	// ErrInvalidTimeRange is returned when the end time is before the start time
+	ErrInvalidTimeRange = errors.New("InvalidTimeRange")
+	// ErrInsufficientDuration is returned when the time series is shorter than required by the analysis
+	ErrInsufficientDuration = errors.New("InsufficientDuration")
+	// ErrNullValues is returned when the values passed by parameter are all zero
+	ErrNullValues = errors.New("NullValues")
+	// ErrUnsorted is returned when the timestamps are not in ascending order
+	ErrUnsorted = errors.New("Unsorted")
+	// ErrInvalidSampleRate is returned when the sample rate of the raw acceleration is invalid
+	ErrInvalidSampleRate = errors.New("InvalidSampleRate")
+	// ErrInvalidMetric is returned when the metric passed by parameter is unknown
+	ErrInvalidMetric = errors.New("InvalidMetric")
+	// ErrInsufficientCalibration is returned when there are not enough non-movement periods to calibrate the raw acceleration
+	ErrInsufficientCalibration = errors.New("InsufficientCalibration")
+	// ErrMasked is returned when all the records needed by the analysis are masked (e.g. non-wear periods)
+	ErrMasked = errors.New("Masked")
+	// ErrInsufficientCoverage is returned when the valid data does not reach the minimum coverage
+	ErrInsufficientCoverage = errors.New("InsufficientCoverage")
+	// ErrInvalidPeriod is returned when the period passed by parameter is invalid
+	ErrInvalidPeriod = errors.New("InvalidPeriod")
+	// ErrInsufficientData is returned when the valid records are not enough to fit the model
+	ErrInsufficientData = errors.New("InsufficientData")
+	// ErrInvalidHarmonics is returned when the number of harmonics passed by parameter is invalid
+	ErrInvalidHarmonics = errors.New("InvalidHarmonics")
+	// ErrInvalidCriterion is returned when the information criterion passed by parameter is unknown
+	ErrInvalidCriterion = errors.New("InvalidCriterion")
+	// ErrInvalidAlpha is returned when the significance level passed by parameter is not between 0 and 1
+	ErrInvalidAlpha = errors.New("InvalidAlpha")
+	// ErrInvalidBinning is returned when the binning passed by parameter is unknown
+	ErrInvalidBinning = errors.New("InvalidBinning")
+	// ErrInvalidBoutLength is returned when the range of bout lengths passed by parameter is invalid
+	ErrInvalidBoutLength = errors.New("InvalidBoutLength")
+	// ErrInvalidWindow is returned when the window passed by parameter is unknown
+	ErrInvalidWindow = errors.New("InvalidWindow")
+	// ErrInvalidOrder is returned when the order of the detrending polynomial passed by parameter is invalid
+	ErrInvalidOrder = errors.New("InvalidOrder")
+	// ErrInvalidScale is returned when the window sizes (scales) passed by parameter are invalid
+	ErrInvalidScale = errors.New("InvalidScale")
+	// ErrInvalidThreshold is returned when the threshold passed by parameter is invalid
+	ErrInvalidThreshold = errors.New("InvalidThreshold")
+)
+
+// Error describes an error returned by a function of the package,
+// wrapping one of the sentinel errors (or the error returned by an inner function)
+type Error struct {
+	// Func is the name of the function that returned the error
+	Func string
+	// Err is the underlying error
+	Err error
+	// Required is the duration required by the function (only used by ErrInsufficientDuration)
+	Required time.Duration
+	// Actual is the duration of the time series (only used by ErrInsufficientDuration)
+	Actual time.Duration
+	// Index is the position of the offending record (-1 when not related to a record)
+	Index int
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	message := e.Func + ": " + e.Err.Error()
+	if e.Required > 0 {
+		message += fmt.Sprintf(" (required %v, actual %v)", e.Required, e.Actual)
+	}
+	if e.Index >= 0 {
+		message += fmt.Sprintf(" (index %d)", e.Index)
+	}
+	return message
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Creates a new error related to the function passed by parameter
+func newError(function string, err error) *Error {
+	return &Error{Func: function, Err: err, Index: -1}
+}
+
+// Creates a new ErrInsufficientDuration error with the required and actual durations
+func durationError(function string, required time.Duration, actual time.Duration) *Error {
+	return &Error{Func: function, Err: ErrInsufficientDuration, Required: required, Actual: actual, Index: -1}
+}
+
+// Creates a new error related to a specific record of the time series
+func indexError(function string, err error, index int) *Error {
+	return &Error{Func: function, Err: err, Index: index}
+}
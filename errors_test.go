@@ -0,0 +1,114 @@
+package chronobiology
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorMessage(t *testing.T) {
+
+	// Table tests
+	var tTests = []struct {
+		err     *Error
+		message string
+	}{
+		{newError("AverageDay", ErrEmpty), "AverageDay: Empty"},
+		{durationError("InterdailyStability", 48*time.Hour, 6*time.Hour), "InterdailyStability: InsufficientDuration (required 48h0m0s, actual 6h0m0s)"},
+		{indexError("NewTimeSeries", ErrUnsorted, 3), "NewTimeSeries: Unsorted (index 3)"},
+		{newError("IntradailyVariability", newError("ConvertDataBasedOnEpoch", ErrInvalidEpoch)), "IntradailyVariability: ConvertDataBasedOnEpoch: InvalidEpoch"},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if table.err.Error() != table.message {
+			t.Error(
+				"Expected: ", table.message,
+				"Received: ", table.err.Error(),
+			)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+
+	utc, _ := time.LoadLocation("UTC")
+
+	var dateTime []time.Time
+	var data []float64
+
+	tempDateTime := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+	for index := 0; index < 6; index++ {
+		dateTime = append(dateTime, tempDateTime)
+		data = append(data, 100.0)
+		tempDateTime = tempDateTime.Add(1 * time.Hour)
+	}
+
+	_, _, err := HigherActivity(5, nil, nil)
+	if !errors.Is(err, ErrEmpty) {
+		t.Error("Expected: ErrEmpty, Received: ", err)
+	}
+
+	_, _, err = LowerActivity(5, dateTime, data[:3])
+	if !errors.Is(err, ErrDifferentSize) {
+		t.Error("Expected: ErrDifferentSize, Received: ", err)
+	}
+
+	_, _, err = M10(dateTime, data)
+	if !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+
+	var durationErr *Error
+	if !errors.As(err, &durationErr) {
+		t.Error("Expected: *Error")
+	} else {
+		if durationErr.Func != "HigherActivity" {
+			t.Error("Expected: HigherActivity, Received: ", durationErr.Func)
+		}
+		if durationErr.Required != 10*time.Hour || durationErr.Actual != 5*time.Hour {
+			t.Error("Expected: 10h and 5h, Received: ", durationErr.Required, durationErr.Actual)
+		}
+	}
+
+	_, err = InterdailyStability(dateTime, data)
+	if !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+
+	_, err = RelativeAmplitude(0.0, 0.0)
+	if !errors.Is(err, ErrNullValues) {
+		t.Error("Expected: ErrNullValues, Received: ", err)
+	}
+
+	_, _, err = FilterDataByDateTime(dateTime, data, dateTime[1], dateTime[0])
+	if !errors.Is(err, ErrInvalidTimeRange) {
+		t.Error("Expected: ErrInvalidTimeRange, Received: ", err)
+	}
+
+	_, _, err = ConvertDataBasedOnEpoch(dateTime, data, -60)
+	if !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+
+	// Intradaily variability must return before calculating when the time series is shorter than 2 hours
+	iv, err := IntradailyVariability(dateTime[:2], data[:2])
+	if !errors.Is(err, ErrInsufficientDuration) || iv != nil {
+		t.Error("Expected: ErrInsufficientDuration and nil, Received: ", err, iv)
+	}
+
+	// Intradaily variability must surface the ConvertDataBasedOnEpoch error
+	invalidDateTime := []time.Time{dateTime[0], dateTime[0], dateTime[0], dateTime[5]}
+	_, err = IntradailyVariability(invalidDateTime, data[:4])
+	if !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+
+	// Unsorted timestamps
+	_, err = NewTimeSeries([]time.Time{dateTime[1], dateTime[0]}, data[:2])
+
+	var unsortedErr *Error
+	if !errors.Is(err, ErrUnsorted) || !errors.As(err, &unsortedErr) || unsortedErr.Index != 1 {
+		t.Error("Expected: ErrUnsorted at index 1, Received: ", err)
+	}
+}
@@ -0,0 +1,258 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Parameters used by the Levenberg-Marquardt fit of the extended cosinor
+const (
+	extendedIterations = 500   // Maximum number of iterations
+	extendedTolerance  = 1e-10 // Minimum relative improvement of the residual sum of squares
+	extendedMaxLambda  = 1e12  // Maximum damping factor
+)
+
+// ExtendedCosinorResult stores the parameters of the anti-logistic extended cosinor proposed by Marler et al. (2006),
+// fitted on the log-transformed data: log(data + 1) = Min + Amp * F(cos(2 * pi * (t - Acrophase) / Period)),
+// where F(x) = exp(Beta * (x - Alpha)) / (1 + exp(Beta * (x - Alpha)))
+type ExtendedCosinorResult struct {
+	// Period is the period used by the fit
+	Period time.Duration
+	// Min is the minimum of the fitted curve
+	Min float64
+	// Amp is the difference between the maximum and the minimum of the fitted curve
+	Amp float64
+	// Alpha defines the relative width of the peaks and troughs (from -1 to 1)
+	Alpha float64
+	// Beta defines the steepness of the rise and fall of the curve
+	Beta float64
+	// Acrophase is the time of the peak elapsed since midnight (the clock time when the period is 24 hours)
+	Acrophase time.Duration
+	// UpMesor is the time (elapsed since midnight) when the curve crosses the middle value going up
+	UpMesor time.Duration
+	// DownMesor is the time (elapsed since midnight) when the curve crosses the middle value going down
+	DownMesor time.Duration
+	// F is the pseudo F statistic comparing the fit with a constant model
+	F float64
+	// RSS is the residual sum of squares of the fit
+	RSS float64
+	// Iterations is the number of iterations used by the fit
+	Iterations int
+	// Converged reports whether the fit converged before the maximum number of iterations
+	Converged bool
+	// N is the number of records used by the fit
+	N int
+}
+
+// Parameters of the extended cosinor (min, amp, alpha, beta and acrophase in hours)
+type extendedParameters [5]float64
+
+// Evaluates the extended cosinor and its partial derivatives at the time (hours) passed by parameter
+func (parameters extendedParameters) evaluate(hours float64, periodHours float64) (value float64, gradient [5]float64) {
+
+	omega := 2.0 * math.Pi / periodHours
+	angle := omega * (hours - parameters[4])
+	cosine := math.Cos(angle)
+
+	logistic := 1.0 / (1.0 + math.Exp(-parameters[3]*(cosine-parameters[2])))
+	derivative := parameters[1] * logistic * (1.0 - logistic)
+
+	value = parameters[0] + parameters[1]*logistic
+	gradient[0] = 1.0
+	gradient[1] = logistic
+	gradient[2] = -derivative * parameters[3]
+	gradient[3] = derivative * (cosine - parameters[2])
+	gradient[4] = derivative * parameters[3] * math.Sin(angle) * omega
+	return
+}
+
+// Keeps the parameters in the valid ranges (alpha from -1 to 1, beta and amp above zero, acrophase within the period)
+func (parameters *extendedParameters) constrain(periodHours float64) {
+	parameters[1] = math.Abs(parameters[1])
+	parameters[2] = math.Max(-1.0, math.Min(1.0, parameters[2]))
+	parameters[3] = math.Max(1e-6, math.Abs(parameters[3]))
+	parameters[4] = math.Mod(parameters[4], periodHours)
+	if parameters[4] < 0 {
+		parameters[4] += periodHours
+	}
+}
+
+// Calculates the residual sum of squares of the extended cosinor
+func (parameters extendedParameters) rss(hours []float64, data []float64, periodHours float64) (rss float64) {
+	for index := 0; index < len(data); index++ {
+		value, _ := parameters.evaluate(hours[index], periodHours)
+		rss += (data[index] - value) * (data[index] - value)
+	}
+	return
+}
+
+// Fits the extended cosinor using the Levenberg-Marquardt algorithm from the initial parameters passed by parameter
+func fitExtended(hours []float64, data []float64, periodHours float64, initial extendedParameters) (parameters extendedParameters, rss float64, iterations int, converged bool) {
+
+	parameters = initial
+	parameters.constrain(periodHours)
+	rss = parameters.rss(hours, data, periodHours)
+	lambda := 1e-3
+
+	for iterations = 1; iterations <= extendedIterations; iterations++ {
+
+		// Normal equations of the linearized problem
+		var jtj [5][5]float64
+		var jtr [5]float64
+		for index := 0; index < len(data); index++ {
+			value, gradient := parameters.evaluate(hours[index], periodHours)
+			residual := data[index] - value
+			for row := 0; row < 5; row++ {
+				jtr[row] += gradient[row] * residual
+				for column := 0; column < 5; column++ {
+					jtj[row][column] += gradient[row] * gradient[column]
+				}
+			}
+		}
+
+		improved := false
+		for lambda <= extendedMaxLambda {
+
+			damped := make([][]float64, 5)
+			for row := 0; row < 5; row++ {
+				damped[row] = make([]float64, 5)
+				copy(damped[row], jtj[row][:])
+				damped[row][row] += lambda * math.Max(jtj[row][row], 1e-12)
+			}
+
+			inverse, ok := invertMatrix(damped)
+			if ok {
+				candidate := parameters
+				for row := 0; row < 5; row++ {
+					for column := 0; column < 5; column++ {
+						candidate[row] += inverse[row][column] * jtr[column]
+					}
+				}
+				candidate.constrain(periodHours)
+
+				if candidateRSS := candidate.rss(hours, data, periodHours); candidateRSS < rss {
+					converged = (rss - candidateRSS) <= extendedTolerance*math.Max(rss, 1e-300)
+					parameters, rss = candidate, candidateRSS
+					lambda = math.Max(lambda/10.0, 1e-12)
+					improved = true
+					break
+				}
+			}
+			lambda *= 10.0
+		}
+
+		// No step improves the fit: it is a minimum
+		if !improved {
+			converged = true
+		}
+		if converged {
+			return
+		}
+	}
+
+	iterations = extendedIterations
+	return
+}
+
+// ExtendedCosinor fits the anti-logistic extended cosinor proposed by Marler et al. (2006) on the log-transformed data
+// (log(data + 1), so the data should be non-negative activity counts) using nonlinear least squares.
+// The period is DefaultPeriod when zero. The masked records and the NaN values are not used
+func (ts *TimeSeries) ExtendedCosinor(period time.Duration) (result ExtendedCosinorResult, err error) {
+
+	// Check the parameters
+	if err = ts.check("ExtendedCosinor"); err != nil {
+		return
+	}
+	if period < 0 {
+		err = newError("ExtendedCosinor", ErrInvalidPeriod)
+		return
+	}
+	if period == 0 {
+		period = DefaultPeriod
+	}
+
+	periodHours := period.Hours()
+
+	var dateTime []time.Time
+	var data []float64
+	for index := 0; index < len(ts.Data); index++ {
+		if ts.valid(index) && ts.Data[index] > -1.0 {
+			dateTime = append(dateTime, ts.DateTime[index])
+			data = append(data, math.Log1p(ts.Data[index]))
+		}
+	}
+
+	n := len(data)
+	if n <= 5 {
+		err = newError("ExtendedCosinor", ErrInsufficientData)
+		return
+	}
+
+	hours := elapsedSinceMidnight(dateTime)
+	for index := range hours {
+		hours[index] /= 3600.0
+	}
+
+	// The initial parameters are based on the single-component cosinor of the log-transformed data
+	cosinor, cosinorErr := Cosinor(period, dateTime, data)
+	if cosinorErr != nil {
+		err = newError("ExtendedCosinor", cosinorErr)
+		return
+	}
+	acrophase := cosinor.AcrophaseTime.Hours()
+
+	// Several initial shapes are tested, since the fit may converge to a local minimum
+	first := true
+	var best extendedParameters
+	for _, alpha := range []float64{-0.5, 0.0, 0.5} {
+		for _, beta := range []float64{2.0, 10.0} {
+			initial := extendedParameters{cosinor.MESOR - cosinor.Amplitude, 2.0 * cosinor.Amplitude, alpha, beta, acrophase}
+			parameters, rss, iterations, converged := fitExtended(hours, data, periodHours, initial)
+			if first || rss < result.RSS {
+				first = false
+				best = parameters
+				result.RSS, result.Iterations, result.Converged = rss, iterations, converged
+			}
+		}
+	}
+
+	hour := float64(time.Hour)
+	width := math.Acos(best[2]) * periodHours / (2.0 * math.Pi)
+	wrap := func(value float64) time.Duration {
+		value = math.Mod(value, periodHours)
+		if value < 0 {
+			value += periodHours
+		}
+		return time.Duration(value * hour)
+	}
+
+	result.Period = period
+	result.Min, result.Amp, result.Alpha, result.Beta = best[0], best[1], best[2], best[3]
+	result.Acrophase = wrap(best[4])
+	result.UpMesor = wrap(best[4] - width)
+	result.DownMesor = wrap(best[4] + width)
+	result.N = n
+
+	// Pseudo F statistic (4 and n - 5 degrees of freedom)
+	mean := average(data)
+	tss := 0.0
+	for _, value := range data {
+		tss += (value - mean) * (value - mean)
+	}
+	if result.RSS > 0 {
+		result.F = ((tss - result.RSS) / 4.0) / (result.RSS / float64(n-5))
+	} else {
+		result.F = math.Inf(1)
+	}
+
+	return
+}
+
+// ExtendedCosinor fits the anti-logistic extended cosinor (Marler et al., 2006) with the period passed by parameter (DefaultPeriod when zero)
+func ExtendedCosinor(period time.Duration, dateTime []time.Time, data []float64) (result ExtendedCosinorResult, err error) {
+	ts, err := wrapTimeSeries("ExtendedCosinor", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.ExtendedCosinor(period)
+}
@@ -0,0 +1,70 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestExtendedCosinor(t *testing.T) {
+
+	utc, _ := time.LoadLocation("UTC")
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	// Activity counts following the extended cosinor: min 1, amp 4, alpha -0.2, beta 8 and acrophase at 14h
+	parameters := extendedParameters{1, 4, -0.2, 8, 14}
+
+	var dateTime []time.Time
+	var data []float64
+	for index := 0; index < 7*144; index++ {
+		current := start.Add(time.Duration(index) * 10 * time.Minute)
+		value, _ := parameters.evaluate(current.Sub(start).Hours(), 24)
+		dateTime = append(dateTime, current)
+		data = append(data, math.Expm1(value+0.05*noise(index)))
+	}
+
+	result, err := ExtendedCosinor(0, dateTime, data)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	// Table tests
+	var tTests = []struct {
+		name      string
+		value     float64
+		expected  float64
+		tolerance float64
+	}{
+		{"Min", result.Min, 1, 0.02},
+		{"Amp", result.Amp, 4, 0.02},
+		{"Alpha", result.Alpha, -0.2, 0.01},
+		{"Beta", result.Beta, 8, 0.5},
+		{"Acrophase", result.Acrophase.Hours(), 14, 0.05},
+		// 14 - acos(-0.2) * 24 / (2 * pi)
+		{"UpMesor", result.UpMesor.Hours(), 7.230, 0.05},
+		{"DownMesor", result.DownMesor.Hours(), 20.770, 0.05},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if math.Abs(table.value-table.expected) > table.tolerance {
+			t.Error(
+				"Parameter: ", table.name,
+				"Expected: ", table.expected,
+				"Received: ", table.value,
+			)
+		}
+	}
+
+	if !result.Converged || result.Iterations == 0 || result.F < 1000 || result.N != len(data) {
+		t.Error("Unexpected diagnostics: ", result.Converged, result.Iterations, result.F, result.N)
+	}
+
+	if _, err = ExtendedCosinor(-time.Hour, dateTime, data); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = ExtendedCosinor(0, dateTime[:5], data[:5]); !errors.Is(err, ErrInsufficientData) {
+		t.Error("Expected: ErrInsufficientData, Received: ", err)
+	}
+}
@@ -0,0 +1,252 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Window represents the part of each day used by an analysis
+type Window int
+
+// Windows used by the fragmentation analysis
+const (
+	// WholeDay uses all the records
+	WholeDay Window = iota
+	// L5Window only uses the records within the L5 of each day (rest period)
+	L5Window
+	// M10Window only uses the records within the M10 of each day (active period)
+	M10Window
+)
+
+// Bout stores a run of consecutive rest (or active) records
+type Bout struct {
+	// Start is the time of the first record of the bout
+	Start time.Time
+	// Duration is the duration of the bout (number of records times the epoch)
+	Duration time.Duration
+	// Active reports whether the records are active (above the threshold) or at rest
+	Active bool
+}
+
+// BoutCount stores the number of bouts with the same duration
+type BoutCount struct {
+	// Duration is the duration of the bouts
+	Duration time.Duration
+	// Count is the number of bouts
+	Count int
+}
+
+// FragmentationOptions defines how the data is binarized and which bouts are used to estimate the transition probabilities
+type FragmentationOptions struct {
+	// Threshold defines the active records (above the threshold) and the rest records (zero by default)
+	Threshold float64
+	// MinBout is the shortest bout duration used to estimate the transition probabilities (one epoch when zero)
+	MinBout time.Duration
+	// MaxBout is the longest bout duration used to estimate the transition probabilities (no limit when zero)
+	MaxBout time.Duration
+	// Window selects the records used (WholeDay by default)
+	Window Window
+}
+
+// FragmentationResult stores the transition probabilities proposed by Lim et al. (2011) and the bouts used
+type FragmentationResult struct {
+	// KRA is the rest to activity transition probability (NaN when there is no rest bout in the range)
+	KRA float64
+	// KAR is the activity to rest transition probability (NaN when there is no active bout in the range)
+	KAR float64
+	// RestBouts stores the rest bouts
+	RestBouts []Bout
+	// ActiveBouts stores the active bouts
+	ActiveBouts []Bout
+	// RestDistribution stores the number of rest bouts of each duration (from the shortest to the longest)
+	RestDistribution []BoutCount
+	// ActiveDistribution stores the number of active bouts of each duration (from the shortest to the longest)
+	ActiveDistribution []BoutCount
+}
+
+// Finds the bouts of the records selected by the include slice (nil to use all the records).
+// The bouts are interrupted by the masked records, the NaN values, the gaps and the records not included
+func (ts *TimeSeries) bouts(threshold float64, include []bool) (bouts []Bout) {
+
+	epoch := time.Duration(ts.Epoch) * time.Second
+	current := -1
+
+	for index := 0; index < ts.Len(); index++ {
+
+		if !ts.valid(index) || (include != nil && !include[index]) {
+			current = -1
+			continue
+		}
+
+		active := ts.Data[index] > threshold
+		if current >= 0 && bouts[current].Active == active && ts.DateTime[index].Sub(ts.DateTime[index-1]) == epoch {
+			bouts[current].Duration += epoch
+			continue
+		}
+
+		bouts = append(bouts, Bout{Start: ts.DateTime[index], Duration: epoch, Active: active})
+		current = len(bouts) - 1
+	}
+
+	return
+}
+
+// Counts the bouts of each duration (in epochs). The position zero is not used
+func boutCounts(bouts []Bout, epoch time.Duration) (counts []int) {
+	for _, bout := range bouts {
+		length := int(bout.Duration / epoch)
+		for len(counts) <= length {
+			counts = append(counts, 0)
+		}
+		counts[length]++
+	}
+	return
+}
+
+// Calculates the average transition probability of the bout durations from minLength to maxLength (epochs):
+// the number of bouts with duration t divided by the number of bouts with duration t or longer (Lim et al., 2011)
+func transitionProbability(counts []int, minLength int, maxLength int) float64 {
+
+	if maxLength <= 0 || maxLength >= len(counts) {
+		maxLength = len(counts) - 1
+	}
+
+	// Number of bouts with duration t or longer
+	longer := make([]int, len(counts)+1)
+	for length := len(counts) - 1; length >= 0; length-- {
+		longer[length] = longer[length+1] + counts[length]
+	}
+
+	sum := 0.0
+	n := 0
+	for length := minLength; length <= maxLength; length++ {
+		if longer[length] > 0 {
+			sum += float64(counts[length]) / float64(longer[length])
+			n++
+		}
+	}
+
+	if n == 0 {
+		return math.NaN()
+	}
+	return sum / float64(n)
+}
+
+// Converts the counts of each duration (in epochs) to the distribution (only the durations with bouts)
+func boutDistribution(counts []int, epoch time.Duration) (distribution []BoutCount) {
+	for length, count := range counts {
+		if count > 0 {
+			distribution = append(distribution, BoutCount{Duration: time.Duration(length) * epoch, Count: count})
+		}
+	}
+	return
+}
+
+// Bouts finds the runs of consecutive rest (below or equal to the threshold) and active (above the threshold) records.
+// The bouts are interrupted by the masked records, the NaN values and the gaps
+func (ts *TimeSeries) Bouts(threshold float64) (bouts []Bout, err error) {
+
+	// Check the parameters
+	if err = ts.check("Bouts"); err != nil {
+		return
+	}
+	if ts.Epoch == 0 {
+		err = newError("Bouts", ErrInvalidEpoch)
+		return
+	}
+
+	return ts.bouts(threshold, nil), nil
+}
+
+// Fragmentation calculates the rest to activity (kRA) and activity to rest (kAR) transition probabilities proposed by
+// Lim et al. (2011) from the bouts of the binarized data, optionally restricted to the L5 or M10 of each day.
+// The bouts are interrupted by the masked records, the NaN values, the gaps and the boundaries of the windows
+func (ts *TimeSeries) Fragmentation(options FragmentationOptions) (result FragmentationResult, err error) {
+
+	// Check the parameters
+	if err = ts.check("Fragmentation"); err != nil {
+		return
+	}
+	if ts.Epoch == 0 {
+		err = newError("Fragmentation", ErrInvalidEpoch)
+		return
+	}
+
+	epoch := time.Duration(ts.Epoch) * time.Second
+	if options.MinBout < 0 || options.MaxBout < 0 || (options.MaxBout > 0 && options.MaxBout < options.MinBout) {
+		err = newError("Fragmentation", ErrInvalidBoutLength)
+		return
+	}
+	minLength := int(options.MinBout / epoch)
+	if minLength < 1 {
+		minLength = 1
+	}
+	maxLength := int(options.MaxBout / epoch)
+
+	// The records within the daily L5 or M10 windows
+	var include []bool
+	if options.Window != WholeDay {
+
+		var summary DailyActivitySummary
+		var hours int
+		switch options.Window {
+		case L5Window:
+			hours = 5
+			summary, err = ts.dailyActivity("Fragmentation", hours, false)
+		case M10Window:
+			hours = 10
+			summary, err = ts.dailyActivity("Fragmentation", hours, true)
+		default:
+			err = newError("Fragmentation", ErrInvalidWindow)
+		}
+		if err != nil {
+			return
+		}
+
+		include = make([]bool, ts.Len())
+		for _, day := range summary.Days {
+			end := day.Onset.Add(time.Duration(hours) * time.Hour)
+			for index := 0; index < ts.Len(); index++ {
+				if !ts.DateTime[index].Before(day.Onset) && ts.DateTime[index].Before(end) {
+					include[index] = true
+				}
+			}
+		}
+	}
+
+	for _, bout := range ts.bouts(options.Threshold, include) {
+		if bout.Active {
+			result.ActiveBouts = append(result.ActiveBouts, bout)
+		} else {
+			result.RestBouts = append(result.RestBouts, bout)
+		}
+	}
+
+	restCounts := boutCounts(result.RestBouts, epoch)
+	activeCounts := boutCounts(result.ActiveBouts, epoch)
+
+	result.KRA = transitionProbability(restCounts, minLength, maxLength)
+	result.KAR = transitionProbability(activeCounts, minLength, maxLength)
+	result.RestDistribution = boutDistribution(restCounts, epoch)
+	result.ActiveDistribution = boutDistribution(activeCounts, epoch)
+
+	return
+}
+
+// Bouts finds the runs of consecutive rest (below or equal to the threshold) and active (above the threshold) records
+func Bouts(threshold float64, dateTime []time.Time, data []float64) (bouts []Bout, err error) {
+	ts, err := wrapTimeSeries("Bouts", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.Bouts(threshold)
+}
+
+// Fragmentation calculates the rest to activity (kRA) and activity to rest (kAR) transition probabilities (Lim et al., 2011)
+func Fragmentation(options FragmentationOptions, dateTime []time.Time, data []float64) (result FragmentationResult, err error) {
+	ts, err := wrapTimeSeries("Fragmentation", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.Fragmentation(options)
+}
@@ -0,0 +1,92 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFragmentation(t *testing.T) {
+
+	// Rest and active bouts: R1 A2 R1 A2 R2 A4 R3
+	ts := minuteSeries([]float64{0, 5, 5, 0, 5, 5, 0, 0, 5, 5, 5, 5, 0, 0, 0})
+
+	// Table tests
+	var tTests = []struct {
+		options FragmentationOptions
+		kRA     float64
+		kAR     float64
+	}{
+		{FragmentationOptions{}, 2.0 / 3.0, 5.0 / 12.0},
+		{FragmentationOptions{MaxBout: 2 * time.Minute}, 0.5, 1.0 / 3.0},
+		{FragmentationOptions{MinBout: 2 * time.Minute}, 0.75, 5.0 / 9.0},
+		{FragmentationOptions{MinBout: 4 * time.Minute, MaxBout: 4 * time.Minute}, math.NaN(), 1.0},
+		{FragmentationOptions{Threshold: 5}, 1.0 / 15.0, math.NaN()},
+	}
+
+	for _, test := range tTests {
+		result, err := ts.Fragmentation(test.options)
+		if err != nil || !(floatEquals(result.KRA, test.kRA) || math.IsNaN(result.KRA) && math.IsNaN(test.kRA)) ||
+			!(floatEquals(result.KAR, test.kAR) || math.IsNaN(result.KAR) && math.IsNaN(test.kAR)) {
+			t.Error("Options: ", test.options, "Expected: ", test.kRA, test.kAR, "Received: ", result.KRA, result.KAR, err)
+		}
+	}
+
+	result, _ := Fragmentation(FragmentationOptions{}, ts.DateTime, ts.Data)
+	if len(result.RestBouts) != 4 || len(result.ActiveBouts) != 3 || result.RestBouts[3].Duration != 3*time.Minute ||
+		!result.ActiveBouts[2].Start.Equal(ts.DateTime[8]) || len(result.ActiveDistribution) != 2 ||
+		result.ActiveDistribution[0] != (BoutCount{2 * time.Minute, 2}) || result.RestDistribution[0] != (BoutCount{time.Minute, 2}) {
+		t.Error("Unexpected bouts: ", result)
+	}
+
+	// The masked records and the gaps interrupt the bouts
+	ts.Mask = make([]bool, ts.Len())
+	ts.Mask[9] = true
+	bouts, err := ts.Bouts(0)
+	if err != nil || len(bouts) != 8 || bouts[5].Duration != time.Minute || bouts[6].Duration != 2*time.Minute {
+		t.Error("Unexpected bouts: ", bouts, err)
+	}
+	bouts, err = Bouts(0, append(ts.DateTime[:2:2], ts.DateTime[3:]...), append(ts.Data[:2:2], ts.Data[3:]...))
+	if err != nil || len(bouts) != 7 || bouts[1].Duration != time.Minute || bouts[3].Duration != 2*time.Minute {
+		t.Error("Unexpected bouts: ", bouts, err)
+	}
+
+	// Invalid parameters
+	if _, err = ts.Fragmentation(FragmentationOptions{MinBout: 5 * time.Minute, MaxBout: 2 * time.Minute}); !errors.Is(err, ErrInvalidBoutLength) {
+		t.Error("Expected: ErrInvalidBoutLength, Received: ", err)
+	}
+	if _, err = ts.Fragmentation(FragmentationOptions{Window: Window(5)}); !errors.Is(err, ErrInvalidWindow) {
+		t.Error("Expected: ErrInvalidWindow, Received: ", err)
+	}
+}
+
+func TestFragmentationWindow(t *testing.T) {
+
+	// 2 days with a fragmented rest from 01:00 to 06:00 (2 minutes of rest and 1 minute of activity)
+	var data []float64
+	for index := 0; index < 2*1440; index++ {
+		minute := index % 1440
+		switch {
+		case minute >= 60 && minute < 360 && minute%3 == 2:
+			data = append(data, 5.0)
+		case minute >= 60 && minute < 360:
+			data = append(data, 0.0)
+		default:
+			data = append(data, 100.0)
+		}
+	}
+	ts := minuteSeries(data)
+
+	result, err := ts.Fragmentation(FragmentationOptions{Window: L5Window})
+	if err != nil || !floatEquals(result.KRA, 0.5) || !floatEquals(result.KAR, 1.0) || len(result.RestDistribution) != 1 ||
+		result.RestDistribution[0] != (BoutCount{2 * time.Minute, 200}) || result.ActiveDistribution[0] != (BoutCount{time.Minute, 200}) {
+		t.Error("Unexpected result: ", result.KRA, result.KAR, result.RestDistribution, result.ActiveDistribution, err)
+	}
+
+	// The long active bouts of the day are used
+	result, err = ts.Fragmentation(FragmentationOptions{MinBout: 2 * time.Minute})
+	if err != nil || !floatEquals(result.KRA, 1.0) || len(result.ActiveDistribution) != 4 || result.KAR >= 0.5 {
+		t.Error("Unexpected result: ", result.KRA, result.KAR, result.ActiveDistribution, err)
+	}
+}
@@ -0,0 +1,3 @@
+module github.com/kelvins/chronobiology
+
+go 1.13
@@ -0,0 +1,325 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Default parameters of the HDCZA algorithm (van Hees et al., 2018)
+const (
+	DefaultHDCZAWindow     = 5 * time.Minute  // Window of the rolling medians
+	DefaultHDCZAPercentile = 0.1              // Percentile of the z-angle changes of the night
+	DefaultHDCZAMultiplier = 15.0             // The threshold is the percentile multiplied by this value
+	DefaultHDCZAMin        = 0.13             // Minimum threshold (degrees)
+	DefaultHDCZAMax        = 0.5              // Maximum threshold (degrees)
+	DefaultHDCZAMinBlock   = 30 * time.Minute // Minimum duration of the blocks below the threshold
+	DefaultHDCZAMaxGap     = time.Hour        // Maximum duration between the blocks of the same window (not included)
+)
+
+// SleepWindow stores a period of a night, e.g. the sleep period time window detected by the HDCZA
+// or the time in bed reported in a sleep diary
+type SleepWindow struct {
+	// Start is the start of the window (e.g. lights off)
+	Start time.Time
+	// End is the end of the window (e.g. lights on), not included
+	End time.Time
+}
+
+// HDCZAOptions stores the parameters of the HDCZA algorithm. The zero values are replaced by the default parameters
+type HDCZAOptions struct {
+	// Window is the window of the rolling medians of the z-angle and of its changes
+	Window time.Duration
+	// Percentile is the percentile (from 0 to 1) of the z-angle changes of each night used to find the threshold
+	Percentile float64
+	// Multiplier is the value multiplied by the percentile to find the threshold
+	Multiplier float64
+	// MinThreshold and MaxThreshold limit the threshold (degrees), e.g. when the percentile is zero
+	MinThreshold float64
+	MaxThreshold float64
+	// MinBlock is the minimum duration of the blocks below the threshold
+	MinBlock time.Duration
+	// MaxGap is the duration between two blocks from which they are not joined
+	MaxGap time.Duration
+}
+
+// NightMetrics stores the sleep metrics of a night
+type NightMetrics struct {
+	// Window is the period of the night used to find the metrics (e.g. the sleep period time window)
+	Window SleepWindow
+	// SleepOnset is the start of the first epoch scored as sleep (zero when there is no sleep)
+	SleepOnset time.Time
+	// SleepOffset is the end of the last epoch scored as sleep (zero when there is no sleep)
+	SleepOffset time.Time
+	// TimeInBed is the duration of the window
+	TimeInBed time.Duration
+	// TotalSleepTime is the duration of the epochs scored as sleep
+	TotalSleepTime time.Duration
+	// WASO is the duration of the epochs scored as wake after the sleep onset and before the sleep offset
+	WASO time.Duration
+	// SleepOnsetLatency is the duration from the start of the window to the sleep onset
+	SleepOnsetLatency time.Duration
+	// SleepEfficiency is the total sleep time divided by the time in bed (from 0 to 1)
+	SleepEfficiency float64
+	// WakeBouts is the number of wake bouts after the sleep onset and before the sleep offset
+	WakeBouts int
+	// MeanWakeBout is the mean duration of the wake bouts (zero when there is no wake bout)
+	MeanWakeBout time.Duration
+	// FragmentationIndex is the number of wake bouts per hour of total sleep time (zero when there is no sleep)
+	FragmentationIndex float64
+}
+
+// Returns the noon of the day of the time passed by parameter or, before noon, the noon of the previous day
+func previousNoon(dateTime time.Time) time.Time {
+	noon := time.Date(dateTime.Year(), dateTime.Month(), dateTime.Day(), 12, 0, 0, 0, dateTime.Location())
+	if dateTime.Before(noon) {
+		noon = noon.AddDate(0, 0, -1)
+	}
+	return noon
+}
+
+// Joins the runs of true values separated by runs of false values up to the maximum number of records
+func joinShortGaps(values []bool, records int) {
+	starts, lengths := runs(values)
+	for run := 1; run < len(starts)-1; run++ {
+		if !values[starts[run]] && lengths[run] <= records {
+			for index := starts[run]; index < starts[run]+lengths[run]; index++ {
+				values[index] = true
+			}
+		}
+	}
+}
+
+// SleepPeriodWindows detects the sleep period time window of each night (from noon to noon) using the HDCZA algorithm
+// proposed by van Hees et al. (2018) on the z-angle of the wrist (e.g. aggregated using AngleZ): the absolute changes of the
+// z-angle (after a rolling median) are filtered by a rolling median and the threshold of each night is the percentile of the
+// changes multiplied by the multiplier (10th percentile * 15), limited from MinThreshold to MaxThreshold. The blocks below the
+// threshold lasting more than MinBlock are joined when they are separated by less than MaxGap, and the longest block of the night
+// is its sleep period time window.
+// The nights without any block are not returned. The gaps, the masked records and the NaN values are not used
+func (ts *TimeSeries) SleepPeriodWindows(options HDCZAOptions) (windows []SleepWindow, err error) {
+
+	if options.Window == 0 {
+		options.Window = DefaultHDCZAWindow
+	}
+	if options.Percentile == 0 {
+		options.Percentile = DefaultHDCZAPercentile
+	}
+	if options.Multiplier == 0 {
+		options.Multiplier = DefaultHDCZAMultiplier
+	}
+	if options.MinThreshold == 0 {
+		options.MinThreshold = DefaultHDCZAMin
+	}
+	if options.MaxThreshold == 0 {
+		options.MaxThreshold = DefaultHDCZAMax
+	}
+	if options.MinBlock == 0 {
+		options.MinBlock = DefaultHDCZAMinBlock
+	}
+	if options.MaxGap == 0 {
+		options.MaxGap = DefaultHDCZAMaxGap
+	}
+
+	// Check the parameters
+	if options.Percentile < 0 || options.Percentile > 1 || options.Multiplier < 0 || options.MinThreshold < 0 ||
+		options.MaxThreshold < options.MinThreshold || math.IsNaN(options.Percentile) || math.IsNaN(options.Multiplier) {
+		err = newError("SleepPeriodWindows", ErrInvalidThreshold)
+		return
+	}
+	if options.Window < 0 || options.MinBlock < 0 || options.MaxGap < 0 {
+		err = newError("SleepPeriodWindows", ErrInvalidPeriod)
+		return
+	}
+
+	if err = ts.check("SleepPeriodWindows"); err != nil {
+		return
+	}
+	if ts.Epoch <= 0 {
+		err = newError("SleepPeriodWindows", ErrInvalidEpoch)
+		return
+	}
+
+	// The gaps, the masked records and the NaN values are not used by the rolling medians
+	filled, err := ts.FillGapsInData(math.NaN())
+	if err != nil {
+		err = newError("SleepPeriodWindows", err)
+		return
+	}
+	angle := filled.Data
+	invalid := make([]bool, filled.Len())
+	for index := range invalid {
+		invalid[index] = !filled.valid(index)
+	}
+	records := durationRecords(options.Window, ts.Epoch)
+
+	// Absolute changes of the rolling median of the z-angle
+	median := medianFilter(angle, invalid, records)
+	changes := make([]float64, len(median))
+	changesInvalid := make([]bool, len(median))
+	changes[0] = math.NaN()
+	changesInvalid[0] = true
+	for index := 1; index < len(median); index++ {
+		changes[index] = math.Abs(median[index] - median[index-1])
+		if math.IsNaN(changes[index]) || invalid[index] || invalid[index-1] {
+			changes[index] = math.NaN()
+			changesInvalid[index] = true
+		}
+	}
+	signal := filled.derive(filled.DateTime, medianFilter(changes, changesInvalid, records), ts.Epoch)
+
+	epoch := time.Duration(ts.Epoch) * time.Second
+	last := signal.DateTime[signal.Len()-1]
+	for noon := previousNoon(signal.DateTime[0]); !noon.After(last); noon = noon.AddDate(0, 0, 1) {
+
+		// The end of the night is not included
+		night, _ := signal.FilterDataByDateTime(noon, noon.AddDate(0, 0, 1).Add(-time.Nanosecond))
+		if night.Len() == 0 {
+			continue
+		}
+
+		nightInvalid := make([]bool, night.Len())
+		valid := 0
+		for index, value := range night.Data {
+			nightInvalid[index] = math.IsNaN(value)
+			if !nightInvalid[index] {
+				valid++
+			}
+		}
+		if valid == 0 {
+			continue
+		}
+		threshold := percentile(night.Data, nightInvalid, options.Percentile) * options.Multiplier
+		threshold = math.Max(options.MinThreshold, math.Min(options.MaxThreshold, threshold))
+
+		// Blocks below the threshold (the gaps of the same duration as MaxGap are not joined)
+		blocks := make([]bool, night.Len())
+		for index, value := range night.Data {
+			blocks[index] = !nightInvalid[index] && value < threshold
+		}
+		removeShortRuns(blocks, true, durationRecords(options.MinBlock, ts.Epoch)+1)
+		joinShortGaps(blocks, durationRecords(options.MaxGap, ts.Epoch)-1)
+
+		// The longest block is the sleep period time window
+		longest := -1
+		starts, lengths := runs(blocks)
+		for run, start := range starts {
+			if blocks[start] && (longest < 0 || lengths[run] > lengths[longest]) {
+				longest = run
+			}
+		}
+		if longest < 0 {
+			continue
+		}
+
+		windows = append(windows, SleepWindow{
+			Start: night.DateTime[starts[longest]],
+			End:   night.DateTime[starts[longest]+lengths[longest]-1].Add(epoch),
+		})
+	}
+
+	if len(windows) == 0 {
+		err = newError("SleepPeriodWindows", ErrInsufficientData)
+	}
+	return
+}
+
+// SleepMetrics calculates the sleep metrics of each window (e.g. the sleep period time windows detected by SleepPeriodWindows
+// or the time in bed reported in a sleep diary) from the sleep (true) and wake (false) scores of the records (e.g. the output of
+// a SleepScorer). The records of each window are extracted using FilterDataByDateTime and the end of the window is not included.
+// The durations are based on the number of records, so the gaps are not counted as sleep or wake and the masked records
+// are counted as wake
+func (ts *TimeSeries) SleepMetrics(sleep []bool, windows []SleepWindow) (nights []NightMetrics, err error) {
+
+	// Check the parameters
+	if err = ts.check("SleepMetrics"); err != nil {
+		return
+	}
+	if len(sleep) != ts.Len() {
+		err = newError("SleepMetrics", ErrDifferentSize)
+		return
+	}
+	if len(windows) == 0 {
+		err = newError("SleepMetrics", ErrEmpty)
+		return
+	}
+	for _, window := range windows {
+		if !window.End.After(window.Start) {
+			err = newError("SleepMetrics", ErrInvalidTimeRange)
+			return
+		}
+	}
+	if ts.Epoch <= 0 {
+		err = newError("SleepMetrics", ErrInvalidEpoch)
+		return
+	}
+
+	// The scores are stored as a time series (1 is sleep) so the windows can be extracted
+	scores := make([]float64, ts.Len())
+	for index, value := range sleep {
+		if value && ts.valid(index) {
+			scores[index] = 1
+		}
+	}
+	scored := ts.derive(ts.DateTime, scores, ts.Epoch)
+
+	epoch := time.Duration(ts.Epoch) * time.Second
+	for _, window := range windows {
+
+		metrics := NightMetrics{Window: window, TimeInBed: window.End.Sub(window.Start)}
+
+		night, _ := scored.FilterDataByDateTime(window.Start, window.End.Add(-time.Nanosecond))
+		values := make([]bool, night.Len())
+		first, last := -1, -1
+		for index, value := range night.Data {
+			values[index] = value == 1
+			if values[index] {
+				if first < 0 {
+					first = index
+				}
+				last = index
+				metrics.TotalSleepTime += epoch
+			}
+		}
+
+		if first >= 0 {
+			metrics.SleepOnset = night.DateTime[first]
+			metrics.SleepOffset = night.DateTime[last].Add(epoch)
+			metrics.SleepOnsetLatency = metrics.SleepOnset.Sub(window.Start)
+
+			starts, lengths := runs(values[first : last+1])
+			for run, start := range starts {
+				if !values[first+start] {
+					metrics.WakeBouts++
+					metrics.WASO += time.Duration(lengths[run]) * epoch
+				}
+			}
+			if metrics.WakeBouts > 0 {
+				metrics.MeanWakeBout = metrics.WASO / time.Duration(metrics.WakeBouts)
+			}
+			metrics.FragmentationIndex = float64(metrics.WakeBouts) / metrics.TotalSleepTime.Hours()
+		}
+
+		metrics.SleepEfficiency = float64(metrics.TotalSleepTime) / float64(metrics.TimeInBed)
+		nights = append(nights, metrics)
+	}
+
+	return
+}
+
+// SleepPeriodWindows detects the sleep period time window of each night using the HDCZA algorithm (van Hees et al., 2018)
+func SleepPeriodWindows(options HDCZAOptions, dateTime []time.Time, data []float64) (windows []SleepWindow, err error) {
+	ts, err := wrapTimeSeries("SleepPeriodWindows", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.SleepPeriodWindows(options)
+}
+
+// SleepMetrics calculates the sleep metrics of each window from the sleep (true) and wake (false) scores of the records
+func SleepMetrics(sleep []bool, windows []SleepWindow, dateTime []time.Time, data []float64) (nights []NightMetrics, err error) {
+	ts, err := wrapTimeSeries("SleepMetrics", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.SleepMetrics(sleep, windows)
+}
@@ -0,0 +1,128 @@
+package chronobiology
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// Absolute value of the difference between two times
+func timeDistance(a time.Time, b time.Time) time.Duration {
+	if a.After(b) {
+		return a.Sub(b)
+	}
+	return b.Sub(a)
+}
+
+func TestSleepPeriodWindows(t *testing.T) {
+
+	// 3 days of z-angle (1 minute epochs): still from 23h to 7h (with a change of posture at 3h) and moving during the day
+	var data []float64
+	for index := 0; index < 3*24*60; index++ {
+		hour := (index / 60) % 24
+		switch {
+		case hour >= 3 && hour < 7:
+			data = append(data, 40.0+0.01*noise(index))
+		case hour >= 23 || hour < 3:
+			data = append(data, -20.0+0.01*noise(index))
+		default:
+			data = append(data, 60.0*noise(index))
+		}
+	}
+	ts := minuteSeries(data)
+
+	windows, err := SleepPeriodWindows(HDCZAOptions{}, ts.DateTime, ts.Data)
+	if err != nil || len(windows) != 4 {
+		t.Fatal("Expected: 4 windows, Received: ", windows, err)
+	}
+
+	// The first window starts with the data and the last one ends with the data
+	start := ts.DateTime[0]
+	expected := []SleepWindow{
+		{start, start.Add(7 * time.Hour)},
+		{start.Add(23 * time.Hour), start.Add(31 * time.Hour)},
+		{start.Add(47 * time.Hour), start.Add(55 * time.Hour)},
+		{start.Add(71 * time.Hour), start.Add(72 * time.Hour)},
+	}
+	for index, window := range windows {
+		if timeDistance(window.Start, expected[index].Start) > 5*time.Minute || timeDistance(window.End, expected[index].End) > 5*time.Minute {
+			t.Error("Expected: ", expected[index], "Received: ", window)
+		}
+	}
+
+	// Without the blocks longer than 2 hours only the last night (1 hour) is not found
+	windows, _ = ts.SleepPeriodWindows(HDCZAOptions{MinBlock: 2 * time.Hour})
+	if len(windows) != 3 {
+		t.Error("Expected: 3 windows, Received: ", windows)
+	}
+
+	// Invalid parameters
+	if _, err = ts.SleepPeriodWindows(HDCZAOptions{Percentile: 2}); !errors.Is(err, ErrInvalidThreshold) {
+		t.Error("Expected: ErrInvalidThreshold, Received: ", err)
+	}
+	if _, err = ts.SleepPeriodWindows(HDCZAOptions{MaxGap: -time.Hour}); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+
+	// Always moving
+	moving := minuteSeries(data[7*60 : 23*60])
+	if _, err = moving.SleepPeriodWindows(HDCZAOptions{}); !errors.Is(err, ErrInsufficientData) {
+		t.Error("Expected: ErrInsufficientData, Received: ", err)
+	}
+}
+
+func TestSleepMetrics(t *testing.T) {
+
+	// 5 hours of scores: the window from 0h to 4h and a window without sleep from 4h to 5h
+	sleep := append(scoreRuns(30, 60, 10, 120, 5, 5, 10), repeatMask(false, 60)...)
+	ts := minuteSeries(repeatValue(0, len(sleep)))
+	start := ts.DateTime[0]
+	windows := []SleepWindow{
+		{start, start.Add(4 * time.Hour)},
+		{start.Add(4 * time.Hour), start.Add(5 * time.Hour)},
+	}
+
+	nights, err := SleepMetrics(sleep, windows, ts.DateTime, ts.Data)
+	if err != nil || len(nights) != 2 {
+		t.Fatal("Expected: 2 nights, Received: ", nights, err)
+	}
+
+	night := nights[0]
+	if !night.SleepOnset.Equal(start.Add(30*time.Minute)) || !night.SleepOffset.Equal(start.Add(230*time.Minute)) {
+		t.Error("Expected: 00:30 and 03:50, Received: ", night.SleepOnset, night.SleepOffset)
+	}
+	if night.TimeInBed != 4*time.Hour || night.TotalSleepTime != 185*time.Minute || night.WASO != 15*time.Minute ||
+		night.SleepOnsetLatency != 30*time.Minute {
+		t.Error("Unexpected durations: ", night.TimeInBed, night.TotalSleepTime, night.WASO, night.SleepOnsetLatency)
+	}
+	if night.WakeBouts != 2 || night.MeanWakeBout != 7*time.Minute+30*time.Second {
+		t.Error("Expected: 2 wake bouts of 7m30s, Received: ", night.WakeBouts, night.MeanWakeBout)
+	}
+	if !floatEquals(night.SleepEfficiency, 185.0/240.0) || !floatEquals(night.FragmentationIndex, 2.0/(185.0/60.0)) {
+		t.Error("Unexpected efficiency or fragmentation: ", night.SleepEfficiency, night.FragmentationIndex)
+	}
+
+	night = nights[1]
+	if !night.SleepOnset.IsZero() || night.TotalSleepTime != 0 || night.SleepEfficiency != 0 || night.WakeBouts != 0 {
+		t.Error("Expected a night without sleep, Received: ", night)
+	}
+
+	// The masked records are counted as wake
+	ts.Mask = repeatMask(false, ts.Len())
+	ts.Mask[60] = true
+	nights, _ = ts.SleepMetrics(sleep, windows[:1])
+	if nights[0].WakeBouts != 3 || nights[0].TotalSleepTime != 184*time.Minute {
+		t.Error("Expected: 3 wake bouts and 184 minutes of sleep, Received: ", nights[0].WakeBouts, nights[0].TotalSleepTime)
+	}
+
+	// Invalid parameters
+	if _, err = ts.SleepMetrics(sleep[1:], windows); !errors.Is(err, ErrDifferentSize) {
+		t.Error("Expected: ErrDifferentSize, Received: ", err)
+	}
+	if _, err = ts.SleepMetrics(sleep, nil); !errors.Is(err, ErrEmpty) {
+		t.Error("Expected: ErrEmpty, Received: ", err)
+	}
+	if _, err = ts.SleepMetrics(sleep, []SleepWindow{{start, start}}); !errors.Is(err, ErrInvalidTimeRange) {
+		t.Error("Expected: ErrInvalidTimeRange, Received: ", err)
+	}
+}
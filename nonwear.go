@@ -0,0 +1,208 @@
+package chronobiology
+
+import (
+	"sort"
+	"time"
+)
+
+// Parameters used by the non-wear detection algorithms
+const (
+	troianoWindow      = 60    // Minimum length of a non-wear period (minutes)
+	troianoSpike       = 2     // Maximum length of the tolerated interruptions (minutes)
+	troianoSpikeCounts = 100   // Maximum value of the tolerated interruptions (counts per minute)
+	choiWindow         = 90    // Minimum length of a non-wear period (minutes)
+	choiSpike          = 2     // Maximum length of the tolerated interruptions (minutes)
+	choiSpikeWindow    = 30    // Zero counts required before and after a tolerated interruption (minutes)
+	vanHeesWindow      = 60    // Length of the windows (minutes)
+	vanHeesStep        = 15    // Step between two consecutive windows (minutes)
+	vanHeesSD          = 0.013 // Maximum standard deviation of a still axis (g)
+	vanHeesRange       = 0.050 // Maximum value range of a still axis (g)
+)
+
+// Converts a number of minutes to a number of records of the time series (at least one record)
+func (ts *TimeSeries) records(minutes int) int {
+	records := minutes * 60 / ts.Epoch
+	if records < 1 {
+		return 1
+	}
+	return records
+}
+
+// Checks the time series used by the count based non-wear algorithms
+func (ts *TimeSeries) checkNonWear(function string) error {
+	if err := checkSlices(function, ts.DateTime, ts.Data); err != nil {
+		return err
+	}
+	if ts.Epoch <= 0 {
+		return newError(function, ErrInvalidEpoch)
+	}
+	return nil
+}
+
+// Marks the runs of zero values lasting at least minLength records. The runs of non-zero values surrounded by zeros
+// and lasting up to spikeLength records are considered part of the run when tolerated returns true
+// (start and end are the positions of the interruption, end not included)
+func zeroRuns(data []float64, minLength int, spikeLength int, tolerated func(start int, end int) bool) (mask []bool) {
+
+	zero := make([]bool, len(data))
+	for index := 0; index < len(data); index++ {
+		zero[index] = floatEquals(data[index], 0.0)
+	}
+
+	// Tolerated interruptions
+	still := make([]bool, len(data))
+	copy(still, zero)
+	for start := 0; start < len(data); {
+		if zero[start] {
+			start++
+			continue
+		}
+		end := start
+		for end < len(data) && !zero[end] {
+			end++
+		}
+		if start > 0 && end < len(data) && end-start <= spikeLength && tolerated(start, end) {
+			for index := start; index < end; index++ {
+				still[index] = true
+			}
+		}
+		start = end
+	}
+
+	// Runs long enough to be considered non-wear
+	mask = make([]bool, len(data))
+	for start := 0; start < len(data); {
+		if !still[start] {
+			start++
+			continue
+		}
+		end := start
+		for end < len(data) && still[end] {
+			end++
+		}
+		if end-start >= minLength {
+			for index := start; index < end; index++ {
+				mask[index] = true
+			}
+		}
+		start = end
+	}
+
+	return
+}
+
+// NonWearTroiano detects the non-wear periods of an activity count time series using the algorithm proposed by Troiano et al. (2008):
+// at least 60 consecutive minutes of zero counts, allowing interruptions of up to 2 minutes with counts between 1 and 100 (per minute).
+// It returns a mask (true means non-wear) that can be assigned to the Mask field of the time series.
+// The records are assumed to be consecutive, so the gaps should be filled (e.g. FillGapsInData(0)) before the detection
+func (ts *TimeSeries) NonWearTroiano() (mask []bool, err error) {
+
+	if err = ts.checkNonWear("NonWearTroiano"); err != nil {
+		return
+	}
+
+	limit := troianoSpikeCounts * float64(ts.Epoch) / 60.0
+
+	mask = zeroRuns(ts.Data, ts.records(troianoWindow), ts.records(troianoSpike), func(start int, end int) bool {
+		for index := start; index < end; index++ {
+			if ts.Data[index] > limit {
+				return false
+			}
+		}
+		return true
+	})
+	return
+}
+
+// NonWearChoi detects the non-wear periods of an activity count time series (preferably the vector magnitude) using the algorithm
+// proposed by Choi et al. (2011): at least 90 consecutive minutes of zero counts, allowing interruptions of up to 2 minutes
+// when there are 30 minutes of zero counts before and after them.
+// It returns a mask (true means non-wear) that can be assigned to the Mask field of the time series.
+// The records are assumed to be consecutive, so the gaps should be filled (e.g. FillGapsInData(0)) before the detection
+func (ts *TimeSeries) NonWearChoi() (mask []bool, err error) {
+
+	if err = ts.checkNonWear("NonWearChoi"); err != nil {
+		return
+	}
+
+	window := ts.records(choiSpikeWindow)
+
+	mask = zeroRuns(ts.Data, ts.records(choiWindow), ts.records(choiSpike), func(start int, end int) bool {
+		if start-window < 0 || end+window > len(ts.Data) {
+			return false
+		}
+		for index := start - window; index < end+window; index++ {
+			if (index < start || index >= end) && !floatEquals(ts.Data[index], 0.0) {
+				return false
+			}
+		}
+		return true
+	})
+	return
+}
+
+// NonWearVanHees detects the non-wear periods of the raw acceleration using the algorithm proposed by van Hees et al. (2013):
+// each block of 15 minutes is classified using the 60 minutes window centred on it, which is considered non-wear when
+// at least 2 axes are still (standard deviation below 13 mg and value range below 50 mg, as implemented by GGIR).
+// It returns a mask aligned with the time series returned by Aggregate using the same epoch (seconds)
+func (raw *RawAcceleration) NonWearVanHees(epoch int) (mask []bool, err error) {
+
+	if raw.Len() == 0 {
+		err = newError("NonWearVanHees", ErrEmpty)
+		return
+	}
+	if epoch <= 0 {
+		err = newError("NonWearVanHees", ErrInvalidEpoch)
+		return
+	}
+
+	start := raw.DateTime[0]
+	step := time.Duration(vanHeesStep) * time.Minute
+	window := time.Duration(vanHeesWindow) * time.Minute
+
+	blocks := int(raw.DateTime[raw.Len()-1].Sub(start)/step) + 1
+	nonWear := make([]bool, blocks)
+
+	for block := 0; block < blocks; block++ {
+
+		windowStart := start.Add(time.Duration(block)*step - (window-step)/2)
+		windowEnd := windowStart.Add(window)
+
+		first := sort.Search(raw.Len(), func(index int) bool { return !raw.DateTime[index].Before(windowStart) })
+		last := sort.Search(raw.Len(), func(index int) bool { return !raw.DateTime[index].Before(windowEnd) })
+		if last-first < 2 {
+			continue
+		}
+
+		stillAxes := 0
+		for _, axis := range [][]float64{raw.X, raw.Y, raw.Z} {
+			values := axis[first:last]
+			_, sd := meanSD(values)
+
+			min, max := values[0], values[0]
+			for _, value := range values {
+				if value < min {
+					min = value
+				}
+				if value > max {
+					max = value
+				}
+			}
+
+			if sd < vanHeesSD && max-min < vanHeesRange {
+				stillAxes++
+			}
+		}
+
+		nonWear[block] = stillAxes >= 2
+	}
+
+	dateTime, _ := raw.epochs(epoch)
+
+	mask = make([]bool, len(dateTime))
+	for index := 0; index < len(dateTime); index++ {
+		mask[index] = nonWear[int(dateTime[index].Sub(start)/step)]
+	}
+
+	return
+}
@@ -0,0 +1,222 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Creates a slice with the value repeated n times
+func repeatValue(value float64, n int) []float64 {
+	data := make([]float64, n)
+	for index := range data {
+		data[index] = value
+	}
+	return data
+}
+
+// Creates a time series (epoch of 60 seconds) with the values passed by parameter
+func minuteSeries(data []float64) *TimeSeries {
+	utc, _ := time.LoadLocation("UTC")
+	tempDateTime := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	var dateTime []time.Time
+	for index := 0; index < len(data); index++ {
+		dateTime = append(dateTime, tempDateTime)
+		tempDateTime = tempDateTime.Add(time.Minute)
+	}
+
+	ts, _ := NewTimeSeries(dateTime, data)
+	return ts
+}
+
+// Counts the masked records between the start and end positions (end not included)
+func countMasked(mask []bool, start int, end int) (count int) {
+	for index := start; index < end; index++ {
+		if mask[index] {
+			count++
+		}
+	}
+	return
+}
+
+// Joins the slices passed by parameter
+func joinData(parts ...[]float64) (data []float64) {
+	for _, part := range parts {
+		data = append(data, part...)
+	}
+	return
+}
+
+func TestNonWear(t *testing.T) {
+
+	// Table tests
+	var tTests = []struct {
+		data          []float64
+		troiano, choi int
+	}{
+		// 70 minutes of zeros
+		{joinData(repeatValue(500, 30), repeatValue(0, 70), repeatValue(500, 30)), 70, 0},
+		// 100 minutes of zeros with an interruption of 2 minutes (50 counts) in the middle
+		{joinData(repeatValue(500, 30), repeatValue(0, 49), repeatValue(50, 2), repeatValue(0, 49), repeatValue(500, 30)), 100, 100},
+		// The interruption has more than 100 counts (only accepted by Choi)
+		{joinData(repeatValue(500, 30), repeatValue(0, 49), repeatValue(150, 2), repeatValue(0, 49), repeatValue(500, 30)), 0, 100},
+		// The interruption lasts 3 minutes
+		{joinData(repeatValue(500, 30), repeatValue(0, 49), repeatValue(50, 3), repeatValue(0, 49), repeatValue(500, 30)), 0, 0},
+		// There are not 30 minutes of zeros after the interruption (only accepted by Troiano)
+		{joinData(repeatValue(500, 30), repeatValue(0, 75), repeatValue(50, 2), repeatValue(0, 20), repeatValue(500, 30)), 97, 0},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		ts := minuteSeries(table.data)
+
+		troiano, err := ts.NonWearTroiano()
+		if err != nil || countMasked(troiano, 0, len(troiano)) != table.troiano || countMasked(troiano, 0, 30) != 0 {
+			t.Error(
+				"Expected: ", table.troiano,
+				"Received: ", countMasked(troiano, 0, len(troiano)), err,
+			)
+		}
+
+		choi, err := ts.NonWearChoi()
+		if err != nil || countMasked(choi, 0, len(choi)) != table.choi || countMasked(choi, 0, 30) != 0 {
+			t.Error(
+				"Expected: ", table.choi,
+				"Received: ", countMasked(choi, 0, len(choi)), err,
+			)
+		}
+	}
+
+	// Epoch of 30 seconds: 60 minutes are 120 records
+	ts := minuteSeries(joinData(repeatValue(500, 10), repeatValue(0, 119), repeatValue(500, 10)))
+	ts.Epoch = 30
+	mask, _ := ts.NonWearTroiano()
+	if countMasked(mask, 0, len(mask)) != 0 {
+		t.Error("Expected: 0 records masked, Received: ", countMasked(mask, 0, len(mask)))
+	}
+
+	ts.Epoch = 0
+	if _, err := ts.NonWearChoi(); !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+}
+
+func TestNonWearVanHees(t *testing.T) {
+
+	// 2 hours on a table followed by 2 hours of movement (1 Hz)
+	raw := rawSignal(1, 4*60*60, func(second float64) (x, y, z float64) {
+		if second < 2*60*60 {
+			return 0.001 * math.Sin(second), 0, 1
+		}
+		return 0.5 * math.Sin(second), 0.3 * math.Cos(second), 1
+	})
+
+	mask, err := raw.NonWearVanHees(60)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if len(mask) != 240 {
+		t.Fatal("Expected: 240 epochs, Received: ", len(mask))
+	}
+
+	// The windows (60 minutes) of the blocks that start after 1h30 overlap the movement
+	if countMasked(mask, 0, 90) != 90 || countMasked(mask, 90, 240) != 0 {
+		t.Error("Expected: 90 epochs masked, Received: ", countMasked(mask, 0, 240))
+	}
+
+	if _, err = raw.NonWearVanHees(0); !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+}
+
+func TestMask(t *testing.T) {
+
+	// 3 days with a daily pattern, the device was removed on the second day (from 8h to 12h)
+	var data []float64
+	for index := 0; index < 3*1440; index++ {
+		data = append(data, 100.0+50.0*math.Sin(2.0*math.Pi*float64(index)/1440.0)+float64(index%7))
+	}
+	mask := make([]bool, len(data))
+	for index := 1440 + 8*60; index < 1440+12*60; index++ {
+		mask[index] = true
+	}
+
+	// The masked records must not change the results
+	removed := minuteSeries(data)
+	removed.Mask = mask
+	for index := range mask {
+		if mask[index] {
+			removed.Data[index] = 0.0
+		}
+	}
+	different := minuteSeries(data)
+	different.Mask = mask
+	for index := range mask {
+		if mask[index] {
+			different.Data[index] = 10000.0
+		}
+	}
+
+	m10, onsetM10, err1 := removed.M10()
+	m10Different, onsetM10Different, err2 := different.M10()
+	if err1 != nil || err2 != nil || !floatEquals(m10, m10Different) || !onsetM10.Equal(onsetM10Different) {
+		t.Error("Expected the same M10. Received: ", m10, m10Different, err1, err2)
+	}
+
+	l5, onsetL5, err1 := removed.L5()
+	l5Different, onsetL5Different, err2 := different.L5()
+	if err1 != nil || err2 != nil || !floatEquals(l5, l5Different) || !onsetL5.Equal(onsetL5Different) {
+		t.Error("Expected the same L5. Received: ", l5, l5Different, err1, err2)
+	}
+
+	averageDay, err1 := removed.AverageDay()
+	averageDayDifferent, err2 := different.AverageDay()
+	if err1 != nil || err2 != nil || !sliceFloatEquals(averageDay.Data, averageDayDifferent.Data) {
+		t.Error("Expected the same average day. Received: ", err1, err2)
+	}
+
+	iv, err1 := removed.IntradailyVariability()
+	ivDifferent, err2 := different.IntradailyVariability()
+	if err1 != nil || err2 != nil || !sliceFloatEquals(iv, ivDifferent) {
+		t.Error("Expected the same IV. Received: ", iv[0], ivDifferent[0], err1, err2)
+	}
+
+	is, err1 := removed.InterdailyStability()
+	isDifferent, err2 := different.InterdailyStability()
+	if err1 != nil || err2 != nil || !sliceFloatEquals(is, isDifferent) {
+		t.Error("Expected the same IS. Received: ", is[0], isDifferent[0], err1, err2)
+	}
+
+	// Without the mask, the zeros reduce the interdaily stability
+	removed.Mask = nil
+	isZeros, _ := removed.InterdailyStability()
+	if isZeros[0] >= is[0] {
+		t.Error("Expected IS below ", is[0], "Received: ", isZeros[0])
+	}
+
+	// All the records masked
+	different.Mask = repeatMask(true, len(data))
+	if _, _, err := different.M10(); !errors.Is(err, ErrMasked) {
+		t.Error("Expected: ErrMasked, Received: ", err)
+	}
+	if _, _, err := different.L5(); !errors.Is(err, ErrMasked) {
+		t.Error("Expected: ErrMasked, Received: ", err)
+	}
+
+	// Invalid mask
+	different.Mask = mask[:10]
+	if _, err := different.AverageDay(); !errors.Is(err, ErrDifferentSize) {
+		t.Error("Expected: ErrDifferentSize, Received: ", err)
+	}
+}
+
+// Creates a mask with the value repeated n times
+func repeatMask(value bool, n int) []bool {
+	mask := make([]bool, n)
+	for index := range mask {
+		mask[index] = value
+	}
+	return mask
+}
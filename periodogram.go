@@ -0,0 +1,321 @@
+package chronobiology
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Periodogram stores the power of each period tested by a periodogram and its significance threshold
+type Periodogram struct {
+	// Periods stores the periods tested
+	Periods []time.Duration
+	// Power stores the power of each period (e.g. Qp for the chi-square periodogram)
+	Power []float64
+	// Threshold stores the significance threshold of each period based on the alpha level
+	Threshold []float64
+	// FalseAlarm stores the false-alarm probability of each power (only calculated by the Lomb-Scargle periodogram)
+	FalseAlarm []float64
+	// Alpha is the significance level used to find the thresholds
+	Alpha float64
+	// Peaks stores the significant peaks (local maxima above the threshold), from the highest to the lowest above the threshold
+	Peaks []PeriodogramPeak
+}
+
+// PeriodogramPeak stores a significant peak of a periodogram
+type PeriodogramPeak struct {
+	// Period is the period of the peak
+	Period time.Duration
+	// Power is the power of the peak
+	Power float64
+	// Threshold is the significance threshold of the period
+	Threshold float64
+	// FalseAlarm is the false-alarm probability of the peak (only calculated by the Lomb-Scargle periodogram)
+	FalseAlarm float64
+}
+
+// DominantPeriod returns the period of the highest peak above the threshold (zero when there is no significant peak)
+func (periodogram *Periodogram) DominantPeriod() time.Duration {
+	if len(periodogram.Peaks) == 0 {
+		return 0
+	}
+	return periodogram.Peaks[0].Period
+}
+
+// Finds the significant peaks (local maxima above the threshold) and sorts them by the power above the threshold
+func (periodogram *Periodogram) findPeaks() {
+	periodogram.Peaks = nil
+	power := periodogram.Power
+	for index := 1; index < len(power)-1; index++ {
+		if power[index] > power[index-1] && power[index] >= power[index+1] && power[index] > periodogram.Threshold[index] {
+			peak := PeriodogramPeak{
+				Period:    periodogram.Periods[index],
+				Power:     power[index],
+				Threshold: periodogram.Threshold[index],
+			}
+			if periodogram.FalseAlarm != nil {
+				peak.FalseAlarm = periodogram.FalseAlarm[index]
+			}
+			periodogram.Peaks = append(periodogram.Peaks, peak)
+		}
+	}
+	sort.SliceStable(periodogram.Peaks, func(i, j int) bool {
+		return periodogram.Peaks[i].Power-periodogram.Peaks[i].Threshold > periodogram.Peaks[j].Power-periodogram.Peaks[j].Threshold
+	})
+}
+
+// Checks the parameters of the periodograms
+func checkPeriodogram(function string, minPeriod time.Duration, maxPeriod time.Duration, step time.Duration, alpha float64) error {
+	if minPeriod <= 0 || maxPeriod < minPeriod || step < 0 {
+		return newError(function, ErrInvalidPeriod)
+	}
+	if alpha <= 0 || alpha >= 1 {
+		return newError(function, ErrInvalidAlpha)
+	}
+	return nil
+}
+
+// ChiSquarePeriodogram calculates the chi-square periodogram proposed by Sokolove and Bushell (1978), based on Enright (1965),
+// testing the periods from minPeriod to maxPeriod (step is the time series epoch when zero). The data is folded at each period
+// and Qp is compared with the chi-square distribution (period / epoch - 1 degrees of freedom) at the alpha level (e.g. 0.01).
+// The gaps, the masked records and the NaN values are not used. Only the periods up to half of the time series are tested
+func (ts *TimeSeries) ChiSquarePeriodogram(minPeriod time.Duration, maxPeriod time.Duration, step time.Duration, alpha float64) (periodogram Periodogram, err error) {
+
+	// Check the parameters
+	if err = ts.check("ChiSquarePeriodogram"); err != nil {
+		return
+	}
+	if err = checkPeriodogram("ChiSquarePeriodogram", minPeriod, maxPeriod, step, alpha); err != nil {
+		return
+	}
+	if ts.Epoch == 0 {
+		err = newError("ChiSquarePeriodogram", ErrInvalidEpoch)
+		return
+	}
+
+	epoch := time.Duration(ts.Epoch) * time.Second
+	if step == 0 {
+		step = epoch
+	}
+
+	// The gaps are filled with NaN, so the records are consecutive
+	filled, err := ts.FillGapsInData(math.NaN())
+	if err != nil {
+		err = newError("ChiSquarePeriodogram", err)
+		return
+	}
+
+	data := make([]float64, filled.Len())
+	n := 0
+	mean := 0.0
+	for index := 0; index < filled.Len(); index++ {
+		data[index] = math.NaN()
+		if filled.valid(index) {
+			data[index] = filled.Data[index]
+			mean += data[index]
+			n++
+		}
+	}
+	if n == 0 {
+		err = newError("ChiSquarePeriodogram", ErrMasked)
+		return
+	}
+	mean /= float64(n)
+
+	total := 0.0
+	for _, value := range data {
+		if !math.IsNaN(value) {
+			total += (value - mean) * (value - mean)
+		}
+	}
+
+	periodogram.Alpha = alpha
+	lastColumns := 0
+
+	for period := minPeriod; period <= maxPeriod; period += step {
+
+		// Number of records (columns) of the period
+		columns := int(math.Floor(float64(period)/float64(epoch) + 0.5))
+		if columns < 2 || columns == lastColumns {
+			continue
+		}
+		if columns > len(data)/2 {
+			break
+		}
+		lastColumns = columns
+
+		sums := make([]float64, columns)
+		counts := make([]int, columns)
+		for index, value := range data {
+			if !math.IsNaN(value) {
+				sums[index%columns] += value
+				counts[index%columns]++
+			}
+		}
+
+		// Qp = N * sum(n_h * (M_h - M)^2) / sum((X_i - M)^2)
+		numerator := 0.0
+		used := 0
+		for column := 0; column < columns; column++ {
+			if counts[column] > 0 {
+				columnMean := sums[column] / float64(counts[column])
+				numerator += float64(counts[column]) * (columnMean - mean) * (columnMean - mean)
+				used++
+			}
+		}
+
+		qp := 0.0
+		if total > 0 {
+			qp = float64(n) * numerator / total
+		}
+
+		periodogram.Periods = append(periodogram.Periods, time.Duration(columns)*epoch)
+		periodogram.Power = append(periodogram.Power, qp)
+		periodogram.Threshold = append(periodogram.Threshold, chiSquareQuantile(1.0-alpha, float64(used-1)))
+	}
+
+	if len(periodogram.Periods) == 0 {
+		err = durationError("ChiSquarePeriodogram", 2*minPeriod, ts.Duration())
+		return
+	}
+
+	periodogram.findPeaks()
+	return
+}
+
+// ChiSquarePeriodogram calculates the chi-square periodogram (Sokolove and Bushell, 1978) from minPeriod to maxPeriod
+func ChiSquarePeriodogram(minPeriod time.Duration, maxPeriod time.Duration, step time.Duration, alpha float64, dateTime []time.Time, data []float64) (periodogram Periodogram, err error) {
+	ts, err := wrapTimeSeries("ChiSquarePeriodogram", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.ChiSquarePeriodogram(minPeriod, maxPeriod, step, alpha)
+}
+
+// LombScarglePeriodogram calculates the normalized Lomb-Scargle periodogram (Lomb, 1976; Scargle, 1982) directly from the
+// records, so the data does not need to be evenly sampled (e.g. core body temperature or melatonin samples).
+// The periods from minPeriod to maxPeriod are tested using the step passed by parameter or, when it is zero, using
+// frequencies evenly spaced by 1 / (4 * duration of the time series). The power is normalized by the variance of the data
+// (Horne and Baliunas, 1986) and the false-alarm probabilities consider the number of independent frequencies in the range.
+// The threshold is the power with false-alarm probability equal to alpha. The masked records and the NaN values are not used
+func (ts *TimeSeries) LombScarglePeriodogram(minPeriod time.Duration, maxPeriod time.Duration, step time.Duration, alpha float64) (periodogram Periodogram, err error) {
+
+	// Check the parameters
+	if err = ts.check("LombScarglePeriodogram"); err != nil {
+		return
+	}
+	if err = checkPeriodogram("LombScarglePeriodogram", minPeriod, maxPeriod, step, alpha); err != nil {
+		return
+	}
+
+	// Time (hours) elapsed since the first valid record
+	var hours []float64
+	var data []float64
+	var first time.Time
+	for index := 0; index < ts.Len(); index++ {
+		if ts.valid(index) {
+			if len(hours) == 0 {
+				first = ts.DateTime[index]
+			}
+			hours = append(hours, ts.DateTime[index].Sub(first).Hours())
+			data = append(data, ts.Data[index])
+		}
+	}
+
+	n := len(data)
+	if n < 3 {
+		err = newError("LombScarglePeriodogram", ErrInsufficientData)
+		return
+	}
+
+	mean := average(data)
+	variance := 0.0
+	for index := range data {
+		data[index] -= mean
+		variance += data[index] * data[index]
+	}
+	variance /= float64(n - 1)
+
+	lowest, highest := hours[0], hours[0]
+	for _, value := range hours {
+		lowest = math.Min(lowest, value)
+		highest = math.Max(highest, value)
+	}
+	span := highest - lowest
+	if span <= 0 {
+		err = durationError("LombScarglePeriodogram", minPeriod, 0)
+		return
+	}
+
+	// Periods tested (from the shortest to the longest)
+	if step > 0 {
+		for period := minPeriod; period <= maxPeriod; period += step {
+			periodogram.Periods = append(periodogram.Periods, period)
+		}
+	} else {
+		maxFrequency := 1.0 / minPeriod.Hours()
+		minFrequency := 1.0 / maxPeriod.Hours()
+		resolution := 1.0 / (4.0 * span)
+		for frequency := maxFrequency; frequency > minFrequency; frequency -= resolution {
+			periodogram.Periods = append(periodogram.Periods, time.Duration(float64(time.Hour)/frequency))
+		}
+		periodogram.Periods = append(periodogram.Periods, maxPeriod)
+	}
+
+	// Number of independent frequencies in the range (Press et al., Numerical Recipes)
+	independent := math.Floor(span*(1.0/minPeriod.Hours()-1.0/maxPeriod.Hours()) + 0.5)
+	independent = math.Max(1.0, math.Min(float64(n), independent))
+
+	threshold := -math.Log(1.0 - math.Pow(1.0-alpha, 1.0/independent))
+
+	periodogram.Alpha = alpha
+	for _, period := range periodogram.Periods {
+
+		omega := 2.0 * math.Pi / period.Hours()
+
+		// Time offset (tau) that makes the sine and cosine terms orthogonal
+		sin2, cos2 := 0.0, 0.0
+		for _, value := range hours {
+			sin2 += math.Sin(2.0 * omega * value)
+			cos2 += math.Cos(2.0 * omega * value)
+		}
+		tau := math.Atan2(sin2, cos2) / (2.0 * omega)
+
+		yc, ys, cc, ss := 0.0, 0.0, 0.0, 0.0
+		for index, value := range hours {
+			cosine := math.Cos(omega * (value - tau))
+			sine := math.Sin(omega * (value - tau))
+			yc += data[index] * cosine
+			ys += data[index] * sine
+			cc += cosine * cosine
+			ss += sine * sine
+		}
+
+		power := 0.0
+		if variance > 0 {
+			if cc > 0 {
+				power += yc * yc / cc
+			}
+			if ss > 0 {
+				power += ys * ys / ss
+			}
+			power /= 2.0 * variance
+		}
+
+		periodogram.Power = append(periodogram.Power, power)
+		periodogram.Threshold = append(periodogram.Threshold, threshold)
+		periodogram.FalseAlarm = append(periodogram.FalseAlarm, 1.0-math.Pow(1.0-math.Exp(-power), independent))
+	}
+
+	periodogram.findPeaks()
+	return
+}
+
+// LombScarglePeriodogram calculates the Lomb-Scargle periodogram of unevenly sampled data from minPeriod to maxPeriod
+func LombScarglePeriodogram(minPeriod time.Duration, maxPeriod time.Duration, step time.Duration, alpha float64, dateTime []time.Time, data []float64) (periodogram Periodogram, err error) {
+	ts, err := wrapTimeSeries("LombScarglePeriodogram", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.LombScarglePeriodogram(minPeriod, maxPeriod, step, alpha)
+}
@@ -0,0 +1,167 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Creates the wheel-running activity (6 minutes epoch) of a free-running rodent (active during half of each cycle)
+func freeRunning(days int, tau time.Duration) (dateTime []time.Time, data []float64) {
+	utc, _ := time.LoadLocation("UTC")
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	for index := 0; index < days*240; index++ {
+		current := start.Add(time.Duration(index) * 6 * time.Minute)
+		phase := math.Mod(float64(current.Sub(start)), float64(tau)) / float64(tau)
+		value := 5.0 + 3.0*noise(index)
+		if phase < 0.5 {
+			value += 100.0 + 20.0*noise(index+7)
+		}
+		dateTime = append(dateTime, current)
+		data = append(data, value)
+	}
+	return
+}
+
+func TestChiSquarePeriodogram(t *testing.T) {
+
+	tau := 23*time.Hour + 36*time.Minute
+	dateTime, data := freeRunning(10, tau)
+
+	periodogram, err := ChiSquarePeriodogram(20*time.Hour, 28*time.Hour, 0, 0.01, dateTime, data)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if len(periodogram.Periods) != 81 || len(periodogram.Power) != 81 || len(periodogram.Threshold) != 81 {
+		t.Error("Expected: 81 periods, Received: ", len(periodogram.Periods))
+	}
+	if periodogram.DominantPeriod() != tau {
+		t.Error("Expected: ", tau, "Received: ", periodogram.DominantPeriod())
+	}
+	if periodogram.Peaks[0].Power < periodogram.Peaks[0].Threshold || periodogram.Alpha != 0.01 {
+		t.Error("Unexpected peak: ", periodogram.Peaks[0])
+	}
+
+	// 239 degrees of freedom at 24 hours
+	if math.Abs(periodogram.Threshold[40]-chiSquareQuantile(0.99, 239)) > 1e-9 || periodogram.Periods[40] != 24*time.Hour {
+		t.Error("Unexpected threshold: ", periodogram.Periods[40], periodogram.Threshold[40])
+	}
+
+	// Missing data (gaps, NaN and masked records) does not change the dominant period
+	ts, _ := NewTimeSeries(append(dateTime[:500:500], dateTime[600:]...), append(data[:500:500], data[600:]...))
+	ts.Data[1000] = math.NaN()
+	ts.Mask = make([]bool, ts.Len())
+	ts.Mask[1500] = true
+	periodogram, err = ts.ChiSquarePeriodogram(20*time.Hour, 28*time.Hour, 12*time.Minute, 0.05)
+	if err != nil || periodogram.DominantPeriod() != tau || len(periodogram.Periods) != 41 {
+		t.Error("Expected: ", tau, "Received: ", periodogram.DominantPeriod(), err)
+	}
+
+	// Without rhythm there are no significant peaks
+	for index := range data {
+		data[index] = 50.0 + 10.0*noise(index)
+	}
+	periodogram, _ = ChiSquarePeriodogram(20*time.Hour, 28*time.Hour, 0, 0.001, dateTime, data)
+	if periodogram.DominantPeriod() != 0 {
+		t.Error("Expected: no significant peak, Received: ", periodogram.Peaks)
+	}
+
+	// Invalid parameters
+	if _, err = ChiSquarePeriodogram(20*time.Hour, 28*time.Hour, 0, 1.5, dateTime, data); !errors.Is(err, ErrInvalidAlpha) {
+		t.Error("Expected: ErrInvalidAlpha, Received: ", err)
+	}
+	if _, err = ChiSquarePeriodogram(28*time.Hour, 20*time.Hour, 0, 0.01, dateTime, data); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = ChiSquarePeriodogram(20*time.Hour, 28*time.Hour, 0, 0.01, dateTime[:300], data[:300]); !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+}
+
+// Creates 5 days of irregular samples (from 5 to 40 minutes) of a cosine with the period passed by parameter
+func irregularData(period time.Duration, noiseLevel float64) (dateTime []time.Time, data []float64) {
+	utc, _ := time.LoadLocation("UTC")
+	current := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+	end := current.Add(5 * 24 * time.Hour)
+
+	for index := 0; current.Before(end); index++ {
+		cycles := float64(current.Sub(end.Add(-5*24*time.Hour))) / float64(period)
+		dateTime = append(dateTime, current)
+		data = append(data, 37.0+0.5*math.Cos(2.0*math.Pi*cycles)+noiseLevel*noise(index))
+		current = current.Add(time.Duration(22.5+17.5*noise(index+3)) * time.Minute)
+	}
+	return
+}
+
+func TestLombScarglePeriodogram(t *testing.T) {
+
+	// Evenly sampled cosine with an integer number of cycles: the normalized power is (N - 1) / 2
+	utc, _ := time.LoadLocation("UTC")
+	var dateTime []time.Time
+	var data []float64
+	for index := 0; index < 96; index++ {
+		dateTime = append(dateTime, time.Date(2015, 1, 1, index, 0, 0, 0, utc))
+		data = append(data, 10.0+math.Cos(2.0*math.Pi*float64(index)/24.0))
+	}
+	periodogram, err := LombScarglePeriodogram(24*time.Hour, 24*time.Hour, 0, 0.01, dateTime, data)
+	if err != nil || len(periodogram.Power) != 1 || math.Abs(periodogram.Power[0]-47.5) > 1e-9 {
+		t.Error("Expected: 47.5, Received: ", periodogram.Power, err)
+	}
+
+	// Unevenly sampled data
+	period := 24*time.Hour + 40*time.Minute
+	dateTime, data = irregularData(period, 0.3)
+	periodogram, err = LombScarglePeriodogram(16*time.Hour, 32*time.Hour, time.Minute, 0.01, dateTime, data)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if len(periodogram.Periods) != 961 || len(periodogram.FalseAlarm) != 961 {
+		t.Error("Expected: 961 periods, Received: ", len(periodogram.Periods))
+	}
+	dominant := periodogram.DominantPeriod()
+	if dominant < period-20*time.Minute || dominant > period+20*time.Minute {
+		t.Error("Expected: ", period, "Received: ", dominant)
+	}
+	peak := periodogram.Peaks[0]
+	if peak.FalseAlarm > 1e-10 || peak.Power <= peak.Threshold {
+		t.Error("Unexpected peaks: ", periodogram.Peaks)
+	}
+
+	// Default frequency grid (the masked records and the NaN values are not used)
+	ts, _ := NewTimeSeries(dateTime, data)
+	ts.Data[10] = math.NaN()
+	ts.Mask = make([]bool, ts.Len())
+	ts.Mask[20] = true
+	ts.Data[20] = 1000
+	periodogram, err = ts.LombScarglePeriodogram(16*time.Hour, 32*time.Hour, 0, 0.01)
+	if err != nil || periodogram.Periods[0] != 16*time.Hour || periodogram.Periods[len(periodogram.Periods)-1] != 32*time.Hour {
+		t.Error("Unexpected periods: ", periodogram.Periods, err)
+	}
+	dominant = periodogram.DominantPeriod()
+	if dominant < period-time.Hour || dominant > period+time.Hour {
+		t.Error("Expected: ", period, "Received: ", dominant)
+	}
+
+	// Without rhythm there are no significant peaks
+	for index := range data {
+		data[index] = 37.0 + noise(index)
+	}
+	periodogram, _ = LombScarglePeriodogram(16*time.Hour, 32*time.Hour, 0, 0.01, dateTime, data)
+	if periodogram.DominantPeriod() != 0 {
+		t.Error("Expected: no significant peak, Received: ", periodogram.Peaks)
+	}
+
+	// Invalid parameters
+	if _, err = LombScarglePeriodogram(16*time.Hour, 32*time.Hour, 0, 0, dateTime, data); !errors.Is(err, ErrInvalidAlpha) {
+		t.Error("Expected: ErrInvalidAlpha, Received: ", err)
+	}
+	if _, err = LombScarglePeriodogram(0, 32*time.Hour, 0, 0.01, dateTime, data); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = LombScarglePeriodogram(16*time.Hour, 32*time.Hour, 0, 0.01, dateTime[:2], data[:2]); !errors.Is(err, ErrInsufficientData) {
+		t.Error("Expected: ErrInsufficientData, Received: ", err)
+	}
+}
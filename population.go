@@ -0,0 +1,276 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Number of points used to find the amplitude and acrophase confidence intervals from the confidence ellipse
+const ellipsePoints = 3600
+
+// ConfidenceEllipse describes the confidence region of the cosine (beta) and sine (gamma) coefficients of a population-mean cosinor:
+// the points (beta, gamma) where (x - center)' inverse(Covariance) (x - center) <= Radius
+type ConfidenceEllipse struct {
+	// Beta and Gamma are the coordinates of the center (mean coefficients)
+	Beta, Gamma float64
+	// Covariance is the covariance matrix of the mean coefficients
+	Covariance [2][2]float64
+	// Radius defines the size of the region based on the confidence level
+	Radius float64
+}
+
+// Contains reports whether the point (beta, gamma) is inside the confidence ellipse
+func (ellipse ConfidenceEllipse) Contains(beta float64, gamma float64) bool {
+	determinant := ellipse.Covariance[0][0]*ellipse.Covariance[1][1] - ellipse.Covariance[0][1]*ellipse.Covariance[1][0]
+	if determinant <= 0 {
+		return false
+	}
+	x, y := beta-ellipse.Beta, gamma-ellipse.Gamma
+	distance := (ellipse.Covariance[1][1]*x*x - 2.0*ellipse.Covariance[0][1]*x*y + ellipse.Covariance[0][0]*y*y) / determinant
+	return distance <= ellipse.Radius
+}
+
+// Points returns n points (beta and gamma coordinates) of the boundary of the confidence ellipse
+func (ellipse ConfidenceEllipse) Points(n int) (beta []float64, gamma []float64) {
+
+	// Cholesky decomposition of the covariance matrix
+	l11 := math.Sqrt(ellipse.Covariance[0][0])
+	if l11 == 0 {
+		return
+	}
+	l21 := ellipse.Covariance[1][0] / l11
+	l22 := math.Sqrt(math.Max(ellipse.Covariance[1][1]-l21*l21, 0))
+	radius := math.Sqrt(ellipse.Radius)
+
+	for index := 0; index < n; index++ {
+		angle := 2.0 * math.Pi * float64(index) / float64(n)
+		x, y := radius*math.Cos(angle), radius*math.Sin(angle)
+		beta = append(beta, ellipse.Beta+l11*x)
+		gamma = append(gamma, ellipse.Gamma+l21*x+l22*y)
+	}
+	return
+}
+
+// PopulationCosinorResult stores the parameters of a population-mean cosinor (Cornelissen, 2014),
+// which combines the single-component cosinor fits of the subjects
+type PopulationCosinorResult struct {
+	// Period is the period used by the fits
+	Period time.Duration
+	// MESOR is the mean MESOR of the subjects
+	MESOR float64
+	// Amplitude is the amplitude based on the mean coefficients of the subjects
+	Amplitude float64
+	// Acrophase is the acrophase based on the mean coefficients of the subjects in degrees, from 0 to -360
+	Acrophase float64
+	// AcrophaseTime is the time of the peak elapsed since midnight (the clock time when the period is 24 hours)
+	AcrophaseTime time.Duration
+	// MESORCI, AmplitudeCI and AcrophaseCI (degrees) are the 95% confidence intervals (lower and upper limits).
+	// The acrophase interval is NaN when the zero amplitude is inside the confidence ellipse
+	MESORCI, AmplitudeCI, AcrophaseCI [2]float64
+	// Ellipse is the 95% confidence ellipse of the mean coefficients
+	Ellipse ConfidenceEllipse
+	// F is the statistic of the zero-amplitude test and P its p-value
+	F, P float64
+	// Subjects stores the cosinor fit of each subject
+	Subjects []CosinorResult
+}
+
+// Returns the cosine (beta) and sine (gamma) coefficients of a single-component cosinor fit
+func (result CosinorResult) coefficients() (beta float64, gamma float64) {
+	acrophase := result.Acrophase * math.Pi / 180.0
+	return result.Amplitude * math.Cos(acrophase), -result.Amplitude * math.Sin(acrophase)
+}
+
+// PopulationCosinor fits a single-component cosinor to each subject (time series) and combines the fits into
+// the population-mean cosinor proposed by Cornelissen (2014). The period is DefaultPeriod when zero and at least 3 subjects are required
+func PopulationCosinor(period time.Duration, subjects []*TimeSeries) (result PopulationCosinorResult, err error) {
+
+	k := len(subjects)
+	if k < 3 {
+		err = newError("PopulationCosinor", ErrInsufficientData)
+		return
+	}
+
+	var mesor, beta, gamma []float64
+	for index, subject := range subjects {
+		if subject == nil {
+			err = indexError("PopulationCosinor", ErrEmpty, index)
+			return
+		}
+		fit, fitErr := subject.Cosinor(period)
+		if fitErr != nil {
+			err = indexError("PopulationCosinor", fitErr, index)
+			return
+		}
+		subjectBeta, subjectGamma := fit.coefficients()
+		mesor = append(mesor, fit.MESOR)
+		beta = append(beta, subjectBeta)
+		gamma = append(gamma, subjectGamma)
+		result.Subjects = append(result.Subjects, fit)
+	}
+	result.Period = result.Subjects[0].Period
+
+	n := float64(k)
+	meanBeta, meanGamma := average(beta), average(gamma)
+	result.MESOR = average(mesor)
+
+	// Sample variances and covariance of the coefficients
+	var varMesor, varBeta, varGamma, covariance float64
+	for index := 0; index < k; index++ {
+		varMesor += (mesor[index] - result.MESOR) * (mesor[index] - result.MESOR)
+		varBeta += (beta[index] - meanBeta) * (beta[index] - meanBeta)
+		varGamma += (gamma[index] - meanGamma) * (gamma[index] - meanGamma)
+		covariance += (beta[index] - meanBeta) * (gamma[index] - meanGamma)
+	}
+	varMesor /= n - 1.0
+	varBeta /= n - 1.0
+	varGamma /= n - 1.0
+	covariance /= n - 1.0
+
+	amplitude, acrophase := amplitudeAcrophase(meanBeta, meanGamma)
+	degrees := 180.0 / math.Pi
+	result.Amplitude = amplitude
+	result.Acrophase = acrophase * degrees
+	result.AcrophaseTime = acrophaseTime(result.Acrophase, result.Period)
+
+	quantile := studentQuantile(1.0-(1.0-confidenceLevel)/2.0, n-1.0)
+	mesorSE := math.Sqrt(varMesor / n)
+	result.MESORCI = [2]float64{result.MESOR - quantile*mesorSE, result.MESOR + quantile*mesorSE}
+
+	// Confidence ellipse of the mean coefficients
+	result.Ellipse = ConfidenceEllipse{
+		Beta:       meanBeta,
+		Gamma:      meanGamma,
+		Covariance: [2][2]float64{{varBeta / n, covariance / n}, {covariance / n, varGamma / n}},
+		Radius:     2.0 * (n - 1.0) / (n - 2.0) * fisherQuantile(confidenceLevel, 2.0, n-2.0),
+	}
+
+	// Zero-amplitude test (F with 2 and k - 2 degrees of freedom)
+	determinant := varBeta*varGamma - covariance*covariance
+	if determinant > 0 {
+		distance := n * (varGamma*meanBeta*meanBeta - 2.0*covariance*meanBeta*meanGamma + varBeta*meanGamma*meanGamma) / determinant
+		result.F = (n - 2.0) / (2.0 * (n - 1.0)) * distance
+		result.P = 1.0 - fisherCDF(result.F, 2.0, n-2.0)
+	} else {
+		result.F = math.Inf(1)
+	}
+
+	// The amplitude and acrophase intervals are the range of the points of the ellipse
+	pointsBeta, pointsGamma := result.Ellipse.Points(ellipsePoints)
+	if result.Ellipse.Contains(0, 0) {
+		result.AmplitudeCI = [2]float64{0, amplitude}
+		result.AcrophaseCI = [2]float64{math.NaN(), math.NaN()}
+	} else {
+		result.AmplitudeCI = [2]float64{amplitude, amplitude}
+		result.AcrophaseCI = [2]float64{result.Acrophase, result.Acrophase}
+	}
+	for index := range pointsBeta {
+		pointAmplitude, pointAcrophase := amplitudeAcrophase(pointsBeta[index], pointsGamma[index])
+		result.AmplitudeCI[0] = math.Min(result.AmplitudeCI[0], pointAmplitude)
+		result.AmplitudeCI[1] = math.Max(result.AmplitudeCI[1], pointAmplitude)
+
+		if !math.IsNaN(result.AcrophaseCI[0]) {
+			// Difference to the mean acrophase, from -180 to 180 degrees
+			difference := math.Remainder(pointAcrophase-acrophase, 2.0*math.Pi) * degrees
+			result.AcrophaseCI[0] = math.Min(result.AcrophaseCI[0], result.Acrophase+difference)
+			result.AcrophaseCI[1] = math.Max(result.AcrophaseCI[1], result.Acrophase+difference)
+		}
+	}
+
+	return
+}
+
+// GroupComparison stores the population-mean cosinor of each group and the parameter tests proposed by Bingham et al. (1982)
+type GroupComparison struct {
+	// Groups stores the population-mean cosinor of each group
+	Groups []PopulationCosinorResult
+	// MESORF is the statistic of the MESOR equality test (one-way analysis of variance) and MESORP its p-value
+	MESORF, MESORP float64
+	// RhythmF is the statistic of the amplitude and acrophase equality test (Wilks' lambda of the cosine and sine coefficients)
+	// and RhythmP its p-value
+	RhythmF, RhythmP float64
+}
+
+// CompareGroups compares the rhythms of two or more groups of subjects (time series) using the single-component cosinor
+// with the period passed by parameter (DefaultPeriod when zero). Each group must have at least 3 subjects
+func CompareGroups(period time.Duration, groups ...[]*TimeSeries) (comparison GroupComparison, err error) {
+
+	g := len(groups)
+	if g < 2 {
+		err = newError("CompareGroups", ErrInsufficientData)
+		return
+	}
+
+	total := 0
+	var grand [3]float64
+	for index, group := range groups {
+		population, populationErr := PopulationCosinor(period, group)
+		if populationErr != nil {
+			err = indexError("CompareGroups", populationErr, index)
+			return
+		}
+		comparison.Groups = append(comparison.Groups, population)
+
+		for _, subject := range population.Subjects {
+			beta, gamma := subject.coefficients()
+			grand[0] += subject.MESOR
+			grand[1] += beta
+			grand[2] += gamma
+			total++
+		}
+	}
+	for parameter := range grand {
+		grand[parameter] /= float64(total)
+	}
+
+	// Between-groups (B) and within-groups (W) sums of squares and cross products of MESOR, beta and gamma
+	var between, within [3][3]float64
+	for _, group := range comparison.Groups {
+		var mean [3]float64
+		for _, subject := range group.Subjects {
+			beta, gamma := subject.coefficients()
+			mean[0] += subject.MESOR / float64(len(group.Subjects))
+			mean[1] += beta / float64(len(group.Subjects))
+			mean[2] += gamma / float64(len(group.Subjects))
+		}
+		for row := 0; row < 3; row++ {
+			for column := 0; column < 3; column++ {
+				between[row][column] += float64(len(group.Subjects)) * (mean[row] - grand[row]) * (mean[column] - grand[column])
+			}
+		}
+		for _, subject := range group.Subjects {
+			beta, gamma := subject.coefficients()
+			values := [3]float64{subject.MESOR, beta, gamma}
+			for row := 0; row < 3; row++ {
+				for column := 0; column < 3; column++ {
+					within[row][column] += (values[row] - mean[row]) * (values[column] - mean[column])
+				}
+			}
+		}
+	}
+
+	n := float64(total)
+	groupsCount := float64(g)
+
+	// MESOR (g - 1 and n - g degrees of freedom)
+	if within[0][0] > 0 {
+		comparison.MESORF = (between[0][0] / (groupsCount - 1.0)) / (within[0][0] / (n - groupsCount))
+		comparison.MESORP = 1.0 - fisherCDF(comparison.MESORF, groupsCount-1.0, n-groupsCount)
+	} else {
+		comparison.MESORF = math.Inf(1)
+	}
+
+	// Amplitude and acrophase: exact F transformation of the Wilks' lambda for two variables,
+	// with 2(g - 1) and 2(n - g - 1) degrees of freedom
+	determinantWithin := within[1][1]*within[2][2] - within[1][2]*within[2][1]
+	determinantTotal := (within[1][1]+between[1][1])*(within[2][2]+between[2][2]) - (within[1][2]+between[1][2])*(within[2][1]+between[2][1])
+	if determinantWithin > 0 && determinantTotal > 0 {
+		lambda := math.Sqrt(determinantWithin / determinantTotal)
+		comparison.RhythmF = (1.0 - lambda) / lambda * (n - groupsCount - 1.0) / (groupsCount - 1.0)
+		comparison.RhythmP = 1.0 - fisherCDF(comparison.RhythmF, 2.0*(groupsCount-1.0), 2.0*(n-groupsCount-1.0))
+	} else {
+		comparison.RhythmF = math.Inf(1)
+	}
+
+	return
+}
@@ -0,0 +1,126 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Creates a subject (2 days, 30 minutes epoch) with the cosine parameters passed by parameter
+func subjectSeries(seed int, mesor float64, amplitude float64, peak float64) *TimeSeries {
+	utc, _ := time.LoadLocation("UTC")
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	var dateTime []time.Time
+	var data []float64
+	for index := 0; index < 2*48; index++ {
+		current := start.Add(time.Duration(index) * 30 * time.Minute)
+		hours := current.Sub(start).Hours()
+		dateTime = append(dateTime, current)
+		data = append(data, mesor+amplitude*math.Cos(2.0*math.Pi*(hours-peak)/24.0)+5.0*noise(seed*1000+index))
+	}
+
+	ts, _ := NewTimeSeries(dateTime, data)
+	return ts
+}
+
+// Creates a group of subjects with peaks around the time passed by parameter
+func subjectGroup(seed int, subjects int, amplitude float64, peak float64) (group []*TimeSeries) {
+	for index := 0; index < subjects; index++ {
+		group = append(group, subjectSeries(seed+index, 100.0+10.0*noise(seed+index), amplitude+5.0*noise(seed+index+50), peak+noise(seed+index+100)))
+	}
+	return
+}
+
+func TestPopulationCosinor(t *testing.T) {
+
+	group := subjectGroup(1, 12, 30, 15)
+
+	result, err := PopulationCosinor(0, group)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if len(result.Subjects) != 12 || result.Period != DefaultPeriod || result.P > 0.001 {
+		t.Error("Unexpected result: ", len(result.Subjects), result.Period, result.P)
+	}
+
+	// Table tests
+	var tTests = []struct {
+		name     string
+		value    float64
+		ci       [2]float64
+		expected float64
+	}{
+		{"MESOR", result.MESOR, result.MESORCI, 100},
+		{"Amplitude", result.Amplitude, result.AmplitudeCI, 30},
+		{"Acrophase", result.Acrophase, result.AcrophaseCI, -225},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if table.ci[0] > table.value || table.ci[1] < table.value || table.ci[0] > table.expected || table.ci[1] < table.expected {
+			t.Error(
+				"Parameter: ", table.name,
+				"Expected: ", table.expected,
+				"Received: ", table.value, table.ci,
+			)
+		}
+	}
+
+	// The boundary points are on the ellipse
+	beta, gamma := result.Ellipse.Points(8)
+	if len(beta) != 8 || !result.Ellipse.Contains(result.Ellipse.Beta, result.Ellipse.Gamma) {
+		t.Error("Unexpected ellipse: ", result.Ellipse)
+	}
+	for index := range beta {
+		inside := result.Ellipse
+		inside.Radius *= 1.000001
+		outside := result.Ellipse
+		outside.Radius *= 0.999999
+		if !inside.Contains(beta[index], gamma[index]) || outside.Contains(beta[index], gamma[index]) {
+			t.Error("Expected a point on the ellipse: ", beta[index], gamma[index])
+		}
+	}
+
+	// Without rhythm the zero amplitude is inside the ellipse
+	result, err = PopulationCosinor(0, subjectGroup(20, 6, 0, 15))
+	if err != nil || result.P < 0.05 || result.AmplitudeCI[0] != 0 || !math.IsNaN(result.AcrophaseCI[0]) {
+		t.Error("Expected a non-significant rhythm. Received: ", result.P, result.AmplitudeCI, result.AcrophaseCI, err)
+	}
+
+	if _, err = PopulationCosinor(0, group[:2]); !errors.Is(err, ErrInsufficientData) {
+		t.Error("Expected: ErrInsufficientData, Received: ", err)
+	}
+
+	var chronoErr *Error
+	_, err = PopulationCosinor(0, append(group[:3:3], &TimeSeries{}))
+	if !errors.As(err, &chronoErr) || chronoErr.Index != 3 || !errors.Is(err, ErrEmpty) {
+		t.Error("Expected: ErrEmpty on subject 3, Received: ", err)
+	}
+}
+
+func TestCompareGroups(t *testing.T) {
+
+	morning := subjectGroup(1, 10, 30, 15)
+	evening := subjectGroup(30, 10, 30, 19)
+	similar := subjectGroup(60, 10, 30, 15)
+
+	comparison, err := CompareGroups(0, morning, evening)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if len(comparison.Groups) != 2 || comparison.RhythmP > 0.001 || comparison.MESORP < 0.05 {
+		t.Error("Expected different rhythms. Received: ", comparison.RhythmP, comparison.MESORP)
+	}
+
+	comparison, err = CompareGroups(0, morning, similar, subjectGroup(90, 10, 30, 15))
+	if err != nil || len(comparison.Groups) != 3 || comparison.RhythmP < 0.05 {
+		t.Error("Expected similar rhythms. Received: ", comparison.RhythmP, err)
+	}
+
+	if _, err = CompareGroups(0, morning); !errors.Is(err, ErrInsufficientData) {
+		t.Error("Expected: ErrInsufficientData, Received: ", err)
+	}
+}
@@ -0,0 +1,540 @@
+package chronobiology
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Metric represents a metric used to aggregate the raw acceleration into epochs
+type Metric int
+
+// Metrics used to aggregate the raw acceleration into epochs
+const (
+	// ENMO is the Euclidean Norm Minus One (negative values rounded to zero), in mg
+	ENMO Metric = iota
+	// MAD is the Mean Amplitude Deviation of the vector magnitude, in mg
+	MAD
+	// HFEN is the Euclidean Norm of the High-pass Filtered signals (0.2 Hz), in mg
+	HFEN
+	// ZCM is the Zero Crossing Mode: number of zero crossings of the band-pass filtered (0.25-3 Hz) vector magnitude
+	ZCM
+	// PIM is the Proportional Integration Mode: area under the rectified band-pass filtered (0.25-3 Hz) vector magnitude, in mg.s
+	PIM
+	// AngleZ is the angle of the z axis relative to the horizontal plane (van Hees et al., 2015), in degrees,
+	// calculated after a rolling median (5 seconds) of each axis
+	AngleZ
+)
+
+// String returns the metric name
+func (metric Metric) String() string {
+	switch metric {
+	case ENMO:
+		return "ENMO"
+	case MAD:
+		return "MAD"
+	case HFEN:
+		return "HFEN"
+	case ZCM:
+		return "ZCM"
+	case PIM:
+		return "PIM"
+	case AngleZ:
+		return "AngleZ"
+	}
+	return "Unknown"
+}
+
+// Parameters used by the auto-calibration (van Hees et al., 2014)
+const (
+	calibrationWindow     = 10    // Window length (seconds)
+	calibrationSD         = 0.013 // Maximum standard deviation of a non-movement window (g)
+	calibrationSphere     = 0.3   // Each axis must have values above and below this limit (g)
+	calibrationIterations = 1000  // Maximum number of iterations
+	calibrationTolerance  = 1e-10 // Minimum improvement of the error between two iterations
+	zeroCrossingDeadBand  = 0.01  // Dead band used to count the zero crossings (g)
+	highPassHFEN          = 0.2   // Cut-off frequency of the HFEN high-pass filter (Hz)
+	bandPassLow           = 0.25  // Low cut-off frequency of the ZCM and PIM band-pass filter (Hz)
+	bandPassHigh          = 3.0   // High cut-off frequency of the ZCM and PIM band-pass filter (Hz)
+	angleZWindow          = 5.0   // Window of the rolling median of each axis used by the z-angle (seconds)
+)
+
+// RawAcceleration stores raw tri-axial acceleration samples (in g)
+type RawAcceleration struct {
+	// DateTime stores the timestamp of each sample
+	DateTime []time.Time
+	// X, Y and Z store the acceleration of each axis (g)
+	X, Y, Z []float64
+	// SampleRate is the number of samples per second (Hz)
+	SampleRate float64
+}
+
+// Calibration stores the offset and scale of each axis found by the auto-calibration,
+// so the calibrated acceleration is (raw + offset) * scale
+type Calibration struct {
+	// Offset of each axis (g)
+	Offset [3]float64
+	// Scale of each axis
+	Scale [3]float64
+	// ErrorBefore is the mean distance between the non-movement points and the unit sphere before the calibration (g)
+	ErrorBefore float64
+	// ErrorAfter is the mean distance between the non-movement points and the unit sphere after the calibration (g)
+	ErrorAfter float64
+	// Points is the number of non-movement windows used by the calibration
+	Points int
+}
+
+// NewRawAcceleration creates a raw acceleration from the timestamps, axes (g) and sample rate (Hz) passed by parameter
+func NewRawAcceleration(dateTime []time.Time, x []float64, y []float64, z []float64, sampleRate float64) (raw *RawAcceleration, err error) {
+	if len(dateTime) == 0 || len(x) == 0 || len(y) == 0 || len(z) == 0 {
+		err = newError("NewRawAcceleration", ErrEmpty)
+		return
+	}
+	if len(dateTime) != len(x) || len(dateTime) != len(y) || len(dateTime) != len(z) {
+		err = newError("NewRawAcceleration", ErrDifferentSize)
+		return
+	}
+	if sampleRate <= 0 {
+		err = newError("NewRawAcceleration", ErrInvalidSampleRate)
+		return
+	}
+	for index := 1; index < len(dateTime); index++ {
+		if dateTime[index].Before(dateTime[index-1]) {
+			err = indexError("NewRawAcceleration", ErrUnsorted, index)
+			return
+		}
+	}
+
+	raw = &RawAcceleration{DateTime: dateTime, X: x, Y: y, Z: z, SampleRate: sampleRate}
+	return
+}
+
+// Len returns the number of samples
+func (raw *RawAcceleration) Len() int {
+	return len(raw.DateTime)
+}
+
+// Calculates the vector magnitude of the sample passed by parameter
+func (raw *RawAcceleration) magnitude(index int) float64 {
+	return math.Sqrt(raw.X[index]*raw.X[index] + raw.Y[index]*raw.Y[index] + raw.Z[index]*raw.Z[index])
+}
+
+// Calculates the mean and the standard deviation of a slice
+func meanSD(data []float64) (mean float64, sd float64) {
+	mean = average(data)
+	if len(data) < 2 {
+		return
+	}
+	for _, value := range data {
+		sd += (value - mean) * (value - mean)
+	}
+	sd = math.Sqrt(sd / float64(len(data)-1))
+	return
+}
+
+// Calibrate finds the offset and scale of each axis using the auto-calibration method proposed by van Hees et al. (2014):
+// the means of the non-movement windows (10 seconds with SD below 13 mg in all axes) should lie on the unit sphere (1 g).
+// It returns ErrInsufficientCalibration when the non-movement windows do not cover the sphere (values above 0.3 g and below -0.3 g in each axis)
+func (raw *RawAcceleration) Calibrate() (calibration Calibration, err error) {
+
+	window := int(raw.SampleRate * calibrationWindow)
+	if window < 2 {
+		err = newError("Calibrate", ErrInvalidSampleRate)
+		return
+	}
+
+	// Means of the non-movement windows
+	var points [][3]float64
+	for start := 0; start+window <= raw.Len(); start += window {
+		var point [3]float64
+		still := true
+		for axis, values := range [][]float64{raw.X, raw.Y, raw.Z} {
+			mean, sd := meanSD(values[start : start+window])
+			if sd >= calibrationSD || math.Abs(mean) >= 2.0 {
+				still = false
+				break
+			}
+			point[axis] = mean
+		}
+		if still {
+			points = append(points, point)
+		}
+	}
+
+	// The points must cover the sphere
+	for axis := 0; axis < 3; axis++ {
+		above, below := false, false
+		for _, point := range points {
+			above = above || point[axis] > calibrationSphere
+			below = below || point[axis] < -calibrationSphere
+		}
+		if !above || !below {
+			err = newError("Calibrate", ErrInsufficientCalibration)
+			return
+		}
+	}
+
+	calibration.Points = len(points)
+	calibration.Scale = [3]float64{1, 1, 1}
+
+	// Mean distance between the calibrated points and the unit sphere
+	sphereError := func() float64 {
+		var total float64
+		for _, point := range points {
+			var norm float64
+			for axis := 0; axis < 3; axis++ {
+				value := (point[axis] + calibration.Offset[axis]) * calibration.Scale[axis]
+				norm += value * value
+			}
+			total += math.Abs(math.Sqrt(norm) - 1.0)
+		}
+		return total / float64(len(points))
+	}
+
+	calibration.ErrorBefore = sphereError()
+	previousError := calibration.ErrorBefore
+
+	for iteration := 0; iteration < calibrationIterations; iteration++ {
+
+		current := make([][3]float64, len(points))
+		closest := make([][3]float64, len(points))
+		weights := make([]float64, len(points))
+
+		for index, point := range points {
+			var norm float64
+			for axis := 0; axis < 3; axis++ {
+				current[index][axis] = (point[axis] + calibration.Offset[axis]) * calibration.Scale[axis]
+				norm += current[index][axis] * current[index][axis]
+			}
+			norm = math.Sqrt(norm)
+
+			var distance float64
+			for axis := 0; axis < 3; axis++ {
+				closest[index][axis] = current[index][axis] / norm
+				distance += (closest[index][axis] - current[index][axis]) * (closest[index][axis] - current[index][axis])
+			}
+
+			// Points closer to the sphere have higher weights (limited to 100)
+			weights[index] = 100.0
+			if distance = math.Sqrt(distance); distance > 0.01 {
+				weights[index] = 1.0 / distance
+			}
+		}
+
+		// Weighted linear regression (closest = intercept + slope * current) of each axis
+		for axis := 0; axis < 3; axis++ {
+			var sumW, sumX, sumY, sumXX, sumXY float64
+			for index := range points {
+				w := weights[index]
+				x := current[index][axis]
+				y := closest[index][axis]
+				sumW += w
+				sumX += w * x
+				sumY += w * y
+				sumXX += w * x * x
+				sumXY += w * x * y
+			}
+			denominator := sumW*sumXX - sumX*sumX
+			if denominator == 0 {
+				continue
+			}
+			slope := (sumW*sumXY - sumX*sumY) / denominator
+			intercept := (sumY - slope*sumX) / sumW
+
+			calibration.Offset[axis] += intercept / (calibration.Scale[axis] * slope)
+			calibration.Scale[axis] *= slope
+		}
+
+		currentError := sphereError()
+		if math.Abs(previousError-currentError) < calibrationTolerance {
+			break
+		}
+		previousError = currentError
+	}
+
+	calibration.ErrorAfter = sphereError()
+
+	return
+}
+
+// Apply returns a new raw acceleration with the calibration passed by parameter applied to each axis
+func (raw *RawAcceleration) Apply(calibration Calibration) *RawAcceleration {
+	axes := [3][]float64{raw.X, raw.Y, raw.Z}
+	var calibrated [3][]float64
+
+	for axis := 0; axis < 3; axis++ {
+		calibrated[axis] = make([]float64, len(axes[axis]))
+		for index, value := range axes[axis] {
+			calibrated[axis][index] = (value + calibration.Offset[axis]) * calibration.Scale[axis]
+		}
+	}
+
+	return &RawAcceleration{
+		DateTime:   raw.DateTime,
+		X:          calibrated[0],
+		Y:          calibrated[1],
+		Z:          calibrated[2],
+		SampleRate: raw.SampleRate,
+	}
+}
+
+// Biquad filter (second-order section) coefficients
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+}
+
+// Creates a second-order Butterworth-like section (highPass defines the filter type) using the bilinear transform
+func newBiquad(cutOff float64, sampleRate float64, q float64, highPass bool) biquad {
+	w0 := 2.0 * math.Pi * cutOff / sampleRate
+	alpha := math.Sin(w0) / (2.0 * q)
+	cos := math.Cos(w0)
+	a0 := 1.0 + alpha
+
+	if highPass {
+		return biquad{
+			b0: (1.0 + cos) / 2.0 / a0,
+			b1: -(1.0 + cos) / a0,
+			b2: (1.0 + cos) / 2.0 / a0,
+			a1: -2.0 * cos / a0,
+			a2: (1.0 - alpha) / a0,
+		}
+	}
+	return biquad{
+		b0: (1.0 - cos) / 2.0 / a0,
+		b1: (1.0 - cos) / a0,
+		b2: (1.0 - cos) / 2.0 / a0,
+		a1: -2.0 * cos / a0,
+		a2: (1.0 - alpha) / a0,
+	}
+}
+
+// Applies the cascade of sections to the signal. The first value is subtracted from the signal
+// to reduce the transient at the beginning (the sections must be high-pass or band-pass)
+func filterSignal(signal []float64, sections []biquad) []float64 {
+	output := make([]float64, len(signal))
+	if len(signal) == 0 {
+		return output
+	}
+	for index, value := range signal {
+		output[index] = value - signal[0]
+	}
+
+	for _, section := range sections {
+		var x1, x2, y1, y2 float64
+		for index, x := range output {
+			y := section.b0*x + section.b1*x1 + section.b2*x2 - section.a1*y1 - section.a2*y2
+			x2, x1 = x1, x
+			y2, y1 = y1, y
+			output[index] = y
+		}
+	}
+	return output
+}
+
+// Applies a centered rolling median (window of samples) to the signal. The windows are shorter at the ends of the signal,
+// the NaN values are not used and the result is NaN when the window does not have any other value.
+// The window is kept sorted, so each sample only inserts and removes one value
+func rollingMedian(signal []float64, samples int) []float64 {
+	half := samples / 2
+	output := make([]float64, len(signal))
+	window := make([]float64, 0, 2*half+1)
+
+	insert := func(value float64) {
+		if math.IsNaN(value) {
+			return
+		}
+		position := sort.SearchFloat64s(window, value)
+		window = append(window, 0.0)
+		copy(window[position+1:], window[position:])
+		window[position] = value
+	}
+	remove := func(value float64) {
+		if math.IsNaN(value) {
+			return
+		}
+		position := sort.SearchFloat64s(window, value)
+		copy(window[position:], window[position+1:])
+		window = window[:len(window)-1]
+	}
+
+	for index := 0; index < half && index < len(signal); index++ {
+		insert(signal[index])
+	}
+	for index := range signal {
+		if index+half < len(signal) {
+			insert(signal[index+half])
+		}
+		if index-half-1 >= 0 {
+			remove(signal[index-half-1])
+		}
+		if len(window) == 0 {
+			output[index] = math.NaN()
+		} else if len(window)%2 == 1 {
+			output[index] = window[len(window)/2]
+		} else {
+			output[index] = (window[len(window)/2-1] + window[len(window)/2]) / 2.0
+		}
+	}
+	return output
+}
+
+// Calculates the signal (per sample) used by the metric passed by parameter
+func (raw *RawAcceleration) metricSignal(metric Metric) (signal []float64, err error) {
+
+	signal = make([]float64, raw.Len())
+
+	switch metric {
+	case ENMO:
+		for index := range signal {
+			signal[index] = math.Max(raw.magnitude(index)-1.0, 0.0)
+		}
+	case MAD:
+		for index := range signal {
+			signal[index] = raw.magnitude(index)
+		}
+	case HFEN:
+		// 4th order Butterworth high-pass filter (two sections)
+		sections := []biquad{
+			newBiquad(highPassHFEN, raw.SampleRate, 0.54119610, true),
+			newBiquad(highPassHFEN, raw.SampleRate, 1.30656296, true),
+		}
+		x := filterSignal(raw.X, sections)
+		y := filterSignal(raw.Y, sections)
+		z := filterSignal(raw.Z, sections)
+		for index := range signal {
+			signal[index] = math.Sqrt(x[index]*x[index] + y[index]*y[index] + z[index]*z[index])
+		}
+	case ZCM, PIM:
+		if raw.SampleRate <= 2*bandPassHigh {
+			err = newError("Aggregate", ErrInvalidSampleRate)
+			return
+		}
+		for index := range signal {
+			signal[index] = raw.magnitude(index)
+		}
+		signal = filterSignal(signal, []biquad{
+			newBiquad(bandPassLow, raw.SampleRate, math.Sqrt2/2.0, true),
+			newBiquad(bandPassHigh, raw.SampleRate, math.Sqrt2/2.0, false),
+		})
+	case AngleZ:
+		// The rolling median of each axis removes the short movements
+		records := int(angleZWindow * raw.SampleRate)
+		x := rollingMedian(raw.X, records)
+		y := rollingMedian(raw.Y, records)
+		z := rollingMedian(raw.Z, records)
+		for index := range signal {
+			signal[index] = math.Atan2(z[index], math.Hypot(x[index], y[index])) * 180.0 / math.Pi
+		}
+	default:
+		err = newError("Aggregate", ErrInvalidMetric)
+	}
+
+	return
+}
+
+// Aggregates the samples of an epoch based on the metric passed by parameter
+func aggregateEpoch(metric Metric, signal []float64, sampleRate float64) float64 {
+	switch metric {
+	case ENMO, HFEN:
+		return average(signal) * 1000.0
+	case AngleZ:
+		return average(signal)
+	case MAD:
+		mean := average(signal)
+		var total float64
+		for _, value := range signal {
+			total += math.Abs(value - mean)
+		}
+		return total / float64(len(signal)) * 1000.0
+	case ZCM:
+		// A crossing is counted when the signal goes from one side of the dead band to the other
+		var crossings float64
+		side := 0
+		for _, value := range signal {
+			current := 0
+			if value > zeroCrossingDeadBand {
+				current = 1
+			} else if value < -zeroCrossingDeadBand {
+				current = -1
+			}
+			if current != 0 {
+				if side != 0 && current != side {
+					crossings++
+				}
+				side = current
+			}
+		}
+		return crossings
+	case PIM:
+		var total float64
+		for _, value := range signal {
+			total += math.Abs(value)
+		}
+		return total / sampleRate * 1000.0
+	}
+	return 0.0
+}
+
+// Splits the samples into epochs (seconds) based on the timestamps, returning the start time of each epoch
+// and the position of its first sample (the last position is the number of samples)
+func (raw *RawAcceleration) epochs(epoch int) (dateTime []time.Time, bounds []int) {
+
+	start := raw.DateTime[0]
+	epochDuration := time.Duration(epoch) * time.Second
+
+	first := 0
+	for first < raw.Len() {
+		number := int64(raw.DateTime[first].Sub(start) / epochDuration)
+		epochStart := start.Add(time.Duration(number) * epochDuration)
+		epochEnd := epochStart.Add(epochDuration)
+
+		last := first
+		for last < raw.Len() && raw.DateTime[last].Before(epochEnd) {
+			last++
+		}
+
+		dateTime = append(dateTime, epochStart)
+		bounds = append(bounds, first)
+		first = last
+	}
+	bounds = append(bounds, raw.Len())
+
+	return
+}
+
+// Aggregate aggregates the raw acceleration into epochs (seconds) using the metric passed by parameter,
+// returning a time series that can be used by the analysis functions (e.g. M10, L5, IV and IS).
+// The epochs are defined by the timestamps (starting at the first sample), so the sample rate drift does not
+// accumulate, and each epoch is labelled by its start time. Epochs without samples are not returned
+func (raw *RawAcceleration) Aggregate(metric Metric, epoch int) (ts *TimeSeries, err error) {
+
+	if raw.Len() == 0 {
+		err = newError("Aggregate", ErrEmpty)
+		return
+	}
+	if epoch <= 0 {
+		err = newError("Aggregate", ErrInvalidEpoch)
+		return
+	}
+
+	signal, err := raw.metricSignal(metric)
+	if err != nil {
+		return
+	}
+
+	dateTime, bounds := raw.epochs(epoch)
+
+	data := make([]float64, len(dateTime))
+	for index := range dateTime {
+		data[index] = aggregateEpoch(metric, signal[bounds[index]:bounds[index+1]], raw.SampleRate)
+	}
+
+	ts = &TimeSeries{
+		DateTime: dateTime,
+		Data:     data,
+		Epoch:    epoch,
+		Location: raw.DateTime[0].Location(),
+		Metadata: map[string]string{"metric": metric.String()},
+	}
+	return
+}
@@ -0,0 +1,238 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+// Creates a raw acceleration with the sample rate and signal passed by parameter
+func rawSignal(sampleRate float64, samples int, signal func(second float64) (x, y, z float64)) *RawAcceleration {
+	utc, _ := time.LoadLocation("UTC")
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	var dateTime []time.Time
+	var x, y, z []float64
+
+	for index := 0; index < samples; index++ {
+		second := float64(index) / sampleRate
+		dateTime = append(dateTime, start.Add(time.Duration(second*float64(time.Second))))
+		valueX, valueY, valueZ := signal(second)
+		x = append(x, valueX)
+		y = append(y, valueY)
+		z = append(z, valueZ)
+	}
+
+	raw, _ := NewRawAcceleration(dateTime, x, y, z, sampleRate)
+	return raw
+}
+
+func TestNewRawAcceleration(t *testing.T) {
+
+	utc, _ := time.LoadLocation("UTC")
+	dateTime := []time.Time{time.Date(2015, 1, 1, 0, 0, 0, 0, utc), time.Date(2015, 1, 1, 0, 0, 1, 0, utc)}
+	values := []float64{0.0, 1.0}
+
+	// Table tests
+	var tTests = []struct {
+		dateTime   []time.Time
+		x          []float64
+		sampleRate float64
+		err        error
+	}{
+		{nil, nil, 30, ErrEmpty},
+		{dateTime, values[:1], 30, ErrDifferentSize},
+		{dateTime, values, 0, ErrInvalidSampleRate},
+		{[]time.Time{dateTime[1], dateTime[0]}, values, 30, ErrUnsorted},
+		{dateTime, values, 30, nil},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		_, err := NewRawAcceleration(table.dateTime, table.x, values, values, table.sampleRate)
+		if table.err == nil && err != nil || table.err != nil && !errors.Is(err, table.err) {
+			t.Error(
+				"Expected: ", table.err,
+				"Received: ", err,
+			)
+		}
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+
+	// Orientations of the device (unit vectors) during the non-movement periods
+	orientations := [][3]float64{
+		{1, 0, 0}, {-1, 0, 0}, {0, 1, 0}, {0, -1, 0}, {0, 0, 1}, {0, 0, -1},
+		{0.577, 0.577, 0.577}, {-0.577, 0.577, -0.577}, {0.707, -0.707, 0}, {0, 0.707, -0.707},
+	}
+
+	offset := [3]float64{0.05, -0.03, 0.02}
+	scale := [3]float64{1.02, 0.98, 1.01}
+
+	// Each orientation lasts 20 seconds (10 Hz), with a small noise
+	sampleRate := 10.0
+	raw := rawSignal(sampleRate, len(orientations)*200, func(second float64) (x, y, z float64) {
+		orientation := orientations[int(second)/20]
+		noise := 0.002 * math.Sin(second*7.0)
+		x = (orientation[0]+noise)/scale[0] - offset[0]
+		y = (orientation[1]+noise)/scale[1] - offset[1]
+		z = (orientation[2]+noise)/scale[2] - offset[2]
+		return
+	})
+
+	calibration, err := raw.Calibrate()
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if calibration.Points != 20 {
+		t.Error("Expected: 20 points, Received: ", calibration.Points)
+	}
+	for axis := 0; axis < 3; axis++ {
+		if math.Abs(calibration.Offset[axis]-offset[axis]) > 0.005 || math.Abs(calibration.Scale[axis]-scale[axis]) > 0.005 {
+			t.Error(
+				"Axis: ", axis,
+				"Expected: ", offset[axis], scale[axis],
+				"Received: ", calibration.Offset[axis], calibration.Scale[axis],
+			)
+		}
+	}
+	if calibration.ErrorAfter >= calibration.ErrorBefore || calibration.ErrorAfter > 0.005 {
+		t.Error("Expected a smaller error after the calibration. Received: ", calibration.ErrorBefore, calibration.ErrorAfter)
+	}
+
+	calibrated := raw.Apply(calibration)
+	if math.Abs(calibrated.magnitude(0)-1.0) > 0.005 {
+		t.Error("Expected: 1 g, Received: ", calibrated.magnitude(0))
+	}
+
+	// The device was always in the same position
+	still := rawSignal(sampleRate, 600, func(second float64) (x, y, z float64) {
+		return 0.0, 0.0, 1.0
+	})
+
+	_, err = still.Calibrate()
+	if !errors.Is(err, ErrInsufficientCalibration) {
+		t.Error("Expected: ErrInsufficientCalibration, Received: ", err)
+	}
+}
+
+func TestAggregate(t *testing.T) {
+
+	sampleRate := 30.0
+
+	// Oscillation of 0.5 g (1 Hz) on the z axis
+	raw := rawSignal(sampleRate, 180*30, func(second float64) (x, y, z float64) {
+		return 0.0, 0.0, 1.0 + 0.5*math.Sin(2.0*math.Pi*second)
+	})
+
+	// Expected values (the first epoch is affected by the filter transient)
+	meanAbs := 0.5 * 2.0 / math.Pi
+
+	// Table tests
+	var tTests = []struct {
+		metric    Metric
+		expected  float64
+		tolerance float64
+	}{
+		{ENMO, 0.5 / math.Pi * 1000.0, 1.0},
+		{MAD, meanAbs * 1000.0, 2.0},
+		{HFEN, meanAbs * 1000.0, 0.05 * meanAbs * 1000.0},
+		{ZCM, 120.0, 2.0},
+		{PIM, meanAbs * 60.0 * 1000.0, 0.05 * meanAbs * 60.0 * 1000.0},
+		{AngleZ, 90.0, 1e-9},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		ts, err := raw.Aggregate(table.metric, 60)
+		if err != nil {
+			t.Error("Expected error = nil. Received: ", err)
+			continue
+		}
+		if ts.Len() != 3 || ts.Epoch != 60 || ts.Metadata["metric"] != table.metric.String() {
+			t.Error("Unexpected time series: ", ts.Len(), ts.Epoch, ts.Metadata)
+			continue
+		}
+		if !ts.DateTime[1].Equal(ts.DateTime[0].Add(time.Minute)) {
+			t.Error("Expected epochs of 1 minute. Received: ", ts.DateTime)
+		}
+		if math.Abs(ts.Data[2]-table.expected) > table.tolerance {
+			t.Error(
+				"Metric: ", table.metric,
+				"Expected: ", table.expected,
+				"Received: ", ts.Data[2],
+			)
+		}
+	}
+
+	// Static device (1 g)
+	still := rawSignal(sampleRate, 120*30, func(second float64) (x, y, z float64) {
+		return 0.0, 0.0, 1.0
+	})
+	ts, _ := still.Aggregate(ENMO, 30)
+	if ts.Len() != 4 || !sliceFloatEquals(ts.Data, []float64{0, 0, 0, 0}) {
+		t.Error("Expected: [0 0 0 0], Received: ", ts.Data)
+	}
+
+	// The aggregated time series can be used by the analysis functions
+	if _, _, err := ts.HigherActivity(1); !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+
+	// Device tilted by 30 degrees
+	tilted := rawSignal(sampleRate, 60*30, func(second float64) (x, y, z float64) {
+		return 0.0, math.Cos(math.Pi / 6.0), math.Sin(math.Pi / 6.0)
+	})
+	ts, _ = tilted.Aggregate(AngleZ, 60)
+	if ts.Len() != 1 || math.Abs(ts.Data[0]-30.0) > 1e-9 {
+		t.Error("Expected: [30], Received: ", ts.Data)
+	}
+
+	// The short movements (a sample every 2 seconds) are removed by the rolling median of each axis
+	moved := rawSignal(sampleRate, 60*30, func(second float64) (x, y, z float64) {
+		if math.Mod(second, 2.0) < 0.5/sampleRate {
+			return 1.0, 0.0, 0.0
+		}
+		return 0.0, math.Cos(math.Pi / 6.0), math.Sin(math.Pi / 6.0)
+	})
+	ts, _ = moved.Aggregate(AngleZ, 60)
+	if ts.Len() != 1 || math.Abs(ts.Data[0]-30.0) > 1e-9 {
+		t.Error("Expected: [30], Received: ", ts.Data)
+	}
+
+	// The NaN samples are not used by the rolling median (NaN when the window does not have any valid sample)
+	missing := rawSignal(sampleRate, 120*30, func(second float64) (x, y, z float64) {
+		if second < 60.0 {
+			return math.NaN(), math.NaN(), math.NaN()
+		}
+		if math.Mod(second, 2.0) < 0.5/sampleRate {
+			return math.NaN(), 0.0, 0.0
+		}
+		return 0.0, math.Cos(math.Pi / 6.0), math.Sin(math.Pi / 6.0)
+	})
+	ts, err := missing.Aggregate(AngleZ, 60)
+	if err != nil || ts.Len() != 2 || !math.IsNaN(ts.Data[0]) || math.Abs(ts.Data[1]-30.0) > 1e-9 {
+		t.Error("Expected: [NaN 30], Received: ", ts.Data, err)
+	}
+
+	_, err = raw.Aggregate(ENMO, 0)
+	if !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+
+	_, err = raw.Aggregate(Metric(99), 60)
+	if !errors.Is(err, ErrInvalidMetric) {
+		t.Error("Expected: ErrInvalidMetric, Received: ", err)
+	}
+
+	slow := rawSignal(4, 400, func(second float64) (x, y, z float64) {
+		return 0.0, 0.0, 1.0
+	})
+	_, err = slow.Aggregate(ZCM, 60)
+	if !errors.Is(err, ErrInvalidSampleRate) {
+		t.Error("Expected: ErrInvalidSampleRate, Received: ", err)
+	}
+}
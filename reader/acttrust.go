@@ -0,0 +1,130 @@
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layout of the timestamps of the Condor ActTrust files
+const actTrustLayout = "02/01/2006 15:04:05"
+
+// Converts an ActTrust column name to a channel name (e.g. "EXT TEMPERATURE" to "ext_temperature")
+func actTrustChannel(column string) string {
+	return strings.ToLower(strings.Replace(strings.TrimSpace(column), " ", "_", -1))
+}
+
+// ReadActTrust reads a Condor ActTrust text export. The header block ("KEY : VALUE" lines) is stored in the metadata
+// and each column after DATE/TIME (e.g. PIM, TAT, ZCM, LIGHT, TEMPERATURE) becomes a channel, named in lower case
+// with underscores (e.g. "pim", "ext_temperature"). Both decimal separators (',' and '.') are accepted.
+// The timestamps are created in the location passed by parameter (default UTC)
+func ReadActTrust(r io.Reader, location *time.Location) (recording *Recording, err error) {
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	scanner := bufio.NewScanner(r)
+	recording = newRecording()
+
+	var columns []string
+	line := 0
+
+	// Header block
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(strings.ToUpper(text), "DATE/TIME") {
+			columns = strings.Split(text, ";")
+			break
+		}
+
+		parts := strings.SplitN(text, ":", 2)
+		if len(parts) == 2 {
+			recording.Metadata[actTrustChannel(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return nil, fmt.Errorf("%w: DATE/TIME column not found", ErrInvalidFormat)
+	}
+
+	msColumn := -1
+	for index, column := range columns {
+		if index > 0 && strings.EqualFold(strings.TrimSpace(column), "MS") {
+			msColumn = index
+		}
+	}
+
+	// Data
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		fields := strings.Split(text, ";")
+		if len(fields) < len(columns) {
+			return nil, &ParseError{Line: line, Column: len(fields), Err: ErrMalformed}
+		}
+
+		dateTime, parseErr := time.ParseInLocation(actTrustLayout, strings.TrimSpace(fields[0]), location)
+		if parseErr != nil {
+			return nil, &ParseError{Line: line, Column: 0, Err: fmt.Errorf("%w: %v", ErrMalformed, parseErr)}
+		}
+
+		var values []float64
+		for index := 1; index < len(columns); index++ {
+			value, parseErr := parseFloat(fields[index], ',')
+			if parseErr != nil {
+				return nil, &ParseError{Line: line, Column: index, Err: fmt.Errorf("%w: %v", ErrMalformed, parseErr)}
+			}
+			values = append(values, value)
+		}
+
+		if msColumn > 0 {
+			dateTime = dateTime.Add(time.Duration(values[msColumn-1]) * time.Millisecond)
+		}
+
+		recording.DateTime = append(recording.DateTime, dateTime)
+		for index := 1; index < len(columns); index++ {
+			if index == msColumn {
+				continue
+			}
+			name := actTrustChannel(columns[index])
+			recording.Channels[name] = append(recording.Channels[name], values[index-1])
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if interval, convErr := strconv.Atoi(recording.Metadata["interval"]); convErr == nil && interval > 0 {
+		recording.Epoch = interval
+	}
+
+	return
+}
+
+// ReadActTrustFile opens and reads a Condor ActTrust text export
+func ReadActTrustFile(path string, location *time.Location) (recording *Recording, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	recording, err = ReadActTrust(file, location)
+	if err == nil {
+		recording.Metadata["file"] = path
+	}
+	return
+}
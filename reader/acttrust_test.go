@@ -0,0 +1,92 @@
+package reader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadActTrust(t *testing.T) {
+
+	content := "+-------------------------------------------------+\n" +
+		"DEVICE_ID : 00000123\n" +
+		"SUBJECT_NAME : S01\n" +
+		"INTERVAL : 60\n" +
+		"+-------------------------------------------------+\n" +
+		"DATE/TIME;MS;EVENT;TEMPERATURE;EXT TEMPERATURE;PIM;TAT;ZCM;LIGHT\n" +
+		"01/01/2015 10:00:00;0;0;30,50;25,25;1200;30;15;100,5\n" +
+		"01/01/2015 10:01:00;500;0;30.75;25.00;800;20;10;200\n" +
+		"01/01/2015 10:02:00;0;1;31,00;25,50;0;0;0;0\n"
+
+	recording, err := ReadActTrust(strings.NewReader(content), nil)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if recording.Epoch != 60 || recording.Metadata["device_id"] != "00000123" || recording.Metadata["subject_name"] != "S01" {
+		t.Error("Unexpected header: ", recording.Epoch, recording.Metadata)
+	}
+	if len(recording.DateTime) != 3 {
+		t.Fatal("Expected: 3 records, Received: ", len(recording.DateTime))
+	}
+	if !recording.DateTime[1].Equal(time.Date(2015, 1, 1, 10, 1, 0, 500000000, time.UTC)) {
+		t.Error("Expected: 2015-01-01 10:01:00.5, Received: ", recording.DateTime[1])
+	}
+
+	if _, ok := recording.Channels["ms"]; ok {
+		t.Error("Expected the MS column to be merged into the timestamps")
+	}
+
+	// Table tests
+	var tTests = []struct {
+		channel string
+		values  []float64
+	}{
+		{"pim", []float64{1200, 800, 0}},
+		{"tat", []float64{30, 20, 0}},
+		{"zcm", []float64{15, 10, 0}},
+		{"light", []float64{100.5, 200, 0}},
+		{"temperature", []float64{30.5, 30.75, 31}},
+		{"ext_temperature", []float64{25.25, 25, 25.5}},
+		{"event", []float64{0, 0, 1}},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if !sliceEquals(recording.Channels[table.channel], table.values) {
+			t.Error(
+				"Channel: ", table.channel,
+				"Expected: ", table.values,
+				"Received: ", recording.Channels[table.channel],
+			)
+		}
+	}
+}
+
+func TestReadActTrustInvalid(t *testing.T) {
+
+	_, err := ReadActTrust(strings.NewReader("DEVICE_ID : 1\n"), nil)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+
+	content := "DATE/TIME;PIM;ZCM\n" +
+		"01/01/2015 10:00:00;10;1\n" +
+		"01/01/2015 10:01:00;abc;1\n"
+
+	_, err = ReadActTrust(strings.NewReader(content), nil)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 3 || parseErr.Column != 1 {
+		t.Error("Expected: ParseError on line 3 column 1, Received: ", err)
+	}
+
+	content = "DATE/TIME;PIM;ZCM\n" +
+		"01/01/2015 10:00:00;10\n"
+
+	_, err = ReadActTrust(strings.NewReader(content), nil)
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Error("Expected: ParseError on line 2, Received: ", err)
+	}
+}
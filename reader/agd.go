@@ -0,0 +1,151 @@
+package reader
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kelvins/chronobiology/reader/internal/sqlite"
+)
+
+// Number of seconds between 0001-01-01 (.NET ticks origin) and 1970-01-01 (Unix origin)
+const ticksToUnixSeconds = 62135596800
+
+// Converts .NET ticks (100 ns intervals since 0001-01-01) to time, interpreting it
+// as the wall clock of the location passed by parameter
+func ticksToTime(ticks int64, location *time.Location) time.Time {
+	utc := time.Unix(ticks/10000000-ticksToUnixSeconds, (ticks%10000000)*100).UTC()
+	return wallClock(utc, location)
+}
+
+// Interprets the UTC date and time passed by parameter as the wall clock of the location
+func wallClock(utc time.Time, location *time.Location) time.Time {
+	if location == nil {
+		location = time.UTC
+	}
+	return time.Date(utc.Year(), utc.Month(), utc.Day(), utc.Hour(), utc.Minute(), utc.Second(), utc.Nanosecond(), location)
+}
+
+// Converts a SQLite value to float64 (NaN when it is not a number)
+func sqliteFloat(value interface{}) float64 {
+	switch number := value.(type) {
+	case int64:
+		return float64(number)
+	case float64:
+		return number
+	case string:
+		if parsed, err := strconv.ParseFloat(number, 64); err == nil {
+			return parsed
+		}
+	}
+	return math.NaN()
+}
+
+// ReadAGD reads an ActiGraph .agd file (a SQLite database with the epoch data), which must have the size passed by parameter.
+// The timestamps are stored as the device wall clock, so they are interpreted in the location passed by parameter (default UTC).
+// Each column of the data table (e.g. axis1, axis2, axis3, steps, lux) becomes a channel, and the vector magnitude
+// of the three axes is stored in the "vm" channel
+func ReadAGD(r io.ReaderAt, size int64, location *time.Location) (recording *Recording, err error) {
+
+	db, err := sqlite.Open(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	settings, err := db.ReadTable("settings")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	recording = newRecording()
+
+	nameColumn, valueColumn := -1, -1
+	for index, column := range settings.Columns {
+		switch strings.ToLower(column) {
+		case "settingname":
+			nameColumn = index
+		case "settingvalue":
+			valueColumn = index
+		}
+	}
+	if nameColumn < 0 || valueColumn < 0 {
+		return nil, fmt.Errorf("%w: invalid settings table", ErrInvalidFormat)
+	}
+
+	for _, row := range settings.Rows {
+		name, _ := row[nameColumn].(string)
+		value := fmt.Sprint(row[valueColumn])
+		recording.Metadata[strings.ToLower(name)] = value
+	}
+
+	if epoch, convErr := strconv.Atoi(recording.Metadata["epochlength"]); convErr == nil {
+		recording.Epoch = epoch
+	}
+
+	data, err := db.ReadTable("data")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	timestampColumn := -1
+	for index, column := range data.Columns {
+		if strings.EqualFold(column, "dataTimestamp") {
+			timestampColumn = index
+		}
+	}
+	if timestampColumn < 0 {
+		return nil, fmt.Errorf("%w: data table without timestamps", ErrInvalidFormat)
+	}
+
+	for line, row := range data.Rows {
+		ticks, ok := row[timestampColumn].(int64)
+		if !ok {
+			return nil, &ParseError{Line: line + 1, Column: timestampColumn, Err: ErrMalformed}
+		}
+		recording.DateTime = append(recording.DateTime, ticksToTime(ticks, location))
+
+		for index, column := range data.Columns {
+			if index == timestampColumn {
+				continue
+			}
+			recording.Channels[column] = append(recording.Channels[column], sqliteFloat(row[index]))
+		}
+	}
+
+	axis1, ok1 := recording.Channels["axis1"]
+	axis2, ok2 := recording.Channels["axis2"]
+	axis3, ok3 := recording.Channels["axis3"]
+	if ok1 && ok2 && ok3 {
+		vm := make([]float64, len(axis1))
+		for index := range vm {
+			vm[index] = math.Sqrt(axis1[index]*axis1[index] + axis2[index]*axis2[index] + axis3[index]*axis3[index])
+		}
+		recording.Channels["vm"] = vm
+	}
+
+	return
+}
+
+// ReadAGDFile opens and reads an ActiGraph .agd file
+func ReadAGDFile(path string, location *time.Location) (recording *Recording, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+
+	recording, err = ReadAGD(file, info.Size(), location)
+	if err == nil {
+		recording.Metadata["file"] = path
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package reader
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTicksToTime(t *testing.T) {
+	location := time.FixedZone("UTC-3", -3*60*60)
+
+	// 635556672000000000 ticks = 2015-01-01 00:00:00
+	dateTime := ticksToTime(635556672000000000, location)
+
+	if !dateTime.Equal(time.Date(2015, 1, 1, 0, 0, 0, 0, location)) {
+		t.Error("Expected: 2015-01-01 00:00:00 -0300, Received: ", dateTime)
+	}
+}
+
+func TestReadAGDFile(t *testing.T) {
+
+	// Synthetic file generated by testdata/generate_agd.py
+	path := filepath.Join("testdata", "sample.agd")
+
+	recording, err := ReadAGDFile(path, nil)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if recording.Epoch != 60 {
+		t.Error("Expected: 60, Received: ", recording.Epoch)
+	}
+	if recording.Metadata["deviceserial"] != "MOS2A12345678" || recording.Metadata["file"] != path {
+		t.Error("Unexpected metadata: ", recording.Metadata["deviceserial"], recording.Metadata["file"])
+	}
+	if len(recording.DateTime) != 300 {
+		t.Fatal("Expected: 300 records, Received: ", len(recording.DateTime))
+	}
+	if !recording.DateTime[1].Equal(time.Date(2015, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Error("Expected: 2015-01-01 00:01:00, Received: ", recording.DateTime[1])
+	}
+
+	for _, name := range []string{"axis1", "axis2", "axis3", "steps", "lux", "vm"} {
+		if len(recording.Channels[name]) != 300 {
+			t.Error("Expected 300 values in the channel ", name)
+		}
+	}
+
+	axis1 := recording.Channels["axis1"]
+	if axis1[10] != 370 {
+		t.Error("Expected: 370, Received: ", axis1[10])
+	}
+
+	vm := recording.Channels["vm"]
+	expected := math.Sqrt(370*370 + 110*110 + 70*70)
+	if math.Abs(vm[10]-expected) > 1e-9 {
+		t.Error("Expected: ", expected, "Received: ", vm[10])
+	}
+
+	// The channels can be used by the analysis functions
+	ts, err := recording.Channel("axis1")
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if _, _, err = ts.HigherActivity(1); err != nil {
+		t.Error("Expected error = nil. Received: ", err)
+	}
+
+	_, err = ReadAGDFile(filepath.Join("testdata", "generate_agd.py"), nil)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+}
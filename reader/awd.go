@@ -0,0 +1,157 @@
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Number of lines in the header of the Actiwatch AWD files
+const awdHeaderLines = 7
+
+// Epoch (seconds) of each epoch code used by the Actiwatch AWD files (as pyActigraphy)
+var awdEpochs = map[int]int{
+	1:  15,
+	2:  30,
+	4:  60,
+	8:  120,
+	20: 300,
+	81: 2,
+}
+
+// Parses the start date and time of the Actiwatch AWD files (e.g. "01-Jan-2015" and "10:00")
+func parseAWDStart(date string, clock string, location *time.Location) (time.Time, error) {
+	date = strings.TrimSpace(date)
+	clock = strings.TrimSpace(clock)
+
+	// Month names are not always capitalised (e.g. "01-jan-2015")
+	if parts := strings.Split(date, "-"); len(parts) == 3 && len(parts[1]) == 3 {
+		parts[1] = strings.ToUpper(parts[1][:1]) + strings.ToLower(parts[1][1:])
+		date = strings.Join(parts, "-")
+	}
+
+	layouts := []string{"02-Jan-2006 15:04", "02-Jan-2006 15:04:05", "2-Jan-2006 15:04", "2-Jan-2006 15:04:05"}
+	var err error
+	for _, layout := range layouts {
+		var start time.Time
+		start, err = time.ParseInLocation(layout, date+" "+clock, location)
+		if err == nil {
+			return start, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// ReadAWD reads a Philips Actiwatch .AWD file. The header (7 lines) stores the subject, start date, start time,
+// epoch code, age, serial number and sex, followed by one line per epoch with the activity count and, optionally,
+// the light level or an event marker ("M"). The counts are stored in the "activity" channel, the light in the "light"
+// channel and the markers (1 when pressed) in the "marker" channel. The timestamps are created in the location passed by parameter (default UTC)
+func ReadAWD(r io.Reader, location *time.Location) (recording *Recording, err error) {
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	scanner := bufio.NewScanner(r)
+
+	var header []string
+	for len(header) < awdHeaderLines && scanner.Scan() {
+		header = append(header, strings.TrimSpace(scanner.Text()))
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if len(header) < awdHeaderLines {
+		return nil, fmt.Errorf("%w: incomplete AWD header", ErrInvalidFormat)
+	}
+
+	start, err := parseAWDStart(header[1], header[2], location)
+	if err != nil {
+		return nil, &ParseError{Line: 2, Column: -1, Err: fmt.Errorf("%w: %v", ErrMalformed, err)}
+	}
+
+	code, err := strconv.Atoi(header[3])
+	if err != nil {
+		return nil, &ParseError{Line: 4, Column: -1, Err: fmt.Errorf("%w: %v", ErrMalformed, err)}
+	}
+	epoch, ok := awdEpochs[code]
+	if !ok {
+		return nil, &ParseError{Line: 4, Column: -1, Err: fmt.Errorf("%w: unknown epoch code %d", ErrMalformed, code)}
+	}
+
+	recording = newRecording()
+	recording.Epoch = epoch
+	recording.Metadata["subject"] = header[0]
+	recording.Metadata["age"] = header[4]
+	recording.Metadata["serial_number"] = header[5]
+	recording.Metadata["sex"] = header[6]
+
+	var activity, light, marker []float64
+	hasLight := false
+
+	for line := awdHeaderLines + 1; scanner.Scan(); line++ {
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		fields := strings.Split(text, ",")
+
+		value, parseErr := strconv.ParseFloat(strings.TrimSpace(fields[0]), 64)
+		if parseErr != nil {
+			return nil, &ParseError{Line: line, Column: 0, Err: fmt.Errorf("%w: %v", ErrMalformed, parseErr)}
+		}
+
+		lightValue := 0.0
+		markerValue := 0.0
+		if len(fields) > 1 {
+			second := strings.TrimSpace(fields[1])
+			switch {
+			case strings.EqualFold(second, "M"):
+				markerValue = 1.0
+			case second != "":
+				lightValue, parseErr = strconv.ParseFloat(second, 64)
+				if parseErr != nil {
+					return nil, &ParseError{Line: line, Column: 1, Err: fmt.Errorf("%w: %v", ErrMalformed, parseErr)}
+				}
+				hasLight = true
+			}
+		}
+
+		recording.DateTime = append(recording.DateTime, start.Add(time.Duration(len(activity)*epoch)*time.Second))
+		activity = append(activity, value)
+		light = append(light, lightValue)
+		marker = append(marker, markerValue)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	recording.Channels["activity"] = activity
+	recording.Channels["marker"] = marker
+	if hasLight {
+		recording.Channels["light"] = light
+	}
+
+	return
+}
+
+// ReadAWDFile opens and reads a Philips Actiwatch .AWD file
+func ReadAWDFile(path string, location *time.Location) (recording *Recording, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	recording, err = ReadAWD(file, location)
+	if err == nil {
+		recording.Metadata["file"] = path
+	}
+	return
+}
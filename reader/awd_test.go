@@ -0,0 +1,120 @@
+package reader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadAWD(t *testing.T) {
+
+	content := "S01\n01-jan-2015\n23:58\n2\n35\nP12345\n1\n" +
+		"  10 , 120.5\n" +
+		"  20 , M\n" +
+		"  30\n" +
+		"\n" +
+		"  40 , 0\n"
+
+	recording, err := ReadAWD(strings.NewReader(content), nil)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if recording.Epoch != 30 || recording.Metadata["subject"] != "S01" || recording.Metadata["serial_number"] != "P12345" {
+		t.Error("Unexpected header: ", recording.Epoch, recording.Metadata)
+	}
+	if len(recording.DateTime) != 4 {
+		t.Fatal("Expected: 4 records, Received: ", len(recording.DateTime))
+	}
+	if !recording.DateTime[0].Equal(time.Date(2015, 1, 1, 23, 58, 0, 0, time.UTC)) ||
+		!recording.DateTime[3].Equal(time.Date(2015, 1, 1, 23, 59, 30, 0, time.UTC)) {
+		t.Error("Unexpected timestamps: ", recording.DateTime)
+	}
+
+	if !sliceEquals(recording.Channels["activity"], []float64{10, 20, 30, 40}) {
+		t.Error("Unexpected activity: ", recording.Channels["activity"])
+	}
+	if !sliceEquals(recording.Channels["light"], []float64{120.5, 0, 0, 0}) {
+		t.Error("Unexpected light: ", recording.Channels["light"])
+	}
+	if !sliceEquals(recording.Channels["marker"], []float64{0, 1, 0, 0}) {
+		t.Error("Unexpected marker: ", recording.Channels["marker"])
+	}
+
+	ts, err := recording.Channel("activity")
+	if err != nil || ts.Epoch != 30 {
+		t.Error("Expected a time series with epoch 30. Received: ", err)
+	}
+}
+
+func TestReadAWDEpochCodes(t *testing.T) {
+
+	// Table tests
+	var tTests = []struct {
+		code  string
+		epoch int
+	}{
+		{"1", 15},
+		{"2", 30},
+		{"4", 60},
+		{"8", 120},
+		{"20", 300},
+		{"81", 2},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		content := "S01\n01-Jan-2015\n10:00\n" + table.code + "\n35\nP1\n1\n10\n20\n"
+		recording, err := ReadAWD(strings.NewReader(content), nil)
+		if err != nil || recording.Epoch != table.epoch ||
+			recording.DateTime[1].Sub(recording.DateTime[0]) != time.Duration(table.epoch)*time.Second {
+			t.Error("Code: ", table.code, "Expected: ", table.epoch, "Received: ", recording, err)
+		}
+	}
+}
+
+func TestReadAWDInvalid(t *testing.T) {
+
+	// Table tests
+	var tTests = []struct {
+		content string
+		line    int
+	}{
+		{"S01\n01-Jan-2015\n25:00\n4\n35\nP1\n1\n10\n", 2},
+		{"S01\n01-Jan-2015\n10:00\n3\n35\nP1\n1\n10\n", 4},
+		{"S01\n01-Jan-2015\n10:00\n4\n35\nP1\n1\n10\nabc\n", 9},
+		{"S01\n01-Jan-2015\n10:00\n4\n35\nP1\n1\n10\n20 , x\n", 9},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		_, err := ReadAWD(strings.NewReader(table.content), nil)
+
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || parseErr.Line != table.line {
+			t.Error(
+				"Expected: ParseError on line ", table.line,
+				"Received: ", err,
+			)
+		}
+	}
+
+	_, err := ReadAWD(strings.NewReader("S01\n01-Jan-2015\n"), nil)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+}
+
+// Compares two float64 slices
+func sliceEquals(slice1 []float64, slice2 []float64) bool {
+	if len(slice1) != len(slice2) {
+		return false
+	}
+	for index := range slice1 {
+		if slice1[index] != slice2[index] {
+			return false
+		}
+	}
+	return true
+}
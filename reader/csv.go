@@ -0,0 +1,243 @@
+package reader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Column maps a column of a delimited file to a channel name
+type Column struct {
+	// Name is the name of the channel (e.g. "activity", "light", "temperature")
+	Name string
+	// Index is the position of the column in the row, starting at 0
+	Index int
+}
+
+// CSVOptions configures how a delimited (CSV, TSV, ...) file is read
+type CSVOptions struct {
+	// Comma is the field delimiter (default ',', use '\t' for TSV files)
+	Comma rune
+	// Comment is the character that starts a comment line (default none)
+	Comment rune
+	// SkipLines is the number of lines to skip at the beginning of the file (e.g. header)
+	SkipLines int
+	// DateTimeColumns are the columns that compose the timestamp, joined by a space
+	// (e.g. {0} for "2015-01-01 10:00:00" or {0, 1} for "2015-01-01" and "10:00:00")
+	DateTimeColumns []int
+	// Layout is the layout used to parse the timestamp (default time.RFC3339)
+	Layout string
+	// Location is the time zone of the timestamps (default UTC)
+	Location *time.Location
+	// DecimalSeparator is the decimal separator of the values (default '.')
+	DecimalSeparator rune
+	// Columns are the value columns to be read
+	Columns []Column
+	// SkipMalformed defines whether the malformed rows are skipped (and stored in the recording)
+	// instead of returning an error. The read errors (e.g. I/O errors) are always returned
+	SkipMalformed bool
+}
+
+// Sets the default values of the options
+func (options *CSVOptions) setDefaults() {
+	if options.Comma == 0 {
+		options.Comma = ','
+	}
+	if len(options.DateTimeColumns) == 0 {
+		options.DateTimeColumns = []int{0}
+	}
+	if options.Layout == "" {
+		options.Layout = time.RFC3339
+	}
+	if options.Location == nil {
+		options.Location = time.UTC
+	}
+	if options.DecimalSeparator == 0 {
+		options.DecimalSeparator = '.'
+	}
+}
+
+// Checks the column indexes (not negative) and the channel names (unique) of the options
+func (options *CSVOptions) check() error {
+	for _, index := range options.DateTimeColumns {
+		if index < 0 {
+			return fmt.Errorf("%w: negative date time column %d", ErrInvalidColumns, index)
+		}
+	}
+	names := make(map[string]bool)
+	for _, column := range options.Columns {
+		if column.Index < 0 {
+			return fmt.Errorf("%w: negative index of %s", ErrInvalidColumns, column.Name)
+		}
+		if names[column.Name] {
+			return fmt.Errorf("%w: repeated name %s", ErrInvalidColumns, column.Name)
+		}
+		names[column.Name] = true
+	}
+	return nil
+}
+
+// Reader that returns at most one line per Read call, so the csv.Reader does not buffer the following lines
+// and the number of the last line consumed can be found
+type lineReader struct {
+	reader  *bufio.Reader
+	pending []byte
+	err     error
+	line    int
+}
+
+// Creates a line reader from the reader passed by parameter
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{reader: bufio.NewReader(r)}
+}
+
+// Read reads the remaining bytes of the current line or the following line
+func (r *lineReader) Read(p []byte) (n int, err error) {
+	if len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.pending, r.err = r.reader.ReadBytes('\n')
+		if len(r.pending) == 0 {
+			return 0, r.err
+		}
+		r.line++
+	}
+	n = copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// Parses a value using the decimal separator passed by parameter
+func parseFloat(value string, decimalSeparator rune) (float64, error) {
+	value = strings.TrimSpace(value)
+	if decimalSeparator != '.' {
+		value = strings.Replace(value, string(decimalSeparator), ".", 1)
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// Parses a row of a delimited file
+func parseRow(row []string, options *CSVOptions) (dateTime time.Time, values []float64, column int, err error) {
+
+	var parts []string
+	for _, column = range options.DateTimeColumns {
+		if column >= len(row) {
+			err = ErrMalformed
+			return
+		}
+		parts = append(parts, strings.TrimSpace(row[column]))
+	}
+
+	dateTime, err = time.ParseInLocation(options.Layout, strings.Join(parts, " "), options.Location)
+	if err != nil {
+		return
+	}
+
+	for _, valueColumn := range options.Columns {
+		column = valueColumn.Index
+		if column >= len(row) {
+			err = ErrMalformed
+			return
+		}
+
+		var value float64
+		value, err = parseFloat(row[column], options.DecimalSeparator)
+		if err != nil {
+			return
+		}
+		values = append(values, value)
+	}
+
+	return
+}
+
+// ReadCSV reads a delimited file (CSV, TSV, ...) based on the options passed by parameter
+func ReadCSV(r io.Reader, options CSVOptions) (recording *Recording, err error) {
+
+	if len(options.Columns) == 0 {
+		err = ErrNoColumns
+		return
+	}
+	if err = options.check(); err != nil {
+		return
+	}
+	options.setDefaults()
+
+	lines := newLineReader(r)
+	csvReader := csv.NewReader(lines)
+	csvReader.Comma = options.Comma
+	csvReader.Comment = options.Comment
+	csvReader.FieldsPerRecord = -1
+	csvReader.LazyQuotes = true
+
+	recording = newRecording()
+
+	for rowIndex := 0; ; rowIndex++ {
+
+		row, readErr := csvReader.Read()
+		if readErr == io.EOF {
+			break
+		}
+
+		// Only the malformed rows can be skipped (e.g. the I/O errors are returned)
+		var csvErr *csv.ParseError
+		if readErr != nil && !errors.As(readErr, &csvErr) {
+			return nil, readErr
+		}
+
+		if rowIndex < options.SkipLines {
+			continue
+		}
+
+		if readErr != nil {
+			parseErr := &ParseError{Line: csvErr.Line, Column: -1, Err: readErr}
+			if !options.SkipMalformed {
+				return nil, parseErr
+			}
+			recording.Malformed = append(recording.Malformed, parseErr)
+			continue
+		}
+
+		// The line of the row is the last line consumed (multiline rows report their last line)
+		line := lines.line
+
+		dateTime, values, column, parseErr := parseRow(row, &options)
+		if parseErr != nil {
+			malformed := &ParseError{Line: line, Column: column, Err: parseErr}
+			if !options.SkipMalformed {
+				return nil, malformed
+			}
+			recording.Malformed = append(recording.Malformed, malformed)
+			continue
+		}
+
+		recording.DateTime = append(recording.DateTime, dateTime)
+		for index, valueColumn := range options.Columns {
+			recording.Channels[valueColumn.Name] = append(recording.Channels[valueColumn.Name], values[index])
+		}
+	}
+
+	return
+}
+
+// ReadCSVFile opens and reads a delimited file (CSV, TSV, ...) based on the options passed by parameter
+func ReadCSVFile(path string, options CSVOptions) (recording *Recording, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	recording, err = ReadCSV(file, options)
+	if err == nil {
+		recording.Metadata["file"] = path
+	}
+	return
+}
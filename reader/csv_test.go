@@ -0,0 +1,210 @@
+package reader
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Reader that always fails (e.g. a disk failure)
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errDiskFailure
+}
+
+var errDiskFailure = errors.New("disk failure")
+
+func TestReadCSV(t *testing.T) {
+
+	content := `date,time,activity,light
+2015-01-01,00:00:00,10,100.5
+2015-01-01,00:01:00,20,200.5
+2015-01-01,00:02:00,30,300.5
+`
+
+	recording, err := ReadCSV(strings.NewReader(content), CSVOptions{
+		SkipLines:       1,
+		DateTimeColumns: []int{0, 1},
+		Layout:          "2006-01-02 15:04:05",
+		Columns:         []Column{{"activity", 2}, {"light", 3}},
+	})
+
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if len(recording.DateTime) != 3 {
+		t.Fatal("Expected: 3 records, Received: ", len(recording.DateTime))
+	}
+	if !recording.DateTime[1].Equal(time.Date(2015, 1, 1, 0, 1, 0, 0, time.UTC)) {
+		t.Error("Expected: 2015-01-01 00:01:00, Received: ", recording.DateTime[1])
+	}
+	if names := recording.Names(); len(names) != 2 || names[0] != "activity" || names[1] != "light" {
+		t.Error("Expected: [activity light], Received: ", names)
+	}
+
+	ts, err := recording.Channel("light")
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if ts.Epoch != 60 || ts.Data[2] != 300.5 || ts.Metadata["channel"] != "light" {
+		t.Error("Unexpected light channel: ", ts.Epoch, ts.Data, ts.Metadata)
+	}
+
+	_, err = recording.Channel("temperature")
+	if !errors.Is(err, ErrUnknownChannel) {
+		t.Error("Expected: ErrUnknownChannel, Received: ", err)
+	}
+
+	_, err = ReadCSV(strings.NewReader(content), CSVOptions{})
+	if !errors.Is(err, ErrNoColumns) {
+		t.Error("Expected: ErrNoColumns, Received: ", err)
+	}
+}
+
+func TestReadCSVOptions(t *testing.T) {
+
+	// TSV file with decimal comma, comments and a different time zone
+	content := "# Subject: S01\n" +
+		"01/01/2015 10:00\t1,5\n" +
+		"01/01/2015 10:00:30\t2,5\n" +
+		"01/01/2015 10:01\tabc\n" +
+		"01/01/2015 10:01\n" +
+		"01/01/2015 10:02\t3,25\n"
+
+	location := time.FixedZone("UTC-3", -3*60*60)
+
+	options := CSVOptions{
+		Comma:            '\t',
+		Comment:          '#',
+		Layout:           "02/01/2006 15:04",
+		Location:         location,
+		DecimalSeparator: ',',
+		Columns:          []Column{{"activity", 1}},
+	}
+
+	// The first malformed row must be reported with its line number
+	_, err := ReadCSV(strings.NewReader(content), options)
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || !errors.Is(err, ErrMalformed) {
+		t.Fatal("Expected: ParseError, Received: ", err)
+	}
+	if parseErr.Line != 3 || parseErr.Column != 0 {
+		t.Error("Expected: line 3 column 0, Received: ", parseErr)
+	}
+
+	// Skip the malformed rows
+	options.SkipMalformed = true
+	recording, err := ReadCSV(strings.NewReader(content), options)
+
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if len(recording.Malformed) != 3 {
+		t.Fatal("Expected: 3 malformed rows, Received: ", len(recording.Malformed))
+	}
+
+	var lines []int
+	for _, malformed := range recording.Malformed {
+		lines = append(lines, malformed.Line)
+	}
+	if lines[0] != 3 || lines[1] != 4 || lines[2] != 5 {
+		t.Error("Expected: [3 4 5], Received: ", lines)
+	}
+
+	data := recording.Channels["activity"]
+	if len(data) != 2 || math.Abs(data[0]-1.5) > 1e-9 || math.Abs(data[1]-3.25) > 1e-9 {
+		t.Error("Expected: [1.5 3.25], Received: ", data)
+	}
+	if recording.DateTime[0].Location() != location || recording.DateTime[0].UTC().Hour() != 13 {
+		t.Error("Expected: 13:00 UTC, Received: ", recording.DateTime[0])
+	}
+}
+
+func TestReadCSVFile(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "chronobiology")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "activity.csv")
+	content := "2015-01-01T00:00:00Z,10\n2015-01-01T00:01:00Z,20\n"
+
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recording, err := ReadCSVFile(path, CSVOptions{Columns: []Column{{"activity", 1}}})
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if recording.Metadata["file"] != path || len(recording.Channels["activity"]) != 2 {
+		t.Error("Unexpected recording: ", recording.Metadata, recording.Channels)
+	}
+
+	_, err = ReadCSVFile(filepath.Join(dir, "missing.csv"), CSVOptions{Columns: []Column{{"activity", 1}}})
+	if err == nil {
+		t.Error("Expected error not nil")
+	}
+}
+
+func TestReadCSVInvalidColumns(t *testing.T) {
+
+	content := "2015-01-01T00:00:00Z,10,20\n2015-01-01T00:01:00Z,30,40\n"
+
+	// Table tests
+	var tTests = []CSVOptions{
+		{Columns: []Column{{"activity", -1}}},
+		{DateTimeColumns: []int{-1}, Columns: []Column{{"activity", 1}}},
+		{Columns: []Column{{"activity", 1}, {"activity", 2}}},
+	}
+
+	for _, options := range tTests {
+		_, err := ReadCSV(strings.NewReader(content), options)
+		if !errors.Is(err, ErrInvalidColumns) {
+			t.Error("Options: ", options, "Expected: ErrInvalidColumns, Received: ", err)
+		}
+	}
+
+	// The line numbers count the skipped lines and the lines longer than the buffer
+	long := strings.Repeat("x", 5000)
+	content = "header," + long + "\n2015-01-01T00:00:00Z,10\n2015-01-01T00:01:00Z,abc"
+	_, err := ReadCSV(strings.NewReader(content), CSVOptions{SkipLines: 1, Columns: []Column{{"activity", 1}}})
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 3 {
+		t.Error("Expected: line 3, Received: ", err)
+	}
+}
+
+func TestReadCSVReadError(t *testing.T) {
+
+	content := "2015-01-01T00:00:00Z,10\n2015-01-01T00:01:00Z,20\n"
+
+	// The read errors are returned even when the malformed rows are skipped
+	var tTests = []struct {
+		content string
+		skip    bool
+	}{
+		{content, false},
+		{content, true},
+		{content + "2015-01-01T00:02:00Z,abc\n", true},
+	}
+
+	for _, test := range tTests {
+		r := io.MultiReader(strings.NewReader(test.content), failingReader{})
+		recording, err := ReadCSV(r, CSVOptions{SkipMalformed: test.skip, Columns: []Column{{"activity", 1}}})
+		var parseErr *ParseError
+		if !errors.Is(err, errDiskFailure) || errors.As(err, &parseErr) || recording != nil {
+			t.Error("SkipMalformed: ", test.skip, "Expected: disk failure, Received: ", err)
+		}
+	}
+}
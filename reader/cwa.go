@@ -0,0 +1,272 @@
+package reader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Size of the data blocks of the Axivity .cwa files
+const cwaBlockSize = 512
+
+// Default acceleration unit of the Axivity .cwa files (1/256 g)
+const cwaAccelerationUnit = 256.0
+
+// CWAReader reads the data blocks of an Axivity .cwa file one at a time, so the whole file
+// does not need to be stored in memory
+type CWAReader struct {
+	// Metadata stores the information found in the file header (e.g. device and session identifiers)
+	Metadata map[string]string
+
+	r        io.Reader
+	location *time.Location
+	number   int
+	next     *cwaBlock
+	eof      bool
+}
+
+// Decoded data block of an Axivity .cwa file
+type cwaBlock struct {
+	start      time.Time
+	sampleRate float64
+	block      *RawBlock
+	x, y, z    []float64
+}
+
+// Decodes the packed timestamp of the Axivity .cwa files (YYYYYYMM MMDDDDDh hhhhmmmm mmssssss)
+func cwaTimestamp(value uint32, location *time.Location) time.Time {
+	return time.Date(
+		int((value>>26)&0x3f)+2000,
+		time.Month((value>>22)&0x0f),
+		int((value>>17)&0x1f),
+		int((value>>12)&0x1f),
+		int((value>>6)&0x3f),
+		int(value&0x3f),
+		0,
+		location,
+	)
+}
+
+// Decodes a packed sample (3 axes of 10 bits and a 2-bit exponent) of the Axivity .cwa files
+func cwaPacked(value uint32) (x int, y int, z int) {
+	exponent := uint(value >> 30)
+	x = int(int16(uint16(value<<6)&0xffc0) >> (6 - exponent))
+	y = int(int16(uint16(value>>4)&0xffc0) >> (6 - exponent))
+	z = int(int16(uint16(value>>14)&0xffc0) >> (6 - exponent))
+	return
+}
+
+// NewCWAReader reads the header of an Axivity .cwa file. The timestamps are stored as the device wall clock,
+// so they are interpreted in the location passed by parameter (default UTC)
+func NewCWAReader(r io.Reader, location *time.Location) (reader *CWAReader, err error) {
+
+	if location == nil {
+		location = time.UTC
+	}
+
+	header := make([]byte, 4)
+	if _, err = io.ReadFull(r, header); err != nil || string(header[:2]) != "MD" {
+		return nil, fmt.Errorf("%w: header not found", ErrInvalidFormat)
+	}
+
+	content := make([]byte, int(binary.LittleEndian.Uint16(header[2:])))
+	if _, err = io.ReadFull(r, content); err != nil || len(content) < 37 {
+		return nil, fmt.Errorf("%w: incomplete header", ErrInvalidFormat)
+	}
+	content = append(header, content...)
+
+	deviceID := uint32(binary.LittleEndian.Uint16(content[5:]))
+	if upper := binary.LittleEndian.Uint16(content[11:]); upper != 0xffff {
+		deviceID |= uint32(upper) << 16
+	}
+
+	reader = &CWAReader{
+		Metadata: map[string]string{
+			"device_id":  strconv.FormatUint(uint64(deviceID), 10),
+			"session_id": strconv.FormatUint(uint64(binary.LittleEndian.Uint32(content[7:])), 10),
+		},
+		r:        r,
+		location: location,
+	}
+
+	if rate := content[36]; rate != 0 {
+		reader.Metadata["sample_rate"] = strconv.FormatFloat(3200.0/float64(uint(1)<<(15-rate&0x0f)), 'f', -1, 64)
+	}
+
+	return reader, nil
+}
+
+// Reads and decodes the next data block (nil at the end of the file)
+func (reader *CWAReader) readBlock() (decoded *cwaBlock, err error) {
+
+	data := make([]byte, cwaBlockSize)
+
+	for {
+		if _, err = io.ReadFull(reader.r, data); err != nil {
+			if err == io.EOF {
+				return nil, nil
+			}
+			return nil, &ParseError{Line: reader.number + 1, Column: -1, Err: fmt.Errorf("%w: %v", ErrMalformed, err)}
+		}
+		reader.number++
+
+		// Other block types are ignored
+		if string(data[:2]) == "AX" && binary.LittleEndian.Uint16(data[2:]) == cwaBlockSize-4 {
+			break
+		}
+	}
+
+	// The sum of all the 16-bit words must be zero
+	var checksum uint16
+	for position := 0; position < cwaBlockSize; position += 2 {
+		checksum += binary.LittleEndian.Uint16(data[position:])
+	}
+	if checksum != 0 {
+		return nil, &ParseError{Line: reader.number, Column: -1, Err: fmt.Errorf("%w: invalid checksum", ErrMalformed)}
+	}
+
+	fractional := binary.LittleEndian.Uint16(data[4:])
+	timestamp := cwaTimestamp(binary.LittleEndian.Uint32(data[14:]), reader.location)
+	lightScale := binary.LittleEndian.Uint16(data[18:])
+	temperatureRaw := binary.LittleEndian.Uint16(data[20:]) & 0x3ff
+	rate := data[24]
+	axes := int(data[25] >> 4)
+	bytesPerSample := int(data[25] & 0x0f)
+	timestampOffset := float64(int16(binary.LittleEndian.Uint16(data[26:])))
+	sampleCount := int(binary.LittleEndian.Uint16(data[28:]))
+
+	if rate == 0 || axes < 3 || (bytesPerSample != 0 && bytesPerSample != 2) {
+		return nil, &ParseError{Line: reader.number, Column: -1, Err: fmt.Errorf("%w: unsupported sample format", ErrMalformed)}
+	}
+	sampleRate := 3200.0 / float64(uint(1)<<(15-rate&0x0f))
+
+	// The fractional part of the timestamp (1/65536 s) moves the sample at which the timestamp applies
+	if fractional&0x8000 != 0 {
+		timestampOffset += float64(int(fractional&0x7fff)<<1) * sampleRate / 65536.0
+	}
+
+	unit := cwaAccelerationUnit
+	if scale := lightScale >> 13; scale != 0 {
+		unit = float64(uint(1) << (8 + scale))
+	}
+
+	decoded = &cwaBlock{
+		start:      timestamp.Add(-time.Duration(timestampOffset / sampleRate * float64(time.Second))),
+		sampleRate: sampleRate,
+		block:      &RawBlock{},
+	}
+
+	light := float64(lightScale & 0x3ff)
+	temperature := float64(temperatureRaw)*75.0/256.0 - 50.0
+
+	samples := data[30 : cwaBlockSize-2]
+	for index := 0; index < sampleCount; index++ {
+		var x, y, z int
+		if bytesPerSample == 0 {
+			if (index+1)*4 > len(samples) {
+				break
+			}
+			x, y, z = cwaPacked(binary.LittleEndian.Uint32(samples[index*4:]))
+		} else {
+			size := axes * 2
+			if (index+1)*size > len(samples) {
+				break
+			}
+			x = int(int16(binary.LittleEndian.Uint16(samples[index*size:])))
+			y = int(int16(binary.LittleEndian.Uint16(samples[index*size+2:])))
+			z = int(int16(binary.LittleEndian.Uint16(samples[index*size+4:])))
+		}
+		decoded.x = append(decoded.x, float64(x)/unit)
+		decoded.y = append(decoded.y, float64(y)/unit)
+		decoded.z = append(decoded.z, float64(z)/unit)
+		decoded.block.Light = append(decoded.block.Light, light)
+		decoded.block.Temperature = append(decoded.block.Temperature, temperature)
+	}
+
+	return decoded, nil
+}
+
+// Next returns the samples of the next data block, or io.EOF when there are no more blocks.
+// The samples are spread between the start of the block and the start of the next block, so the
+// sample rate drift of the device is taken into account (the nominal rate is used in the last block)
+func (reader *CWAReader) Next() (block *RawBlock, err error) {
+
+	current := reader.next
+	if current == nil && !reader.eof {
+		if current, err = reader.readBlock(); err != nil {
+			return
+		}
+	}
+	if current == nil {
+		reader.eof = true
+		return nil, io.EOF
+	}
+
+	if reader.next, err = reader.readBlock(); err != nil {
+		return
+	}
+	if reader.next == nil {
+		reader.eof = true
+	}
+
+	interval := time.Duration(float64(time.Second) / current.sampleRate)
+	if reader.next != nil && len(current.x) > 0 {
+		if measured := reader.next.start.Sub(current.start) / time.Duration(len(current.x)); measured > 0 {
+			interval = measured
+		}
+	}
+
+	block = current.block
+	block.X, block.Y, block.Z = current.x, current.y, current.z
+	for index := range current.x {
+		block.DateTime = append(block.DateTime, current.start.Add(time.Duration(index)*interval))
+	}
+
+	return
+}
+
+// ReadCWA reads all the data blocks of an Axivity .cwa file. The acceleration (g) is stored in the "x", "y" and "z" channels,
+// the raw light sensor value in the "light" channel and the temperature (°C) in the "temperature" channel
+func ReadCWA(r io.Reader, location *time.Location) (recording *Recording, err error) {
+
+	reader, err := NewCWAReader(r, location)
+	if err != nil {
+		return
+	}
+
+	recording = newRecording()
+	for key, value := range reader.Metadata {
+		recording.Metadata[key] = value
+	}
+
+	for {
+		block, nextErr := reader.Next()
+		if nextErr == io.EOF {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		recording.appendBlock(block)
+	}
+
+	return
+}
+
+// ReadCWAFile opens and reads an Axivity .cwa file
+func ReadCWAFile(path string, location *time.Location) (recording *Recording, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	recording, err = ReadCWA(file, location)
+	if err == nil {
+		recording.Metadata["file"] = path
+	}
+	return
+}
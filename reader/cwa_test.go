@@ -0,0 +1,190 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// Creates the header of an Axivity .cwa file (100 Hz)
+func cwaHeader() []byte {
+	header := make([]byte, 1024)
+	copy(header, "MD")
+	binary.LittleEndian.PutUint16(header[2:], 1020)
+	binary.LittleEndian.PutUint16(header[5:], 12345)
+	binary.LittleEndian.PutUint32(header[7:], 42)
+	binary.LittleEndian.PutUint16(header[11:], 0xffff)
+	header[36] = 0x4A
+	return header
+}
+
+// Packs a date and time in the Axivity .cwa format
+func cwaPack(dateTime time.Time) uint32 {
+	return uint32(dateTime.Year()-2000)<<26 | uint32(dateTime.Month())<<22 | uint32(dateTime.Day())<<17 |
+		uint32(dateTime.Hour())<<12 | uint32(dateTime.Minute())<<6 | uint32(dateTime.Second())
+}
+
+// Creates a data block of an Axivity .cwa file (100 Hz). When packed is true, each sample is
+// stored in 4 bytes (exponent 0), otherwise in 6 bytes (16-bit per axis)
+func cwaDataBlock(dateTime time.Time, timestampOffset int, packed bool, samples [][3]int) []byte {
+	block := make([]byte, cwaBlockSize)
+	copy(block, "AX")
+	binary.LittleEndian.PutUint16(block[2:], cwaBlockSize-4)
+	binary.LittleEndian.PutUint32(block[14:], cwaPack(dateTime))
+	binary.LittleEndian.PutUint16(block[18:], 100)
+	binary.LittleEndian.PutUint16(block[20:], 256)
+	block[24] = 0x4A
+	binary.LittleEndian.PutUint16(block[26:], uint16(int16(timestampOffset)))
+	binary.LittleEndian.PutUint16(block[28:], uint16(len(samples)))
+
+	if packed {
+		block[25] = 0x30
+		for index, sample := range samples {
+			value := uint32(sample[0]&0x3ff) | uint32(sample[1]&0x3ff)<<10 | uint32(sample[2]&0x3ff)<<20
+			binary.LittleEndian.PutUint32(block[30+index*4:], value)
+		}
+	} else {
+		block[25] = 0x32
+		for index, sample := range samples {
+			for axis := 0; axis < 3; axis++ {
+				binary.LittleEndian.PutUint16(block[30+index*6+axis*2:], uint16(int16(sample[axis])))
+			}
+		}
+	}
+
+	var sum uint16
+	for position := 0; position < cwaBlockSize-2; position += 2 {
+		sum += binary.LittleEndian.Uint16(block[position:])
+	}
+	binary.LittleEndian.PutUint16(block[cwaBlockSize-2:], -sum)
+	return block
+}
+
+// Creates n identical samples
+func cwaSamples(n int, sample [3]int) [][3]int {
+	samples := make([][3]int, n)
+	for index := range samples {
+		samples[index] = sample
+	}
+	return samples
+}
+
+func TestCWAPacked(t *testing.T) {
+	// Exponent 2 multiplies the values by 4
+	x, y, z := cwaPacked(uint32(100) | uint32(-50&0x3ff)<<10 | uint32(1)<<20 | uint32(2)<<30)
+	if x != 400 || y != -200 || z != 4 {
+		t.Error("Expected: 400 -200 4, Received: ", x, y, z)
+	}
+}
+
+func TestReadCWA(t *testing.T) {
+
+	start := time.Date(2015, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var content []byte
+	content = append(content, cwaHeader()...)
+	content = append(content, cwaDataBlock(start, 0, false, cwaSamples(80, [3]int{256, -128, 0}))...)
+	// The device clock is slightly slower: 81 samples were recorded in the first block
+	content = append(content, cwaDataBlock(start.Add(time.Second), 19, false, cwaSamples(80, [3]int{0, 0, 256}))...)
+	content = append(content, cwaDataBlock(start.Add(2*time.Second), 40, true, cwaSamples(120, [3]int{-256, 128, 511}))...)
+
+	recording, err := ReadCWA(bytes.NewReader(content), nil)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if recording.Metadata["device_id"] != "12345" || recording.Metadata["session_id"] != "42" || recording.Metadata["sample_rate"] != "100" {
+		t.Error("Unexpected metadata: ", recording.Metadata)
+	}
+	if len(recording.DateTime) != 280 {
+		t.Fatal("Expected: 280 samples, Received: ", len(recording.DateTime))
+	}
+
+	// Table tests
+	var tTests = []struct {
+		index    int
+		dateTime time.Time
+		x, y, z  float64
+	}{
+		{0, start, 1, -0.5, 0},
+		{79, start.Add(79 * 10125 * time.Microsecond), 1, -0.5, 0},
+		{80, start.Add(810 * time.Millisecond), 0, 0, 1},
+		{160, start.Add(1600 * time.Millisecond), -1, 0.5, 511.0 / 256.0},
+		{279, start.Add(1600*time.Millisecond + 119*10*time.Millisecond), -1, 0.5, 511.0 / 256.0},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if !recording.DateTime[table.index].Equal(table.dateTime) {
+			t.Error(
+				"Index: ", table.index,
+				"Expected: ", table.dateTime,
+				"Received: ", recording.DateTime[table.index],
+			)
+		}
+		x := recording.Channels["x"][table.index]
+		y := recording.Channels["y"][table.index]
+		z := recording.Channels["z"][table.index]
+		if math.Abs(x-table.x) > 1e-9 || math.Abs(y-table.y) > 1e-9 || math.Abs(z-table.z) > 1e-9 {
+			t.Error(
+				"Index: ", table.index,
+				"Expected: ", table.x, table.y, table.z,
+				"Received: ", x, y, z,
+			)
+		}
+	}
+
+	if recording.Channels["temperature"][0] != 25 || recording.Channels["light"][0] != 100 {
+		t.Error("Unexpected temperature or light: ", recording.Channels["temperature"][0], recording.Channels["light"][0])
+	}
+
+	raw, err := recording.RawAcceleration()
+	if err != nil || raw.SampleRate != 100 {
+		t.Error("Expected a raw acceleration at 100 Hz. Received: ", err)
+	}
+}
+
+func TestCWAReader(t *testing.T) {
+
+	start := time.Date(2015, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	invalid := cwaDataBlock(start.Add(time.Second), 0, false, cwaSamples(80, [3]int{0, 0, 256}))
+	invalid[100]++
+
+	var content []byte
+	content = append(content, cwaHeader()...)
+	content = append(content, cwaDataBlock(start, 0, false, cwaSamples(80, [3]int{0, 0, 256}))...)
+	content = append(content, invalid...)
+
+	reader, err := NewCWAReader(bytes.NewReader(content), nil)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	// The next block is read in advance (to find the sample rate drift)
+	var parseErr *ParseError
+	_, err = reader.Next()
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Error("Expected: ParseError on block 2, Received: ", err)
+	}
+
+	content = append(cwaHeader(), cwaDataBlock(start, 0, false, cwaSamples(80, [3]int{0, 0, 256}))...)
+	reader, _ = NewCWAReader(bytes.NewReader(content), nil)
+
+	block, err := reader.Next()
+	if err != nil || block.Len() != 80 || !block.DateTime[1].Equal(start.Add(10*time.Millisecond)) {
+		t.Error("Unexpected block: ", err)
+	}
+	if _, err = reader.Next(); err != io.EOF {
+		t.Error("Expected: io.EOF, Received: ", err)
+	}
+
+	_, err = NewCWAReader(bytes.NewReader([]byte("AX")), nil)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+}
@@ -0,0 +1,243 @@
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Line that starts each page of the GENEActiv .bin files
+const geneActivPageStart = "Recorded Data"
+
+// Layout of the page time of the GENEActiv .bin files (the last field is the milliseconds)
+const geneActivLayout = "2006-01-02 15:04:05"
+
+// Number of hexadecimal characters of each sample of the GENEActiv .bin files
+const geneActivSampleSize = 12
+
+// GENEActivReader reads the pages of a GENEActiv .bin file one at a time, so the whole file
+// does not need to be stored in memory
+type GENEActivReader struct {
+	// Metadata stores the information found in the file header (e.g. device, subject, calibration)
+	Metadata map[string]string
+	// SampleRate is the measurement frequency (Hz) found in the file header
+	SampleRate float64
+
+	scanner  *bufio.Scanner
+	location *time.Location
+	gain     [3]float64
+	offset   [3]float64
+	volts    float64
+	lux      float64
+	line     int
+	pageRead bool
+}
+
+// Splits a "Key:Value" line of the GENEActiv .bin files
+func splitGENEActiv(text string) (key string, value string, ok bool) {
+	parts := strings.SplitN(text, ":", 2)
+	if len(parts) != 2 {
+		return
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// Parses the frequency of the GENEActiv .bin files (e.g. "100.0 Hz")
+func parseFrequency(value string) (float64, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, ErrMalformed
+	}
+	return parseFloat(fields[0], ',')
+}
+
+// NewGENEActivReader reads the header of a GENEActiv .bin file, including the calibration data used to convert the samples.
+// The timestamps are created in the location passed by parameter or, when nil, in the time zone found in the header (default UTC)
+func NewGENEActivReader(r io.Reader, location *time.Location) (reader *GENEActivReader, err error) {
+
+	reader = &GENEActivReader{
+		Metadata: make(map[string]string),
+		scanner:  bufio.NewScanner(r),
+		location: location,
+	}
+	reader.scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for reader.scanner.Scan() {
+		reader.line++
+		text := strings.TrimSpace(reader.scanner.Text())
+
+		if text == geneActivPageStart {
+			reader.pageRead = true
+			break
+		}
+
+		if key, value, ok := splitGENEActiv(text); ok {
+			reader.Metadata[strings.ToLower(strings.Replace(key, " ", "_", -1))] = value
+		}
+	}
+	if err = reader.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !reader.pageRead {
+		return nil, fmt.Errorf("%w: no pages found", ErrInvalidFormat)
+	}
+
+	// Calibration data
+	for axis, name := range []string{"x", "y", "z"} {
+		if reader.gain[axis], err = strconv.ParseFloat(reader.Metadata[name+"_gain"], 64); err != nil || reader.gain[axis] == 0 {
+			return nil, fmt.Errorf("%w: invalid %s gain", ErrInvalidFormat, name)
+		}
+		if reader.offset[axis], err = strconv.ParseFloat(reader.Metadata[name+"_offset"], 64); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s offset", ErrInvalidFormat, name)
+		}
+	}
+	reader.volts, _ = strconv.ParseFloat(reader.Metadata["volts"], 64)
+	reader.lux, _ = strconv.ParseFloat(reader.Metadata["lux"], 64)
+
+	if reader.SampleRate, err = parseFrequency(reader.Metadata["measurement_frequency"]); err != nil || reader.SampleRate <= 0 {
+		return nil, fmt.Errorf("%w: invalid measurement frequency", ErrInvalidFormat)
+	}
+	reader.Metadata["sample_rate"] = strconv.FormatFloat(reader.SampleRate, 'f', -1, 64)
+
+	if reader.location == nil {
+		reader.location = parseTimeZone(strings.TrimPrefix(reader.Metadata["time_zone"], "GMT"))
+	}
+
+	return reader, nil
+}
+
+// Next returns the samples of the next page, or io.EOF when there are no more pages.
+// The timestamps of each page are based on its own page time, so the sample rate drift does not accumulate
+func (reader *GENEActivReader) Next() (block *RawBlock, err error) {
+
+	// Finds the beginning of the page
+	for !reader.pageRead && reader.scanner.Scan() {
+		reader.line++
+		reader.pageRead = strings.TrimSpace(reader.scanner.Text()) == geneActivPageStart
+	}
+	if err = reader.scanner.Err(); err != nil {
+		return nil, err
+	}
+	if !reader.pageRead {
+		return nil, io.EOF
+	}
+	reader.pageRead = false
+
+	pageLine := reader.line
+	page := make(map[string]string)
+	var data string
+
+	// Page header ("Key:Value" lines) followed by the hexadecimal data
+	for reader.scanner.Scan() {
+		reader.line++
+		text := strings.TrimSpace(reader.scanner.Text())
+		if key, value, ok := splitGENEActiv(text); ok {
+			page[strings.ToLower(key)] = value
+			continue
+		}
+		data = text
+		break
+	}
+	if err = reader.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	pageTime := page["page time"]
+	if index := strings.LastIndex(pageTime, ":"); index > len(geneActivLayout)-3 {
+		pageTime = pageTime[:index] + "." + pageTime[index+1:]
+	}
+	start, parseErr := time.ParseInLocation(geneActivLayout, pageTime, reader.location)
+	if parseErr != nil {
+		return nil, &ParseError{Line: pageLine, Column: -1, Err: fmt.Errorf("%w: invalid page time", ErrMalformed)}
+	}
+
+	sampleRate := reader.SampleRate
+	if frequency, freqErr := parseFrequency(page["measurement frequency"]); freqErr == nil && frequency > 0 {
+		sampleRate = frequency
+	}
+	temperature, _ := parseFloat(page["temperature"], ',')
+
+	if len(data)%geneActivSampleSize != 0 {
+		return nil, &ParseError{Line: reader.line, Column: -1, Err: fmt.Errorf("%w: invalid data length", ErrMalformed)}
+	}
+
+	block = &RawBlock{}
+	for index := 0; index < len(data)/geneActivSampleSize; index++ {
+
+		value, hexErr := strconv.ParseUint(data[index*geneActivSampleSize:(index+1)*geneActivSampleSize], 16, 64)
+		if hexErr != nil {
+			return nil, &ParseError{Line: reader.line, Column: index * geneActivSampleSize, Err: fmt.Errorf("%w: %v", ErrMalformed, hexErr)}
+		}
+
+		// 12-bit signed X, Y and Z, 10-bit light, button and reserved bits
+		var axes [3]float64
+		for axis := 0; axis < 3; axis++ {
+			raw := int((value >> uint(36-12*axis)) & 0xFFF)
+			if raw > 2047 {
+				raw -= 4096
+			}
+			axes[axis] = (float64(raw)*100.0 - reader.offset[axis]) / reader.gain[axis]
+		}
+
+		light := float64((value >> 2) & 0x3FF)
+		if reader.volts > 0 {
+			light = light * reader.lux / reader.volts
+		}
+
+		block.DateTime = append(block.DateTime, start.Add(time.Duration(float64(index)/sampleRate*float64(time.Second))))
+		block.X = append(block.X, axes[0])
+		block.Y = append(block.Y, axes[1])
+		block.Z = append(block.Z, axes[2])
+		block.Light = append(block.Light, light)
+		block.Temperature = append(block.Temperature, temperature)
+	}
+
+	return
+}
+
+// ReadGENEActiv reads all the pages of a GENEActiv .bin file. The acceleration (g) is stored in the "x", "y" and "z"
+// channels, the light (lux) in the "light" channel and the temperature (°C) in the "temperature" channel
+func ReadGENEActiv(r io.Reader, location *time.Location) (recording *Recording, err error) {
+
+	reader, err := NewGENEActivReader(r, location)
+	if err != nil {
+		return
+	}
+
+	recording = newRecording()
+	for key, value := range reader.Metadata {
+		recording.Metadata[key] = value
+	}
+
+	for {
+		block, nextErr := reader.Next()
+		if nextErr == io.EOF {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+		recording.appendBlock(block)
+	}
+
+	return
+}
+
+// ReadGENEActivFile opens and reads a GENEActiv .bin file
+func ReadGENEActivFile(path string, location *time.Location) (recording *Recording, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	recording, err = ReadGENEActiv(file, location)
+	if err == nil {
+		recording.Metadata["file"] = path
+	}
+	return
+}
@@ -0,0 +1,164 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Encodes a GENEActiv sample (12-bit X, Y and Z, 10-bit light and button)
+func geneActivSample(x, y, z, light, button int) string {
+	value := uint64(x&0xFFF)<<36 | uint64(y&0xFFF)<<24 | uint64(z&0xFFF)<<12 | uint64(light&0x3FF)<<2 | uint64(button&1)<<1
+	return fmt.Sprintf("%012X", value)
+}
+
+// Creates a GENEActiv page
+func geneActivPage(sequence int, pageTime string, temperature string, samples ...string) string {
+	return "Recorded Data\n" +
+		"Device Unique Serial Code:012345\n" +
+		fmt.Sprintf("Sequence Number:%d\n", sequence) +
+		"Page Time:" + pageTime + "\n" +
+		"Unassigned:\n" +
+		"Temperature:" + temperature + "\n" +
+		"Battery voltage:4,1\n" +
+		"Device Status:Recording\n" +
+		"Measurement Frequency:2 Hz\n" +
+		strings.Join(samples, "") + "\n"
+}
+
+// Header of the GENEActiv files used by the tests
+const geneActivHeader = "Device Identity\n" +
+	"Device Unique Serial Code:012345\n" +
+	"Device Type:GENEActiv\n" +
+	"Configuration Info\n" +
+	"Measurement Frequency:2 Hz\n" +
+	"Time Zone:GMT -03:00\n" +
+	"Subject Info\n" +
+	"Subject Code:S01\n" +
+	"Calibration Data\n" +
+	"x gain:25600\n" +
+	"x offset:0\n" +
+	"y gain:25600\n" +
+	"y offset:2560\n" +
+	"z gain:12800\n" +
+	"z offset:0\n" +
+	"Volts:300\n" +
+	"Lux:600\n" +
+	"Memory Status\n" +
+	"Number of Pages:2\n"
+
+func TestReadGENEActiv(t *testing.T) {
+
+	content := geneActivHeader +
+		geneActivPage(0, "2015-01-01 10:00:00:000", "25.5",
+			geneActivSample(256, 0, 128, 100, 0),
+			geneActivSample(-256, 256, -128, 50, 1),
+			geneActivSample(0, 0, 0, 0, 0),
+			geneActivSample(2047, -2048, 1, 1023, 0)) +
+		geneActivPage(1, "2015-01-01 10:00:02:010", "26,0",
+			geneActivSample(512, 512, 512, 10, 0))
+
+	recording, err := ReadGENEActiv(strings.NewReader(content), nil)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	if recording.Metadata["subject_code"] != "S01" || recording.Metadata["sample_rate"] != "2" {
+		t.Error("Unexpected metadata: ", recording.Metadata)
+	}
+	if len(recording.DateTime) != 5 {
+		t.Fatal("Expected: 5 samples, Received: ", len(recording.DateTime))
+	}
+
+	location := time.FixedZone("", -3*60*60)
+	expected := []time.Time{
+		time.Date(2015, 1, 1, 10, 0, 0, 0, location),
+		time.Date(2015, 1, 1, 10, 0, 0, 500000000, location),
+		time.Date(2015, 1, 1, 10, 0, 1, 0, location),
+		time.Date(2015, 1, 1, 10, 0, 1, 500000000, location),
+		time.Date(2015, 1, 1, 10, 0, 2, 10000000, location),
+	}
+	for index := range expected {
+		if !recording.DateTime[index].Equal(expected[index]) {
+			t.Error("Expected: ", expected[index], "Received: ", recording.DateTime[index])
+		}
+	}
+
+	// Table tests
+	var tTests = []struct {
+		channel string
+		values  []float64
+	}{
+		{"x", []float64{1, -1, 0, 2047.0 / 256.0, 2}},
+		{"y", []float64{-0.1, 0.9, -0.1, -8.1, 1.9}},
+		{"z", []float64{1, -1, 0, 1.0 / 128.0, 4}},
+		{"light", []float64{200, 100, 0, 2046, 20}},
+		{"temperature", []float64{25.5, 25.5, 25.5, 25.5, 26}},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		values := recording.Channels[table.channel]
+		for index := range table.values {
+			if math.Abs(values[index]-table.values[index]) > 1e-9 {
+				t.Error(
+					"Channel: ", table.channel,
+					"Expected: ", table.values,
+					"Received: ", values,
+				)
+				break
+			}
+		}
+	}
+
+	if _, err = recording.RawAcceleration(); err != nil {
+		t.Error("Expected error = nil. Received: ", err)
+	}
+}
+
+func TestGENEActivReader(t *testing.T) {
+
+	content := geneActivHeader +
+		geneActivPage(0, "2015-01-01 10:00:00:000", "25", geneActivSample(256, 0, 0, 0, 0)) +
+		geneActivPage(1, "invalid", "25", geneActivSample(256, 0, 0, 0, 0))
+
+	reader, err := NewGENEActivReader(strings.NewReader(content), time.UTC)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if reader.SampleRate != 2 {
+		t.Error("Expected: 2 Hz, Received: ", reader.SampleRate)
+	}
+
+	block, err := reader.Next()
+	if err != nil || block.Len() != 1 || block.DateTime[0].Location() != time.UTC {
+		t.Error("Unexpected first page: ", block, err)
+	}
+
+	var parseErr *ParseError
+	_, err = reader.Next()
+	if !errors.As(err, &parseErr) || parseErr.Line != 30 {
+		t.Error("Expected: ParseError on line 30, Received: ", err)
+	}
+
+	_, err = reader.Next()
+	if err != io.EOF {
+		t.Error("Expected: io.EOF, Received: ", err)
+	}
+
+	// Without calibration data
+	_, err = NewGENEActivReader(strings.NewReader("Device Type:GENEActiv\nRecorded Data\n"), nil)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+
+	// Without pages
+	_, err = NewGENEActivReader(strings.NewReader(geneActivHeader), nil)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+}
@@ -0,0 +1,304 @@
+package reader
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Record types of the GT3X log.bin file used by the reader
+const (
+	gt3xActivity  = 0x00
+	gt3xLux       = 0x05
+	gt3xActivity2 = 0x1A
+)
+
+// Separator found at the beginning of each GT3X record
+const gt3xSeparator = 0x1E
+
+// Default acceleration scale (counts per g) of the devices that do not report it
+const gt3xDefaultScale = 341.0
+
+// Parses the time zone of the GT3X info.txt file (e.g. "-03:00:00")
+func parseTimeZone(value string) *time.Location {
+	name := strings.TrimSpace(value)
+	if name == "" {
+		return time.UTC
+	}
+
+	sign := 1
+	if strings.HasPrefix(name, "-") {
+		sign = -1
+	}
+
+	seconds := 0
+	multipliers := []int{3600, 60, 1}
+	for index, part := range strings.Split(strings.TrimLeft(name, "+-"), ":") {
+		if index >= len(multipliers) {
+			break
+		}
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return time.UTC
+		}
+		seconds += number * multipliers[index]
+	}
+
+	return time.FixedZone(name, sign*seconds)
+}
+
+// Reads the "Key: Value" lines of the GT3X info.txt file
+func readInfo(r io.Reader) (info map[string]string, err error) {
+	info = make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) == 2 {
+			info[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// Decodes the 12-bit packed samples of the ACTIVITY records (Y, X and Z order)
+func decodeActivity(payload []byte) (samples [][3]int) {
+	values := len(payload) * 8 / 12
+	count := values / 3
+
+	for sample := 0; sample < count; sample++ {
+		var axes [3]int
+		for axis := 0; axis < 3; axis++ {
+			bitOffset := (sample*3 + axis) * 12
+			position := bitOffset / 8
+
+			var value int
+			if bitOffset%8 == 0 {
+				value = int(payload[position])<<4 | int(payload[position+1])>>4
+			} else {
+				value = int(payload[position]&0x0F)<<8 | int(payload[position+1])
+			}
+			// 12-bit two's complement
+			if value > 2047 {
+				value -= 4096
+			}
+			axes[axis] = value
+		}
+		// Reorder from Y, X, Z to X, Y, Z
+		samples = append(samples, [3]int{axes[1], axes[0], axes[2]})
+	}
+	return
+}
+
+// Decodes the little-endian 16-bit samples of the ACTIVITY2 records (X, Y and Z order)
+func decodeActivity2(payload []byte) (samples [][3]int) {
+	for position := 0; position+6 <= len(payload); position += 6 {
+		samples = append(samples, [3]int{
+			int(int16(binary.LittleEndian.Uint16(payload[position:]))),
+			int(int16(binary.LittleEndian.Uint16(payload[position+2:]))),
+			int(int16(binary.LittleEndian.Uint16(payload[position+4:]))),
+		})
+	}
+	return
+}
+
+// ReadGT3X reads an ActiGraph .gt3x file (a zip file with the info.txt and log.bin files), which must have the size passed by parameter.
+// The raw acceleration (in g) is stored in the "x", "y" and "z" channels and the light in the "lux" channel, one record per sample.
+// The light is multiplied by the "Lux Scale Factor" and limited to the "Lux Max Value" of the info.txt file.
+// The seconds in which the device was in idle sleep mode are filled with the last sample, as recommended by ActiGraph
+func ReadGT3X(r io.ReaderAt, size int64) (recording *Recording, err error) {
+
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+
+	var infoFile, logFile *zip.File
+	for _, file := range archive.File {
+		switch strings.ToLower(file.Name) {
+		case "info.txt":
+			infoFile = file
+		case "log.bin":
+			logFile = file
+		}
+	}
+	if infoFile == nil || logFile == nil {
+		return nil, fmt.Errorf("%w: info.txt or log.bin not found", ErrInvalidFormat)
+	}
+
+	infoReader, err := infoFile.Open()
+	if err != nil {
+		return
+	}
+	info, err := readInfo(infoReader)
+	infoReader.Close()
+	if err != nil {
+		return
+	}
+
+	sampleRate, err := strconv.Atoi(info["Sample Rate"])
+	if err != nil || sampleRate <= 0 {
+		return nil, fmt.Errorf("%w: invalid sample rate", ErrInvalidFormat)
+	}
+
+	scale := gt3xDefaultScale
+	if value, parseErr := strconv.ParseFloat(info["Acceleration Scale"], 64); parseErr == nil && value > 0 {
+		scale = value
+	}
+
+	// The light is converted to lux using the scale factor and limited to the maximum value (when reported)
+	luxScale := 1.0
+	if value, parseErr := strconv.ParseFloat(info["Lux Scale Factor"], 64); parseErr == nil && value > 0 {
+		luxScale = value
+	}
+	luxMax := math.Inf(1)
+	if value, parseErr := strconv.ParseFloat(info["Lux Max Value"], 64); parseErr == nil && value > 0 {
+		luxMax = value
+	}
+
+	location := parseTimeZone(info["TimeZone"])
+
+	recording = newRecording()
+	for key, value := range info {
+		recording.Metadata[strings.ToLower(strings.Replace(key, " ", "_", -1))] = value
+	}
+
+	logReader, err := logFile.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer logReader.Close()
+
+	buffered := bufio.NewReader(logReader)
+	sampleInterval := time.Second / time.Duration(sampleRate)
+
+	var lastSample [3]int
+	var lastSecond int64
+	var lux float64
+	started := false
+
+	appendSample := func(dateTime time.Time, sample [3]int) {
+		recording.DateTime = append(recording.DateTime, dateTime)
+		recording.Channels["x"] = append(recording.Channels["x"], float64(sample[0])/scale)
+		recording.Channels["y"] = append(recording.Channels["y"], float64(sample[1])/scale)
+		recording.Channels["z"] = append(recording.Channels["z"], float64(sample[2])/scale)
+		recording.Channels["lux"] = append(recording.Channels["lux"], lux)
+	}
+
+	// Repeats the last sample during the seconds passed by parameter (idle sleep mode)
+	fillSeconds := func(from int64, to int64) {
+		for second := from; second < to; second++ {
+			start := wallClock(time.Unix(second, 0).UTC(), location)
+			for index := 0; index < sampleRate; index++ {
+				appendSample(start.Add(time.Duration(index)*sampleInterval), lastSample)
+			}
+		}
+	}
+
+	header := make([]byte, 8)
+	for number := 1; ; number++ {
+
+		if _, readErr := io.ReadFull(buffered, header); readErr != nil {
+			if readErr == io.EOF {
+				break
+			}
+			return nil, &ParseError{Line: number, Column: -1, Err: readErr}
+		}
+		if header[0] != gt3xSeparator {
+			return nil, &ParseError{Line: number, Column: -1, Err: ErrMalformed}
+		}
+
+		recordType := header[1]
+		timestamp := int64(binary.LittleEndian.Uint32(header[2:]))
+		payload := make([]byte, int(binary.LittleEndian.Uint16(header[6:]))+1)
+
+		if _, readErr := io.ReadFull(buffered, payload); readErr != nil {
+			return nil, &ParseError{Line: number, Column: -1, Err: readErr}
+		}
+
+		// The checksum is the 1's complement of the XOR of the header and payload bytes
+		checksum := payload[len(payload)-1]
+		payload = payload[:len(payload)-1]
+
+		var sum byte
+		for _, b := range header {
+			sum ^= b
+		}
+		for _, b := range payload {
+			sum ^= b
+		}
+		if ^sum != checksum {
+			return nil, &ParseError{Line: number, Column: -1, Err: fmt.Errorf("%w: invalid checksum", ErrMalformed)}
+		}
+
+		var samples [][3]int
+		switch recordType {
+		case gt3xLux:
+			if len(payload) >= 2 {
+				lux = math.Min(float64(binary.LittleEndian.Uint16(payload))*luxScale, luxMax)
+			}
+			continue
+		case gt3xActivity:
+			samples = decodeActivity(payload)
+		case gt3xActivity2:
+			samples = decodeActivity2(payload)
+		default:
+			continue
+		}
+
+		// Seconds without records between two activity records (idle sleep mode)
+		if started && timestamp > lastSecond+1 {
+			fillSeconds(lastSecond+1, timestamp)
+		}
+
+		// Empty payload (idle sleep mode)
+		if len(samples) == 0 {
+			if started {
+				fillSeconds(timestamp, timestamp+1)
+				lastSecond = timestamp
+			}
+			continue
+		}
+
+		start := wallClock(time.Unix(timestamp, 0).UTC(), location)
+		for index := 0; index < sampleRate; index++ {
+			// Incomplete records are completed with the last sample
+			if index < len(samples) {
+				lastSample = samples[index]
+			}
+			appendSample(start.Add(time.Duration(index)*sampleInterval), lastSample)
+		}
+
+		lastSecond = timestamp
+		started = true
+	}
+
+	return
+}
+
+// ReadGT3XFile opens and reads an ActiGraph .gt3x file
+func ReadGT3XFile(path string) (recording *Recording, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return
+	}
+
+	recording, err = ReadGT3X(file, info.Size())
+	if err == nil {
+		recording.Metadata["file"] = path
+	}
+	return
+}
@@ -0,0 +1,237 @@
+package reader
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Encodes a GT3X log.bin record
+func gt3xRecord(recordType byte, timestamp uint32, payload []byte) []byte {
+	record := []byte{gt3xSeparator, recordType, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(record[2:], timestamp)
+	binary.LittleEndian.PutUint16(record[6:], uint16(len(payload)))
+	record = append(record, payload...)
+
+	var sum byte
+	for _, b := range record {
+		sum ^= b
+	}
+	return append(record, ^sum)
+}
+
+// Encodes samples in the ACTIVITY2 format (little-endian 16-bit X, Y and Z)
+func activity2Payload(samples [][3]int) []byte {
+	var payload []byte
+	for _, sample := range samples {
+		for _, value := range sample {
+			payload = append(payload, byte(uint16(int16(value))), byte(uint16(int16(value))>>8))
+		}
+	}
+	return payload
+}
+
+// Encodes samples in the ACTIVITY format (12-bit packed Y, X and Z)
+func activityPayload(samples [][3]int) []byte {
+	var bits []int
+	for _, sample := range samples {
+		for _, value := range []int{sample[1], sample[0], sample[2]} {
+			unsigned := value & 0xFFF
+			for bit := 11; bit >= 0; bit-- {
+				bits = append(bits, (unsigned>>uint(bit))&1)
+			}
+		}
+	}
+	payload := make([]byte, (len(bits)+7)/8)
+	for index, bit := range bits {
+		payload[index/8] |= byte(bit << uint(7-index%8))
+	}
+	return payload
+}
+
+// Creates a GT3X file in memory
+func gt3xFile(t *testing.T, info string, log []byte) []byte {
+	var buffer bytes.Buffer
+	archive := zip.NewWriter(&buffer)
+
+	for name, content := range map[string][]byte{"info.txt": []byte(info), "log.bin": log} {
+		writer, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writer.Write(content)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func TestReadGT3X(t *testing.T) {
+
+	info := "Serial Number: MOS2A12345678\r\nSample Rate: 4\r\nAcceleration Scale: 256.0\r\nTimeZone: -03:00:00\r\n"
+
+	// 2015-01-01 10:00:00 (device wall clock)
+	start := uint32(time.Date(2015, 1, 1, 10, 0, 0, 0, time.UTC).Unix())
+
+	var log []byte
+	log = append(log, gt3xRecord(gt3xLux, start, []byte{100, 0})...)
+	log = append(log, gt3xRecord(gt3xActivity2, start, activity2Payload([][3]int{{256, 0, -256}, {128, 64, -128}, {0, 0, 256}, {-256, 512, 0}}))...)
+	log = append(log, gt3xRecord(gt3xActivity, start+1, activityPayload([][3]int{{256, -256, 0}, {-1, 2047, -2048}, {10, 20, 30}, {0, 0, 256}}))...)
+	// Idle sleep mode: empty record and a missing second
+	log = append(log, gt3xRecord(gt3xActivity2, start+2, nil)...)
+	log = append(log, gt3xRecord(gt3xActivity2, start+4, activity2Payload([][3]int{{512, 512, 512}}))...)
+	// Ignored record type (battery)
+	log = append(log, gt3xRecord(0x02, start+4, []byte{1, 2})...)
+
+	content := gt3xFile(t, info, log)
+
+	recording, err := ReadGT3X(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	// 5 seconds with 4 samples per second
+	if len(recording.DateTime) != 20 {
+		t.Fatal("Expected: 20 samples, Received: ", len(recording.DateTime))
+	}
+	if recording.Metadata["serial_number"] != "MOS2A12345678" {
+		t.Error("Unexpected metadata: ", recording.Metadata)
+	}
+
+	location := recording.DateTime[0].Location()
+	if _, offset := recording.DateTime[0].Zone(); offset != -3*60*60 {
+		t.Error("Expected: -03:00, Received: ", location)
+	}
+	if recording.DateTime[0].Hour() != 10 || recording.DateTime[1].Sub(recording.DateTime[0]) != 250*time.Millisecond {
+		t.Error("Unexpected timestamps: ", recording.DateTime[0], recording.DateTime[1])
+	}
+
+	x := recording.Channels["x"]
+	y := recording.Channels["y"]
+	z := recording.Channels["z"]
+
+	// Table tests
+	var tTests = []struct {
+		index   int
+		x, y, z float64
+	}{
+		{0, 1.0, 0.0, -1.0},
+		{3, -1.0, 2.0, 0.0},
+		{4, 1.0, -1.0, 0.0},
+		{5, -1.0 / 256.0, 2047.0 / 256.0, -2048.0 / 256.0},
+		{7, 0.0, 0.0, 1.0},
+		{8, 0.0, 0.0, 1.0},
+		{15, 0.0, 0.0, 1.0},
+		{16, 2.0, 2.0, 2.0},
+		{19, 2.0, 2.0, 2.0},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if math.Abs(x[table.index]-table.x) > 1e-9 || math.Abs(y[table.index]-table.y) > 1e-9 || math.Abs(z[table.index]-table.z) > 1e-9 {
+			t.Error(
+				"Index: ", table.index,
+				"Expected: ", table.x, table.y, table.z,
+				"Received: ", x[table.index], y[table.index], z[table.index],
+			)
+		}
+	}
+
+	if recording.Channels["lux"][0] != 100 {
+		t.Error("Expected: 100, Received: ", recording.Channels["lux"][0])
+	}
+
+	// The light is multiplied by the scale factor and limited to the maximum value
+	scaled := info + "Lux Scale Factor: 1.25\r\nLux Max Value: 2500\r\n"
+	luxLog := append(gt3xRecord(gt3xLux, start, []byte{100, 0}), gt3xRecord(gt3xActivity2, start, activity2Payload([][3]int{{0, 0, 256}}))...)
+	luxLog = append(luxLog, gt3xRecord(gt3xLux, start+1, []byte{0x10, 0x27})...)
+	luxLog = append(luxLog, gt3xRecord(gt3xActivity2, start+1, activity2Payload([][3]int{{0, 0, 256}}))...)
+	content = gt3xFile(t, scaled, luxLog)
+	luxRecording, err := ReadGT3X(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if lux := luxRecording.Channels["lux"]; math.Abs(lux[0]-125.0) > 1e-9 || lux[4] != 2500 {
+		t.Error("Expected: 125 and 2500 (10000 * 1.25 limited), Received: ", lux[0], lux[4])
+	}
+
+	raw, err := recording.RawAcceleration()
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if raw.SampleRate != 4 || raw.Len() != 20 {
+		t.Error("Unexpected raw acceleration: ", raw.SampleRate, raw.Len())
+	}
+
+	delete(recording.Metadata, "sample_rate")
+	if _, err = recording.RawAcceleration(); !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+}
+
+func TestReadGT3XInvalid(t *testing.T) {
+
+	info := "Sample Rate: 1\n"
+	start := uint32(time.Date(2015, 1, 1, 10, 0, 0, 0, time.UTC).Unix())
+
+	// Invalid checksum on the second record
+	log := gt3xRecord(gt3xActivity2, start, activity2Payload([][3]int{{1, 2, 3}}))
+	invalid := gt3xRecord(gt3xActivity2, start+1, activity2Payload([][3]int{{1, 2, 3}}))
+	invalid[len(invalid)-1]++
+	log = append(log, invalid...)
+
+	content := gt3xFile(t, info, log)
+
+	_, err := ReadGT3X(bytes.NewReader(content), int64(len(content)))
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 || !errors.Is(err, ErrMalformed) {
+		t.Error("Expected: ParseError on record 2, Received: ", err)
+	}
+
+	// Missing sample rate
+	content = gt3xFile(t, "Serial Number: 1\n", nil)
+	_, err = ReadGT3X(bytes.NewReader(content), int64(len(content)))
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+
+	// Not a zip file
+	_, err = ReadGT3X(bytes.NewReader([]byte("abc")), 3)
+	if !errors.Is(err, ErrInvalidFormat) {
+		t.Error("Expected: ErrInvalidFormat, Received: ", err)
+	}
+}
+
+func TestReadGT3XFile(t *testing.T) {
+
+	start := uint32(time.Date(2015, 1, 1, 10, 0, 0, 0, time.UTC).Unix())
+	content := gt3xFile(t, "Sample Rate: 1\n", gt3xRecord(gt3xActivity2, start, activity2Payload([][3]int{{341, 0, 0}})))
+
+	dir, err := ioutil.TempDir("", "chronobiology")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "sample.gt3x")
+	if err = ioutil.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	recording, err := ReadGT3XFile(path)
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if recording.Metadata["file"] != path || recording.Channels["x"][0] != 1.0 {
+		t.Error("Unexpected recording: ", recording.Metadata, recording.Channels)
+	}
+}
@@ -0,0 +1,411 @@
+// Package sqlite implements a minimal read-only SQLite reader, able to read
+// all the rows of a table (used to read the ActiGraph .agd files without cgo)
+package sqlite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Errors returned by the reader
+var (
+	// ErrInvalidDatabase is returned when the file is not a valid SQLite database
+	ErrInvalidDatabase = errors.New("sqlite: invalid database")
+	// ErrTableNotFound is returned when the table does not exist
+	ErrTableNotFound = errors.New("sqlite: table not found")
+)
+
+const headerMagic = "SQLite format 3\x00"
+
+// Page types of the table b-trees
+const (
+	interiorTablePage = 0x05
+	leafTablePage     = 0x0d
+)
+
+// Database is a read-only SQLite database
+type Database struct {
+	r          io.ReaderAt
+	pageSize   int
+	usableSize int
+	pageCount  int
+}
+
+// Table stores the columns and the rows of a table
+type Table struct {
+	// Columns are the column names in the order they were declared
+	Columns []string
+	// Rows are the values of each row (int64, float64, string, []byte or nil)
+	Rows [][]interface{}
+}
+
+// Open opens the database stored in r, which must have the size passed by parameter
+func Open(r io.ReaderAt, size int64) (db *Database, err error) {
+	header := make([]byte, 100)
+	if _, err = r.ReadAt(header, 0); err != nil {
+		return nil, ErrInvalidDatabase
+	}
+	if string(header[:16]) != headerMagic {
+		return nil, ErrInvalidDatabase
+	}
+	if encoding := binary.BigEndian.Uint32(header[56:]); encoding != 0 && encoding != 1 {
+		return nil, fmt.Errorf("%w: only UTF-8 databases are supported", ErrInvalidDatabase)
+	}
+
+	pageSize := int(binary.BigEndian.Uint16(header[16:]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 {
+		return nil, ErrInvalidDatabase
+	}
+
+	// The usable size must be at least 480 bytes (SQLite file format)
+	usableSize := pageSize - int(header[20])
+	if usableSize < 480 {
+		return nil, fmt.Errorf("%w: invalid reserved space", ErrInvalidDatabase)
+	}
+
+	db = &Database{
+		r:          r,
+		pageSize:   pageSize,
+		usableSize: usableSize,
+		pageCount:  int(size / int64(pageSize)),
+	}
+	return
+}
+
+// Reads the page passed by parameter (starting at 1)
+func (db *Database) page(number int) ([]byte, error) {
+	if number < 1 || number > db.pageCount {
+		return nil, fmt.Errorf("%w: page %d out of range", ErrInvalidDatabase, number)
+	}
+	page := make([]byte, db.pageSize)
+	if _, err := db.r.ReadAt(page, int64(number-1)*int64(db.pageSize)); err != nil {
+		return nil, fmt.Errorf("%w: page %d could not be read: %v", ErrInvalidDatabase, number, err)
+	}
+	return page, nil
+}
+
+// Reads a variable-length integer, returning the value and the number of bytes read
+func varint(data []byte) (value int64, size int) {
+	var result uint64
+	for index := 0; index < 9 && index < len(data); index++ {
+		if index == 8 {
+			result = (result << 8) | uint64(data[index])
+			return int64(result), 9
+		}
+		result = (result << 7) | uint64(data[index]&0x7f)
+		if data[index]&0x80 == 0 {
+			return int64(result), index + 1
+		}
+	}
+	return int64(result), len(data)
+}
+
+// Reads all the records of a table b-tree, starting at its root page
+func (db *Database) walk(root int, visit func(rowid int64, payload []byte) error) error {
+	return db.walkPage(root, make(map[int]bool), visit)
+}
+
+// Reads all the records of the b-tree page and its children. The visited pages are stored,
+// so a corrupt file with cyclic page pointers returns an error
+func (db *Database) walkPage(number int, visited map[int]bool, visit func(rowid int64, payload []byte) error) error {
+
+	if visited[number] {
+		return fmt.Errorf("%w: page %d is referenced twice", ErrInvalidDatabase, number)
+	}
+	visited[number] = true
+
+	page, err := db.page(number)
+	if err != nil {
+		return err
+	}
+
+	// The first page has the database header before the b-tree page header
+	offset := 0
+	if number == 1 {
+		offset = 100
+	}
+
+	pageType := page[offset]
+	headerSize := 8
+	if pageType == interiorTablePage {
+		headerSize = 12
+	} else if pageType != leafTablePage {
+		return fmt.Errorf("%w: page %d is not a table page", ErrInvalidDatabase, number)
+	}
+
+	// The cell pointer array follows the page header
+	cells := int(binary.BigEndian.Uint16(page[offset+3:]))
+	cellsStart := offset + headerSize
+	if cellsStart+2*cells > db.usableSize {
+		return fmt.Errorf("%w: invalid number of cells on page %d", ErrInvalidDatabase, number)
+	}
+
+	for cell := 0; cell < cells; cell++ {
+		pointer := int(binary.BigEndian.Uint16(page[cellsStart+2*cell:]))
+		if pointer < cellsStart+2*cells || pointer >= db.usableSize {
+			return fmt.Errorf("%w: invalid cell pointer on page %d", ErrInvalidDatabase, number)
+		}
+
+		if pageType == interiorTablePage {
+			if pointer+4 > db.usableSize {
+				return fmt.Errorf("%w: invalid cell pointer on page %d", ErrInvalidDatabase, number)
+			}
+			child := int(binary.BigEndian.Uint32(page[pointer:]))
+			if err = db.walkPage(child, visited, visit); err != nil {
+				return err
+			}
+			continue
+		}
+
+		payloadSize, n := varint(page[pointer:db.usableSize])
+		pointer += n
+		rowid, n := varint(page[pointer:db.usableSize])
+		pointer += n
+		if pointer >= db.usableSize || payloadSize < 0 || payloadSize > int64(db.pageCount)*int64(db.usableSize) {
+			return fmt.Errorf("%w: invalid cell on page %d", ErrInvalidDatabase, number)
+		}
+
+		payload, err := db.payload(page, pointer, int(payloadSize))
+		if err != nil {
+			return err
+		}
+		if err = visit(rowid, payload); err != nil {
+			return err
+		}
+	}
+
+	if pageType == interiorTablePage {
+		return db.walkPage(int(binary.BigEndian.Uint32(page[offset+8:])), visited, visit)
+	}
+	return nil
+}
+
+// Reads the payload of a cell, following the overflow pages when needed
+func (db *Database) payload(page []byte, pointer int, size int) ([]byte, error) {
+
+	maxLocal := db.usableSize - 35
+	local := size
+
+	if size > maxLocal {
+		minLocal := ((db.usableSize-12)*32)/255 - 23
+		local = minLocal + (size-minLocal)%(db.usableSize-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+
+	if pointer+local > db.usableSize || (local < size && pointer+local+4 > db.usableSize) {
+		return nil, fmt.Errorf("%w: invalid cell payload", ErrInvalidDatabase)
+	}
+
+	payload := make([]byte, 0, size)
+	payload = append(payload, page[pointer:pointer+local]...)
+
+	if local == size {
+		return payload, nil
+	}
+
+	// The overflow pages are a linked list, so a visited page means a cycle
+	visited := make(map[int]bool)
+	overflow := int(binary.BigEndian.Uint32(page[pointer+local:]))
+	for len(payload) < size {
+		if visited[overflow] {
+			return nil, fmt.Errorf("%w: overflow page %d is referenced twice", ErrInvalidDatabase, overflow)
+		}
+		visited[overflow] = true
+		overflowPage, err := db.page(overflow)
+		if err != nil {
+			return nil, err
+		}
+		length := size - len(payload)
+		if length > db.usableSize-4 {
+			length = db.usableSize - 4
+		}
+		payload = append(payload, overflowPage[4:4+length]...)
+		overflow = int(binary.BigEndian.Uint32(overflowPage))
+	}
+
+	return payload, nil
+}
+
+// Decodes a record into its values
+func record(payload []byte) (values []interface{}, err error) {
+
+	headerSize, n := varint(payload)
+	if headerSize < int64(n) || headerSize > int64(len(payload)) {
+		return nil, fmt.Errorf("%w: invalid record header", ErrInvalidDatabase)
+	}
+
+	var serialTypes []int64
+	for position := n; position < int(headerSize); {
+		serialType, size := varint(payload[position:])
+		serialTypes = append(serialTypes, serialType)
+		position += size
+	}
+
+	body := payload[headerSize:]
+
+	for _, serialType := range serialTypes {
+
+		var size int
+		switch {
+		case serialType >= 12 && serialType%2 == 0:
+			size = int(serialType-12) / 2
+		case serialType >= 13:
+			size = int(serialType-13) / 2
+		case serialType >= 1 && serialType <= 4:
+			size = int(serialType)
+		case serialType == 5:
+			size = 6
+		case serialType == 6 || serialType == 7:
+			size = 8
+		}
+
+		if size < 0 || size > len(body) {
+			return nil, fmt.Errorf("%w: invalid record body", ErrInvalidDatabase)
+		}
+		content := body[:size]
+		body = body[size:]
+
+		switch {
+		case serialType == 0:
+			values = append(values, nil)
+		case serialType >= 1 && serialType <= 6:
+			// Big-endian two's complement integer
+			var value int64
+			for _, b := range content {
+				value = (value << 8) | int64(b)
+			}
+			shift := uint(64 - 8*size)
+			values = append(values, (value<<shift)>>shift)
+		case serialType == 7:
+			values = append(values, math.Float64frombits(binary.BigEndian.Uint64(content)))
+		case serialType == 8:
+			values = append(values, int64(0))
+		case serialType == 9:
+			values = append(values, int64(1))
+		case serialType >= 12 && serialType%2 == 0:
+			values = append(values, content)
+		case serialType >= 13:
+			values = append(values, string(content))
+		default:
+			return nil, fmt.Errorf("%w: invalid serial type %d", ErrInvalidDatabase, serialType)
+		}
+	}
+
+	return
+}
+
+// Splits the column definitions of a CREATE TABLE statement, returning the column names
+// and the index of the INTEGER PRIMARY KEY column (an alias to the rowid), -1 when not found
+func columns(sql string) (names []string, rowidColumn int) {
+
+	rowidColumn = -1
+
+	start := strings.Index(sql, "(")
+	end := strings.LastIndex(sql, ")")
+	if start < 0 || end <= start {
+		return
+	}
+
+	var definitions []string
+	depth := 0
+	last := start + 1
+	for index := start + 1; index < end; index++ {
+		switch sql[index] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				definitions = append(definitions, sql[last:index])
+				last = index + 1
+			}
+		}
+	}
+	definitions = append(definitions, sql[last:end])
+
+	for _, definition := range definitions {
+		fields := strings.Fields(definition)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY", "UNIQUE", "CHECK", "FOREIGN", "CONSTRAINT":
+			continue
+		}
+		if strings.Contains(strings.ToUpper(strings.Join(fields, " ")), "INTEGER PRIMARY KEY") {
+			rowidColumn = len(names)
+		}
+		names = append(names, strings.Trim(fields[0], "\"'`[]"))
+	}
+
+	return
+}
+
+// ReadTable reads all the rows of the table passed by parameter
+func (db *Database) ReadTable(name string) (table *Table, err error) {
+
+	root := 0
+	var sql string
+
+	// The schema table is always stored on the first page
+	err = db.walk(1, func(rowid int64, payload []byte) error {
+		values, err := record(payload)
+		if err != nil {
+			return err
+		}
+		if len(values) < 5 {
+			return nil
+		}
+		if kind, _ := values[0].(string); kind != "table" {
+			return nil
+		}
+		if tableName, _ := values[1].(string); !strings.EqualFold(tableName, name) {
+			return nil
+		}
+		page, _ := values[3].(int64)
+		root = int(page)
+		sql, _ = values[4].(string)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	if root == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrTableNotFound, name)
+	}
+
+	table = &Table{}
+	var rowidColumn int
+	table.Columns, rowidColumn = columns(sql)
+
+	err = db.walk(root, func(rowid int64, payload []byte) error {
+		values, err := record(payload)
+		if err != nil {
+			return err
+		}
+		// Columns added by ALTER TABLE may be missing in old records
+		for len(values) < len(table.Columns) {
+			values = append(values, nil)
+		}
+		if rowidColumn >= 0 && values[rowidColumn] == nil {
+			values[rowidColumn] = rowid
+		}
+		table.Rows = append(table.Rows, values)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return
+}
@@ -0,0 +1,180 @@
+package sqlite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestVarint(t *testing.T) {
+
+	// Table tests
+	var tTests = []struct {
+		data  []byte
+		value int64
+		size  int
+	}{
+		{[]byte{0x00}, 0, 1},
+		{[]byte{0x7f}, 127, 1},
+		{[]byte{0x81, 0x00}, 128, 2},
+		{[]byte{0x82, 0x80, 0x01}, 32769, 3},
+		{[]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, -1, 9},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		value, size := varint(table.data)
+		if value != table.value || size != table.size {
+			t.Error(
+				"Expected: ", table.value, table.size,
+				"Received: ", value, size,
+			)
+		}
+	}
+}
+
+func TestColumns(t *testing.T) {
+	names, rowidColumn := columns(`CREATE TABLE data (dataTimestamp INTEGER PRIMARY KEY NOT NULL, "axis1" INTEGER, value DECIMAL(10, 2), PRIMARY KEY (axis1))`)
+
+	if strings.Join(names, ",") != "dataTimestamp,axis1,value" {
+		t.Error("Expected: dataTimestamp,axis1,value, Received: ", names)
+	}
+	if rowidColumn != 0 {
+		t.Error("Expected: 0, Received: ", rowidColumn)
+	}
+}
+
+func TestReadTable(t *testing.T) {
+
+	// Synthetic file generated by ../../testdata/generate_agd.py (512 bytes pages)
+	content, err := ioutil.ReadFile("../../testdata/sample.agd")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := Open(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	data, err := db.ReadTable("data")
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+	if len(data.Rows) != 300 || len(data.Columns) != 10 {
+		t.Fatal("Expected: 300 rows and 10 columns, Received: ", len(data.Rows), len(data.Columns))
+	}
+
+	// The rows must be read in order (several pages) and the rowid must be used as dataTimestamp
+	for index, row := range data.Rows {
+		if row[1].(int64) != int64((index*37)%500) {
+			t.Fatal("Unexpected axis1 value at row ", index, ": ", row[1])
+		}
+		if index > 0 && row[0].(int64)-data.Rows[index-1][0].(int64) != 600000000 {
+			t.Fatal("Unexpected timestamp at row ", index, ": ", row[0])
+		}
+	}
+
+	settings, err := db.ReadTable("settings")
+	if err != nil {
+		t.Fatal("Expected error = nil. Received: ", err)
+	}
+
+	// The notes setting is stored in overflow pages
+	found := false
+	for _, row := range settings.Rows {
+		if row[1] == "notes" {
+			found = row[2] == strings.Repeat("x", 1500)
+		}
+	}
+	if !found {
+		t.Error("Expected the notes setting with 1500 characters")
+	}
+
+	_, err = db.ReadTable("missing")
+	if !errors.Is(err, ErrTableNotFound) {
+		t.Error("Expected: ErrTableNotFound, Received: ", err)
+	}
+
+	_, err = Open(bytes.NewReader([]byte(strings.Repeat("a", 200))), 200)
+	if !errors.Is(err, ErrInvalidDatabase) {
+		t.Error("Expected: ErrInvalidDatabase, Received: ", err)
+	}
+}
+
+// Reads the tables of the corrupt database, returning the first error
+func readCorrupt(content []byte) error {
+	db, err := Open(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return err
+	}
+	if _, err = db.ReadTable("settings"); err != nil {
+		return err
+	}
+	_, err = db.ReadTable("data")
+	return err
+}
+
+func TestReadCorruptTable(t *testing.T) {
+
+	content, err := ioutil.ReadFile("../../testdata/sample.agd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pageSize := 512
+
+	// Truncated files (the reader must not panic)
+	for size := 0; size < len(content); size += 100 {
+		err := readCorrupt(content[:size])
+		if !errors.Is(err, ErrInvalidDatabase) && !errors.Is(err, ErrTableNotFound) {
+			t.Error("Size: ", size, "Expected: ErrInvalidDatabase, Received: ", err)
+		}
+	}
+
+	// The headers and cell pointers of every page changed to 0x00 and 0xff (the reader must not panic or loop forever)
+	corrupt := make([]byte, len(content))
+	for index := range content {
+		if index%pageSize >= 64 && index >= 200 {
+			continue
+		}
+		for _, value := range []byte{0x00, 0xff} {
+			copy(corrupt, content)
+			corrupt[index] = value
+			readCorrupt(corrupt)
+		}
+	}
+
+	// Interior page whose right-most pointer refers to itself
+	copy(corrupt, content)
+	cyclic := false
+	for number := 2; number <= len(content)/pageSize; number++ {
+		offset := (number - 1) * pageSize
+		if corrupt[offset] == interiorTablePage {
+			binary.BigEndian.PutUint32(corrupt[offset+8:], uint32(number))
+			cyclic = true
+			break
+		}
+	}
+	if err = readCorrupt(corrupt); !cyclic || !errors.Is(err, ErrInvalidDatabase) {
+		t.Error("Expected: ErrInvalidDatabase, Received: ", cyclic, err)
+	}
+
+	// Overflow page (notes setting) whose next page is itself
+	copy(corrupt, content)
+	cyclic = false
+	for number := 2; number <= len(content)/pageSize; number++ {
+		offset := (number - 1) * pageSize
+		next := binary.BigEndian.Uint32(corrupt[offset:])
+		if next > 0 && string(corrupt[offset+4:offset+20]) == strings.Repeat("x", 16) {
+			binary.BigEndian.PutUint32(corrupt[offset:], uint32(number))
+			cyclic = true
+			break
+		}
+	}
+	if err = readCorrupt(corrupt); !cyclic || !errors.Is(err, ErrInvalidDatabase) {
+		t.Error("Expected: ErrInvalidDatabase, Received: ", cyclic, err)
+	}
+}
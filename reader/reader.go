@@ -0,0 +1,163 @@
+// Package reader provides functions to read actigraphy files into time series
+// that can be used by the chronobiology package
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kelvins/chronobiology"
+)
+
+// Sentinel errors returned by the readers (they can be checked using errors.Is)
+var (
+	// ErrMalformed is returned when a row or record of the file could not be parsed
+	ErrMalformed = errors.New("Malformed")
+	// ErrNoColumns is returned when no value column was configured
+	ErrNoColumns = errors.New("NoColumns")
+	// ErrInvalidColumns is returned when a column index is negative or a channel name is repeated
+	ErrInvalidColumns = errors.New("InvalidColumns")
+	// ErrUnknownChannel is returned when the requested channel does not exist in the recording
+	ErrUnknownChannel = errors.New("UnknownChannel")
+	// ErrInvalidFormat is returned when the file is not in the expected format
+	ErrInvalidFormat = errors.New("InvalidFormat")
+)
+
+// ParseError describes a malformed row (or record) of a file
+type ParseError struct {
+	// Line is the line (or record) number, starting at 1
+	Line int
+	// Column is the column number, starting at 0 (-1 when not related to a column)
+	Column int
+	// Err is the underlying error
+	Err error
+}
+
+// Error returns the error message
+func (e *ParseError) Error() string {
+	if e.Column >= 0 {
+		return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrMalformed, so every ParseError can be checked using errors.Is
+func (e *ParseError) Is(target error) bool {
+	return target == ErrMalformed
+}
+
+// Recording stores the channels (e.g. activity, light, temperature) read from a file,
+// all of them sharing the same timestamps
+type Recording struct {
+	// DateTime stores the timestamps of the records
+	DateTime []time.Time
+	// Channels stores the values of each channel by name
+	Channels map[string][]float64
+	// Epoch is the interval between two consecutive records (seconds), zero when unknown
+	Epoch int
+	// Metadata stores the information found in the file header (e.g. subject, device)
+	Metadata map[string]string
+	// Malformed stores the rows skipped because they could not be parsed
+	Malformed []*ParseError
+}
+
+// Creates an empty recording
+func newRecording() *Recording {
+	return &Recording{
+		Channels: make(map[string][]float64),
+		Metadata: make(map[string]string),
+	}
+}
+
+// Names returns the channel names sorted alphabetically
+func (r *Recording) Names() []string {
+	var names []string
+	for name := range r.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Channel returns the channel passed by parameter as a time series ready to be analysed
+func (r *Recording) Channel(name string) (ts *chronobiology.TimeSeries, err error) {
+	data, ok := r.Channels[name]
+	if !ok {
+		err = fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+		return
+	}
+
+	ts, err = chronobiology.NewTimeSeries(r.DateTime, data)
+	if err != nil {
+		return
+	}
+
+	// The epoch stored in the file header is more reliable than the one found by the timestamps
+	if r.Epoch > 0 {
+		ts.Epoch = r.Epoch
+	}
+
+	for key, value := range r.Metadata {
+		ts.Metadata[key] = value
+	}
+	ts.Metadata["channel"] = name
+
+	return
+}
+
+// RawAcceleration returns the "x", "y" and "z" channels (g) as raw acceleration, using the sample rate
+// stored in the "sample_rate" metadata (e.g. recordings read from .gt3x files)
+func (r *Recording) RawAcceleration() (raw *chronobiology.RawAcceleration, err error) {
+	var axes [3][]float64
+	for index, name := range []string{"x", "y", "z"} {
+		var ok bool
+		if axes[index], ok = r.Channels[name]; !ok {
+			err = fmt.Errorf("%w: %s", ErrUnknownChannel, name)
+			return
+		}
+	}
+
+	sampleRate, err := strconv.ParseFloat(r.Metadata["sample_rate"], 64)
+	if err != nil {
+		err = fmt.Errorf("%w: invalid sample rate", ErrInvalidFormat)
+		return
+	}
+
+	return chronobiology.NewRawAcceleration(r.DateTime, axes[0], axes[1], axes[2], sampleRate)
+}
+
+// RawBlock stores the samples of a page (GENEActiv) or block (Axivity) of a raw acceleration file,
+// returned by the streaming readers
+type RawBlock struct {
+	// DateTime stores the timestamp of each sample
+	DateTime []time.Time
+	// X, Y and Z store the acceleration of each axis (g)
+	X, Y, Z []float64
+	// Light stores the light level of each sample
+	Light []float64
+	// Temperature stores the temperature of each sample (°C)
+	Temperature []float64
+}
+
+// Len returns the number of samples in the block
+func (block *RawBlock) Len() int {
+	return len(block.DateTime)
+}
+
+// Appends the block samples to the recording channels
+func (r *Recording) appendBlock(block *RawBlock) {
+	r.DateTime = append(r.DateTime, block.DateTime...)
+	r.Channels["x"] = append(r.Channels["x"], block.X...)
+	r.Channels["y"] = append(r.Channels["y"], block.Y...)
+	r.Channels["z"] = append(r.Channels["z"], block.Z...)
+	r.Channels["light"] = append(r.Channels["light"], block.Light...)
+	r.Channels["temperature"] = append(r.Channels["temperature"], block.Temperature...)
+}
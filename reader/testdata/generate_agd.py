@@ -0,0 +1,39 @@
+# Generates the synthetic ActiGraph .agd file used by the tests (python3 generate_agd.py)
+import sqlite3, os
+from datetime import datetime
+
+def ticks(date):
+    # .NET ticks: 100 ns intervals since 0001-01-01
+    return int((date - datetime(1, 1, 1)).total_seconds()) * 10000000
+
+path = os.path.join(os.path.dirname(os.path.abspath(__file__)), "sample.agd")
+if os.path.exists(path):
+    os.remove(path)
+
+db = sqlite3.connect(path)
+db.execute("PRAGMA page_size = 512")
+db.execute("CREATE TABLE settings (settingID INTEGER PRIMARY KEY AUTOINCREMENT, settingName VARCHAR(64), settingValue VARCHAR(64))")
+db.execute("CREATE TABLE data (dataTimestamp INTEGER PRIMARY KEY NOT NULL, axis1 INTEGER, axis2 INTEGER, axis3 INTEGER, steps INTEGER, lux INTEGER, inclineOff INTEGER, inclineStanding INTEGER, inclineSitting INTEGER, inclineLying INTEGER)")
+
+start = datetime(2015, 1, 1, 0, 0, 0)
+settings = [
+    ("softwarename", "ActiLife"),
+    ("deviceserial", "MOS2A12345678"),
+    ("devicename", "wGT3X-BT"),
+    ("epochlength", "60"),
+    ("startdatetime", str(ticks(start))),
+    ("subjectname", "S01"),
+    # Long value used to test the overflow pages
+    ("notes", "x" * 1500),
+]
+db.executemany("INSERT INTO settings (settingName, settingValue) VALUES (?, ?)", settings)
+
+rows = []
+for index in range(300):
+    timestamp = ticks(start) + index * 60 * 10000000
+    rows.append((timestamp, (index * 37) % 500, (index * 11) % 300, (index * 7) % 200, index % 60, 1000 + index, 0, 60, 0, 0))
+db.executemany("INSERT INTO data VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", rows)
+
+db.commit()
+db.execute("VACUUM")
+db.close()
@@ -0,0 +1,312 @@
+package chronobiology
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Default parameters of the Crespo algorithm
+const (
+	DefaultCrespoPercentile = 0.33          // Percentile of the activity used as threshold
+	DefaultCrespoAlpha      = 8 * time.Hour // Window of the median filter used to find the rest periods
+	DefaultCrespoBeta       = time.Hour     // Window of the median filter used to refine the rest periods
+)
+
+// Default parameters of the Roenneberg algorithm
+const (
+	DefaultRoennebergTrend     = 24 * time.Hour   // Window of the moving average used as trend
+	DefaultRoennebergThreshold = 0.15             // Fraction of the trend below which the activity is handled as rest
+	DefaultRoennebergMinSeed   = 30 * time.Minute // Minimum duration of the consecutive records below the threshold (seeds)
+	DefaultRoennebergMaxGap    = 30 * time.Minute // Maximum duration between the seeds of the same rest period
+)
+
+// CrespoScorer scores the records using the algorithm proposed by Crespo et al. (2012) to detect the rest periods.
+// The zero values are replaced by the default parameters
+type CrespoScorer struct {
+	// Percentile is the percentile (from 0 to 1) of the activity used as threshold
+	Percentile float64
+	// Alpha is the window of the median filter used to find the rest periods
+	Alpha time.Duration
+	// Beta is the window of the median filter used to refine the rest periods and the minimum duration of the periods
+	Beta time.Duration
+}
+
+// RoennebergScorer scores the records using the algorithm proposed by Roenneberg et al. (2015) to detect the rest periods.
+// The zero values are replaced by the default parameters
+type RoennebergScorer struct {
+	// Trend is the window of the centered moving average used as trend
+	Trend time.Duration
+	// Threshold is the fraction of the trend below which the activity is handled as rest
+	Threshold float64
+	// MinSeed is the minimum duration of the consecutive records below the threshold used as seeds of the rest periods
+	MinSeed time.Duration
+	// MaxGap is the maximum duration between the seeds joined in the same rest period
+	MaxGap time.Duration
+}
+
+// Converts the duration to the number of records (at least one) of the epoch
+func durationRecords(duration time.Duration, epoch int) int {
+	records := int(duration / (time.Duration(epoch) * time.Second))
+	if records < 1 {
+		records = 1
+	}
+	return records
+}
+
+// Returns the lowest of the integers
+func minInt(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Returns the highest of the integers
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Calculates the percentile (from 0 to 1) of the valid values using linear interpolation
+func percentile(values []float64, invalid []bool, p float64) float64 {
+	var sorted []float64
+	for index, value := range values {
+		if !invalid[index] {
+			sorted = append(sorted, value)
+		}
+	}
+	sort.Float64s(sorted)
+	position := p * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := int(math.Ceil(position))
+	return sorted[lower] + (position-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+// Applies a centered median filter (window of records) to the valid values. The windows are shorter at the ends
+// of the data and the result is NaN when the window does not have any valid value
+func medianFilter(values []float64, invalid []bool, records int) []float64 {
+	// The invalid values are replaced by NaN, which is not used by the rolling median
+	valid := make([]float64, len(values))
+	for index, value := range values {
+		valid[index] = value
+		if invalid[index] {
+			valid[index] = math.NaN()
+		}
+	}
+	return rollingMedian(valid, records)
+}
+
+// Reverts the runs of the value passed by parameter shorter than the minimum number of records
+func removeShortRuns(values []bool, value bool, records int) {
+	starts, lengths := runs(values)
+	for run, start := range starts {
+		if values[start] == value && lengths[run] < records {
+			for index := start; index < start+lengths[run]; index++ {
+				values[index] = !value
+			}
+		}
+	}
+}
+
+// Score scores each record as rest/sleep (true) or activity/wake (false) using the algorithm proposed by Crespo et al. (2012):
+// the activity is filtered by a median filter (alpha window) and the records below or equal to the percentile of the activity
+// are handled as rest, the rest and activity periods shorter than beta are removed (morphological filtering) and the start
+// and the end of each rest period are refined, within half alpha, using a median filter with the beta window.
+// The gaps, the masked records and the NaN values are not used by the filters and they are scored as wake
+func (scorer CrespoScorer) Score(ts *TimeSeries) (sleep []bool, err error) {
+
+	percentileValue := scorer.Percentile
+	if percentileValue == 0 {
+		percentileValue = DefaultCrespoPercentile
+	}
+	alpha := scorer.Alpha
+	if alpha == 0 {
+		alpha = DefaultCrespoAlpha
+	}
+	beta := scorer.Beta
+	if beta == 0 {
+		beta = DefaultCrespoBeta
+	}
+
+	// Check the parameters
+	if percentileValue <= 0 || percentileValue >= 1 || math.IsNaN(percentileValue) {
+		err = newError("Crespo", ErrInvalidThreshold)
+		return
+	}
+	if beta < 0 || alpha < beta {
+		err = newError("Crespo", ErrInvalidPeriod)
+		return
+	}
+
+	activity, invalid, positions, err := ts.prepareScoring("Crespo")
+	if err != nil {
+		return
+	}
+
+	valid := 0
+	for _, value := range invalid {
+		if !value {
+			valid++
+		}
+	}
+	if valid == 0 {
+		err = newError("Crespo", ErrMasked)
+		return
+	}
+
+	threshold := percentile(activity, invalid, percentileValue)
+	alphaRecords := durationRecords(alpha, ts.Epoch)
+	betaRecords := durationRecords(beta, ts.Epoch)
+
+	// Initial rest periods
+	initial := make([]bool, len(activity))
+	for index, value := range medianFilter(activity, invalid, alphaRecords) {
+		initial[index] = value <= threshold
+	}
+
+	// Morphological filtering (opening and closing)
+	removeShortRuns(initial, true, betaRecords)
+	removeShortRuns(initial, false, betaRecords)
+
+	// Refinement of the start and the end of the rest periods
+	fine := medianFilter(activity, invalid, betaRecords)
+	scores := make([]bool, len(activity))
+	starts, lengths := runs(initial)
+	for run, start := range starts {
+		if !initial[start] {
+			continue
+		}
+		end := start + lengths[run]
+
+		newStart := start
+		for index := maxInt(0, start-alphaRecords/2); index < minInt(len(activity), start+alphaRecords/2); index++ {
+			if fine[index] <= threshold {
+				newStart = index
+				break
+			}
+		}
+		newEnd := end
+		for index := minInt(len(activity), end+alphaRecords/2) - 1; index >= maxInt(0, end-alphaRecords/2); index-- {
+			if fine[index] <= threshold {
+				newEnd = index + 1
+				break
+			}
+		}
+		if newStart >= newEnd {
+			newStart, newEnd = start, end
+		}
+
+		for index := newStart; index < newEnd; index++ {
+			scores[index] = true
+		}
+	}
+
+	return alignScores(scores, invalid, positions, false), nil
+}
+
+// Score scores each record as rest/sleep (true) or activity/wake (false) based on the algorithm proposed by
+// Roenneberg et al. (2015): the trend is the centered moving average of the activity and the records below or equal to
+// the threshold (fraction of the trend) are handled as rest. The consecutive records below the threshold lasting at least
+// MinSeed are the seeds of the rest periods and the seeds separated by up to MaxGap are joined in the same rest period.
+// The gaps, the masked records and the NaN values are not used by the trend and they are scored as wake
+func (scorer RoennebergScorer) Score(ts *TimeSeries) (sleep []bool, err error) {
+
+	trend := scorer.Trend
+	if trend == 0 {
+		trend = DefaultRoennebergTrend
+	}
+	threshold := scorer.Threshold
+	if threshold == 0 {
+		threshold = DefaultRoennebergThreshold
+	}
+	minSeed := scorer.MinSeed
+	if minSeed == 0 {
+		minSeed = DefaultRoennebergMinSeed
+	}
+	maxGap := scorer.MaxGap
+	if maxGap == 0 {
+		maxGap = DefaultRoennebergMaxGap
+	}
+
+	// Check the parameters
+	if threshold < 0 || math.IsNaN(threshold) {
+		err = newError("Roenneberg", ErrInvalidThreshold)
+		return
+	}
+	if trend < 0 || minSeed < 0 || maxGap < 0 {
+		err = newError("Roenneberg", ErrInvalidPeriod)
+		return
+	}
+
+	activity, invalid, positions, err := ts.prepareScoring("Roenneberg")
+	if err != nil {
+		return
+	}
+
+	// Prefix sums of the valid records used by the moving average
+	sums := make([]float64, len(activity)+1)
+	counts := make([]int, len(activity)+1)
+	for index, value := range activity {
+		sums[index+1] = sums[index]
+		counts[index+1] = counts[index]
+		if !invalid[index] {
+			sums[index+1] += value
+			counts[index+1]++
+		}
+	}
+	if counts[len(activity)] == 0 {
+		err = newError("Roenneberg", ErrMasked)
+		return
+	}
+
+	half := durationRecords(trend, ts.Epoch) / 2
+	below := make([]bool, len(activity))
+	for index := range activity {
+		if invalid[index] {
+			continue
+		}
+		first := maxInt(0, index-half)
+		last := minInt(len(activity), index+half+1)
+		mean := (sums[last] - sums[first]) / float64(counts[last]-counts[first])
+		below[index] = activity[index] <= threshold*mean
+	}
+
+	// Seeds of the rest periods
+	removeShortRuns(below, true, durationRecords(minSeed, ts.Epoch))
+
+	// The seeds separated by short periods are joined
+	scores := make([]bool, len(activity))
+	copy(scores, below)
+	gapRecords := durationRecords(maxGap, ts.Epoch)
+	starts, lengths := runs(below)
+	for run := 1; run < len(starts)-1; run++ {
+		if !below[starts[run]] && lengths[run] <= gapRecords {
+			for index := starts[run]; index < starts[run]+lengths[run]; index++ {
+				scores[index] = true
+			}
+		}
+	}
+
+	return alignScores(scores, invalid, positions, false), nil
+}
+
+// Crespo scores each record as rest/sleep (true) or activity/wake (false) using the algorithm proposed by Crespo et al. (2012)
+func Crespo(scorer CrespoScorer, dateTime []time.Time, data []float64) (sleep []bool, err error) {
+	ts, err := wrapTimeSeries("Crespo", dateTime, data)
+	if err != nil {
+		return
+	}
+	return scorer.Score(ts)
+}
+
+// Roenneberg scores each record as rest/sleep (true) or activity/wake (false) based on the algorithm proposed by Roenneberg et al. (2015)
+func Roenneberg(scorer RoennebergScorer, dateTime []time.Time, data []float64) (sleep []bool, err error) {
+	ts, err := wrapTimeSeries("Roenneberg", dateTime, data)
+	if err != nil {
+		return
+	}
+	return scorer.Score(ts)
+}
@@ -0,0 +1,142 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"testing"
+)
+
+// Creates days of 1 minute epochs with rest (low activity) from 23h to 7h and returns the expected scores
+func restSeries(days int) (ts *TimeSeries, rest []bool) {
+	var data []float64
+	for index := 0; index < days*24*60; index++ {
+		hour := (index / 60) % 24
+		if hour >= 23 || hour < 7 {
+			data = append(data, 2.0+2.0*noise(index))
+			rest = append(rest, true)
+		} else {
+			data = append(data, 400.0+100.0*noise(index))
+			rest = append(rest, false)
+		}
+	}
+	return minuteSeries(data), rest
+}
+
+func TestMedianFilter(t *testing.T) {
+
+	// Values with repeated values, invalid records and NaN values
+	values := make([]float64, 200)
+	invalid := make([]bool, len(values))
+	for index := range values {
+		values[index] = math.Round(10.0 * noise(index))
+		invalid[index] = index%7 == 3 || (index >= 50 && index < 70)
+	}
+	values[100] = math.NaN()
+	invalid[100] = true
+
+	for _, records := range []int{1, 2, 5, 8, 31} {
+		filtered := medianFilter(values, invalid, records)
+
+		// Sorts the valid values of each window
+		for index := range values {
+			var window []float64
+			for position := index - records/2; position <= index+records/2; position++ {
+				if position >= 0 && position < len(values) && !invalid[position] {
+					window = append(window, values[position])
+				}
+			}
+			sort.Float64s(window)
+
+			expected := math.NaN()
+			if len(window)%2 == 1 {
+				expected = window[len(window)/2]
+			} else if len(window) > 0 {
+				expected = (window[len(window)/2-1] + window[len(window)/2]) / 2.0
+			}
+			if filtered[index] != expected && !(math.IsNaN(expected) && math.IsNaN(filtered[index])) {
+				t.Error("Records: ", records, "Index: ", index, "Expected: ", expected, "Received: ", filtered[index])
+			}
+		}
+	}
+}
+
+func TestCrespo(t *testing.T) {
+
+	// A nap of 30 minutes (14h of the second day) is shorter than the windows
+	ts, rest := restSeries(3)
+	for index := 38 * 60; index < 38*60+30; index++ {
+		ts.Data[index] = 0
+	}
+	sleep, err := Crespo(CrespoScorer{}, ts.DateTime, ts.Data)
+	if agreement, _ := Agreement(sleep, rest); err != nil || agreement < 0.98 || sleep[38*60+15] {
+		t.Error("Expected: agreement above 0.98 without the nap, Received: ", agreement, err)
+	}
+
+	// The masked records are scored as wake
+	ts.Mask = repeatMask(false, ts.Len())
+	ts.Mask[2*60] = true
+	sleep, _ = CrespoScorer{}.Score(ts)
+	if sleep[2*60] || !sleep[2*60+1] {
+		t.Error("Expected: the masked record scored as wake, Received: ", sleep[2*60], sleep[2*60+1])
+	}
+
+	// Invalid parameters
+	if _, err = (CrespoScorer{Percentile: 1}).Score(ts); !errors.Is(err, ErrInvalidThreshold) {
+		t.Error("Expected: ErrInvalidThreshold, Received: ", err)
+	}
+	if _, err = (CrespoScorer{Beta: DefaultCrespoAlpha + 1}).Score(ts); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	ts.Mask = repeatMask(true, ts.Len())
+	if _, err = (CrespoScorer{}).Score(ts); !errors.Is(err, ErrMasked) {
+		t.Error("Expected: ErrMasked, Received: ", err)
+	}
+}
+
+func TestRoenneberg(t *testing.T) {
+
+	// A nap of 30 minutes (14h of the second day) is a seed of a rest period
+	ts, rest := restSeries(3)
+	for index := 38 * 60; index < 38*60+30; index++ {
+		ts.Data[index] = 0
+		rest[index] = true
+	}
+	sleep, err := Roenneberg(RoennebergScorer{}, ts.DateTime, ts.Data)
+	if agreement, _ := Agreement(sleep, rest); err != nil || agreement < 0.98 || !sleep[38*60+15] {
+		t.Error("Expected: agreement above 0.98 with the nap, Received: ", agreement, err)
+	}
+
+	// Table tests: the awakenings up to MaxGap (3h of the second day) are joined to the rest period
+	var tTests = []struct {
+		minutes int
+		joined  bool
+	}{
+		{20, true},
+		{30, true},
+		{45, false},
+	}
+
+	for _, test := range tTests {
+		ts, _ = restSeries(3)
+		for index := 27 * 60; index < 27*60+test.minutes; index++ {
+			ts.Data[index] = 200
+		}
+		sleep, _ = RoennebergScorer{}.Score(ts)
+		if sleep[27*60] != test.joined {
+			t.Error("Minutes: ", test.minutes, "Expected: ", test.joined, "Received: ", sleep[27*60])
+		}
+	}
+
+	// Invalid parameters
+	if _, err = (RoennebergScorer{Threshold: -1}).Score(ts); !errors.Is(err, ErrInvalidThreshold) {
+		t.Error("Expected: ErrInvalidThreshold, Received: ", err)
+	}
+	if _, err = (RoennebergScorer{MinSeed: -1}).Score(ts); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	ts.Mask = repeatMask(true, ts.Len())
+	if _, err = (RoennebergScorer{}).Score(ts); !errors.Is(err, ErrMasked) {
+		t.Error("Expected: ErrMasked, Received: ", err)
+	}
+}
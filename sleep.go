@@ -0,0 +1,483 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Parameters of the Cole-Kripke algorithm (1 minute epochs, mean activity)
+const (
+	coleKripkeScale     = 0.001 // Scale factor (P)
+	coleKripkeCounts    = 100.0 // The activity counts are divided by this value (as ActiLife for the ActiGraph counts)
+	coleKripkeMaxCounts = 300.0 // Maximum value of the scaled activity counts
+)
+
+// Weights of the Cole-Kripke algorithm, from 4 minutes before to 2 minutes after the scored epoch
+var coleKripkeWeights = []float64{106, 54, 58, 76, 230, 74, 67}
+
+// Scale factor of the Webster algorithm (1 minute epochs)
+const websterScale = 0.025
+
+// Weights of the Webster algorithm, from 4 minutes before to 2 minutes after the scored epoch
+var websterWeights = []float64{0.15, 0.15, 0.15, 0.08, 0.21, 0.12, 0.13}
+
+// DefaultSadehMaxCounts is the limit of the activity counts used by the Sadeh algorithm (as ActiLife)
+const DefaultSadehMaxCounts = 300.0
+
+// Parameters of the Sadeh algorithm (1 minute epochs)
+const (
+	sadehIntercept = 7.601  // Intercept of the probability of sleep
+	sadehMean      = -0.065 // Weight of the mean activity of the 11 minutes window centered on the epoch
+	sadehNAT       = -1.08  // Weight of the number of epochs of the window with activity from 50 to 100
+	sadehSD        = -0.056 // Weight of the standard deviation of the activity of the last 6 minutes
+	sadehLog       = -0.703 // Weight of the natural logarithm of the activity of the epoch plus 1
+	sadehNATMin    = 50.0   // Minimum activity counted by the NAT
+	sadehNATMax    = 100.0  // Maximum activity counted by the NAT (not included)
+)
+
+// Rescoring rules proposed by Webster et al. (1982): after at least wake minutes of wake,
+// the first sleep minutes of sleep are rescored as wake
+var websterRules = []struct {
+	wake  int
+	sleep int
+}{
+	{15, 4},
+	{10, 3},
+	{4, 1},
+}
+
+// Rescoring rules proposed by Webster et al. (1982): the sleep periods up to sleep minutes
+// surrounded by at least wake minutes of wake (before and after) are rescored as wake
+var websterSurroundedRules = []struct {
+	sleep int
+	wake  int
+}{
+	{6, 10},
+	{10, 20},
+}
+
+// Prepares the time series scored by the sleep/wake algorithms: the gaps are filled and the positions
+// of the original records in the filled time series are returned. The gaps, the masked records and the NaN values
+// are handled as zero activity (invalid is true)
+func (ts *TimeSeries) prepareScoring(function string) (activity []float64, invalid []bool, positions []int, err error) {
+
+	// Check the parameters
+	if err = ts.check(function); err != nil {
+		return
+	}
+	if ts.Epoch <= 0 {
+		err = newError(function, ErrInvalidEpoch)
+		return
+	}
+
+	filled, err := ts.FillGapsInData(math.NaN())
+	if err != nil {
+		err = newError(function, err)
+		return
+	}
+
+	activity = make([]float64, filled.Len())
+	invalid = make([]bool, filled.Len())
+	for index := 0; index < filled.Len(); index++ {
+		if filled.valid(index) {
+			activity[index] = filled.Data[index]
+		} else {
+			invalid[index] = true
+		}
+	}
+
+	// The records are kept in the same order by FillGapsInData
+	position := 0
+	for index := 0; index < ts.Len(); index++ {
+		for !filled.DateTime[position].Equal(ts.DateTime[index]) {
+			position++
+		}
+		positions = append(positions, position)
+	}
+	return
+}
+
+// Returns the scores of the original records. The invalid records (gaps, masked records and NaN values)
+// are scored as wake before the rescoring
+func alignScores(scores []bool, invalid []bool, positions []int, rescore bool) (sleep []bool) {
+	for index := range scores {
+		if invalid[index] {
+			scores[index] = false
+		}
+	}
+	if rescore {
+		scores = RescoreWebster(scores)
+	}
+	sleep = make([]bool, len(positions))
+	for index, position := range positions {
+		sleep[index] = scores[position]
+	}
+	return
+}
+
+// ColeKripke scores each epoch as sleep (true) or wake (false) using the algorithm proposed by Cole et al. (1992)
+// for 1 minute epochs (mean activity): D = 0.001 (106 A-4 + 54 A-3 + 58 A-2 + 76 A-1 + 230 A0 + 74 A+1 + 67 A+2),
+// where the epoch is scored as sleep when D is lower than 1. As ActiLife, the ActiGraph counts are divided by 100 and
+// limited to 300. The Webster rescoring rules are applied when rescore is true. The gaps, the masked records and the NaN values
+// are handled as zero activity and scored as wake
+func (ts *TimeSeries) ColeKripke(rescore bool) (sleep []bool, err error) {
+
+	activity, invalid, positions, err := ts.prepareScoring("ColeKripke")
+	if err != nil {
+		return
+	}
+	if ts.Epoch != 60 {
+		err = newError("ColeKripke", ErrInvalidEpoch)
+		return
+	}
+
+	// The ActiGraph counts are scaled and limited
+	scaled := make([]float64, len(activity))
+	for index, value := range activity {
+		scaled[index] = math.Min(value/coleKripkeCounts, coleKripkeMaxCounts)
+	}
+
+	scores := make([]bool, len(activity))
+	for index := range activity {
+		scores[index] = coleKripkeScale*weightedActivity(scaled, index, coleKripkeWeights) < 1.0
+	}
+
+	return alignScores(scores, invalid, positions, rescore), nil
+}
+
+// Calculates the weighted sum of the activity from 4 epochs before to 2 epochs after the epoch passed by parameter
+// (the epochs out of the time series are not used)
+func weightedActivity(activity []float64, index int, weights []float64) (sum float64) {
+	for weightIndex, weight := range weights {
+		position := index + weightIndex - 4
+		if position >= 0 && position < len(activity) {
+			sum += weight * activity[position]
+		}
+	}
+	return
+}
+
+// Webster scores each epoch as sleep (true) or wake (false) using the algorithm proposed by Webster et al. (1982)
+// for 1 minute epochs: D = 0.025 (0.15 A-4 + 0.15 A-3 + 0.15 A-2 + 0.08 A-1 + 0.21 A0 + 0.12 A+1 + 0.13 A+2),
+// where the epoch is scored as sleep when D is lower than 1. The activity is not scaled or limited.
+// The Webster rescoring rules are applied when rescore is true. The gaps, the masked records and the NaN values
+// are handled as zero activity and scored as wake
+func (ts *TimeSeries) Webster(rescore bool) (sleep []bool, err error) {
+
+	activity, invalid, positions, err := ts.prepareScoring("Webster")
+	if err != nil {
+		return
+	}
+	if ts.Epoch != 60 {
+		err = newError("Webster", ErrInvalidEpoch)
+		return
+	}
+
+	scores := make([]bool, len(activity))
+	for index := range activity {
+		scores[index] = websterScale*weightedActivity(activity, index, websterWeights) < 1.0
+	}
+
+	return alignScores(scores, invalid, positions, rescore), nil
+}
+
+// Sadeh scores each epoch as sleep (true) or wake (false) using the algorithm proposed by Sadeh et al. (1994)
+// for 1 minute epochs: PS = 7.601 - 0.065 MW5 - 1.08 NAT - 0.056 SD6 - 0.703 LG, where the epoch is scored as sleep when
+// PS is greater than or equal to zero. MW5 is the mean activity of the 11 minutes window centered on the epoch, NAT is the
+// number of epochs of the window with activity from 50 to 100 (not included), SD6 is the standard deviation of the activity of
+// the epoch and the 5 previous ones and LG is the natural logarithm of the activity of the epoch plus 1 (the windows are
+// shorter at the ends of the time series). As ActiLife, the activity counts are limited to 300 (DefaultSadehMaxCounts),
+// the SadehScorer can use another limit. The Webster rescoring rules are applied when rescore is true.
+// The gaps, the masked records and the NaN values are handled as zero activity and scored as wake
+func (ts *TimeSeries) Sadeh(rescore bool) (sleep []bool, err error) {
+	return ts.sadeh(rescore, DefaultSadehMaxCounts)
+}
+
+// Scores each epoch using the Sadeh algorithm with the activity counts limited to the maximum passed by parameter
+func (ts *TimeSeries) sadeh(rescore bool, maxCounts float64) (sleep []bool, err error) {
+
+	// Check the parameters
+	if maxCounts <= 0 || math.IsNaN(maxCounts) {
+		err = newError("Sadeh", ErrInvalidThreshold)
+		return
+	}
+
+	activity, invalid, positions, err := ts.prepareScoring("Sadeh")
+	if err != nil {
+		return
+	}
+	if ts.Epoch != 60 {
+		err = newError("Sadeh", ErrInvalidEpoch)
+		return
+	}
+	for index, value := range activity {
+		activity[index] = math.Min(value, maxCounts)
+	}
+
+	scores := make([]bool, len(activity))
+	for index := range activity {
+
+		// Mean and NAT of the 11 minutes window
+		sum := 0.0
+		count := 0
+		nat := 0
+		for position := index - 5; position <= index+5; position++ {
+			if position >= 0 && position < len(activity) {
+				sum += activity[position]
+				count++
+				if activity[position] >= sadehNATMin && activity[position] < sadehNATMax {
+					nat++
+				}
+			}
+		}
+		mean := sum / float64(count)
+
+		// Standard deviation of the last 6 minutes
+		var last []float64
+		for position := index - 5; position <= index; position++ {
+			if position >= 0 {
+				last = append(last, activity[position])
+			}
+		}
+		sd := 0.0
+		if len(last) > 1 {
+			lastMean := average(last)
+			for _, value := range last {
+				sd += (value - lastMean) * (value - lastMean)
+			}
+			sd = math.Sqrt(sd / float64(len(last)-1))
+		}
+
+		ps := sadehIntercept + sadehMean*mean + sadehNAT*float64(nat) + sadehSD*sd + sadehLog*math.Log(activity[index]+1.0)
+		scores[index] = ps >= 0
+	}
+
+	return alignScores(scores, invalid, positions, rescore), nil
+}
+
+// Returns the start and the length of the runs of equal values
+func runs(values []bool) (starts []int, lengths []int) {
+	for index := 0; index < len(values); index++ {
+		if index == 0 || values[index] != values[index-1] {
+			starts = append(starts, index)
+			lengths = append(lengths, 0)
+		}
+		lengths[len(lengths)-1]++
+	}
+	return
+}
+
+// RescoreWebster applies the rescoring rules proposed by Webster et al. (1982) to the sleep (true) and wake (false) scores
+// of 1 minute epochs: after at least 4 minutes of wake the first minute of sleep is rescored as wake (3 minutes after at least
+// 10 minutes of wake and 4 minutes after at least 15 minutes of wake), and the sleep periods up to 6 minutes surrounded by
+// at least 10 minutes of wake (or up to 10 minutes surrounded by at least 20 minutes of wake) are rescored as wake
+func RescoreWebster(sleep []bool) (rescored []bool) {
+
+	rescored = make([]bool, len(sleep))
+	copy(rescored, sleep)
+
+	// The first minutes of sleep after a wake period
+	starts, lengths := runs(sleep)
+	for run := 1; run < len(starts); run++ {
+		if !sleep[starts[run]] {
+			continue
+		}
+		for _, rule := range websterRules {
+			if lengths[run-1] >= rule.wake {
+				for index := starts[run]; index < starts[run]+rule.sleep && index < starts[run]+lengths[run]; index++ {
+					rescored[index] = false
+				}
+				break
+			}
+		}
+	}
+
+	// The short sleep periods surrounded by wake
+	starts, lengths = runs(rescored)
+	for run := 1; run < len(starts)-1; run++ {
+		if !rescored[starts[run]] {
+			continue
+		}
+		for _, rule := range websterSurroundedRules {
+			if lengths[run] <= rule.sleep && lengths[run-1] >= rule.wake && lengths[run+1] >= rule.wake {
+				for index := starts[run]; index < starts[run]+lengths[run]; index++ {
+					rescored[index] = false
+				}
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// ColeKripke scores each epoch (1 minute) as sleep (true) or wake (false) using the algorithm proposed by Cole et al. (1992)
+func ColeKripke(rescore bool, dateTime []time.Time, data []float64) (sleep []bool, err error) {
+	ts, err := wrapTimeSeries("ColeKripke", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.ColeKripke(rescore)
+}
+
+// Webster scores each epoch (1 minute) as sleep (true) or wake (false) using the algorithm proposed by Webster et al. (1982)
+func Webster(rescore bool, dateTime []time.Time, data []float64) (sleep []bool, err error) {
+	ts, err := wrapTimeSeries("Webster", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.Webster(rescore)
+}
+
+// Sadeh scores each epoch (1 minute) as sleep (true) or wake (false) using the algorithm proposed by Sadeh et al. (1994)
+func Sadeh(rescore bool, dateTime []time.Time, data []float64) (sleep []bool, err error) {
+	ts, err := wrapTimeSeries("Sadeh", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.Sadeh(rescore)
+}
+
+// Activity thresholds of the Oakley algorithm used by the Actiwatch devices (Actiware)
+const (
+	OakleyLow    = 20.0 // Low threshold (more epochs scored as wake)
+	OakleyMedium = 40.0 // Medium threshold (default)
+	OakleyHigh   = 80.0 // High threshold (more epochs scored as sleep)
+)
+
+// SleepScorer is implemented by the algorithms that score each record of a time series as sleep (true) or wake (false),
+// so the outputs of the different algorithms can be compared on the same time series
+type SleepScorer interface {
+	Score(ts *TimeSeries) (sleep []bool, err error)
+}
+
+// ColeKripkeScorer scores the records using the Cole-Kripke algorithm (1 minute epochs)
+type ColeKripkeScorer struct {
+	// Rescore defines if the Webster rescoring rules are applied
+	Rescore bool
+}
+
+// Score scores each record as sleep (true) or wake (false) using the Cole-Kripke algorithm
+func (scorer ColeKripkeScorer) Score(ts *TimeSeries) (sleep []bool, err error) {
+	return ts.ColeKripke(scorer.Rescore)
+}
+
+// WebsterScorer scores the records using the Webster algorithm (1 minute epochs)
+type WebsterScorer struct {
+	// Rescore defines if the Webster rescoring rules are applied
+	Rescore bool
+}
+
+// Score scores each record as sleep (true) or wake (false) using the Webster algorithm
+func (scorer WebsterScorer) Score(ts *TimeSeries) (sleep []bool, err error) {
+	return ts.Webster(scorer.Rescore)
+}
+
+// SadehScorer scores the records using the Sadeh algorithm (1 minute epochs)
+type SadehScorer struct {
+	// Rescore defines if the Webster rescoring rules are applied
+	Rescore bool
+	// MaxCounts is the limit of the activity counts (DefaultSadehMaxCounts when zero, math.Inf(1) to not limit them)
+	MaxCounts float64
+}
+
+// Score scores each record as sleep (true) or wake (false) using the Sadeh algorithm
+func (scorer SadehScorer) Score(ts *TimeSeries) (sleep []bool, err error) {
+	maxCounts := scorer.MaxCounts
+	if maxCounts == 0 {
+		maxCounts = DefaultSadehMaxCounts
+	}
+	return ts.sadeh(scorer.Rescore, maxCounts)
+}
+
+// OakleyScorer scores the records using the Oakley algorithm (15, 30 or 60 seconds epochs)
+type OakleyScorer struct {
+	// Threshold is the activity threshold (e.g. OakleyLow, OakleyMedium or OakleyHigh). OakleyMedium is used when it is zero
+	Threshold float64
+}
+
+// Score scores each record as sleep (true) or wake (false) using the Oakley algorithm
+func (scorer OakleyScorer) Score(ts *TimeSeries) (sleep []bool, err error) {
+	threshold := scorer.Threshold
+	if threshold == 0 {
+		threshold = OakleyMedium
+	}
+	return ts.Oakley(threshold)
+}
+
+// Oakley scores each epoch as sleep (true) or wake (false) using the algorithm proposed by Oakley (1997) and used by the
+// Actiwatch devices: the total activity of the epoch is the sum of the activity of the epoch multiplied by the number of epochs
+// per minute, the activity of the epochs of the minute before and after multiplied by 1/5 and the activity of the epochs of the
+// second minute before and after multiplied by 1/25 (e.g. 1/25 A-2 + 1/5 A-1 + A0 + 1/5 A+1 + 1/25 A+2 for 1 minute epochs).
+// The epoch is scored as sleep when the total activity is lower than or equal to the threshold (e.g. OakleyLow, OakleyMedium or
+// OakleyHigh). The epoch must divide 1 minute (e.g. 15, 30 or 60 seconds). The gaps, the masked records and the NaN values
+// are handled as zero activity and scored as wake
+func (ts *TimeSeries) Oakley(threshold float64) (sleep []bool, err error) {
+
+	// Check the parameters
+	if threshold <= 0 || math.IsNaN(threshold) {
+		err = newError("Oakley", ErrInvalidThreshold)
+		return
+	}
+
+	activity, invalid, positions, err := ts.prepareScoring("Oakley")
+	if err != nil {
+		return
+	}
+	if ts.Epoch > 60 || 60%ts.Epoch != 0 {
+		err = newError("Oakley", ErrInvalidEpoch)
+		return
+	}
+
+	// Number of epochs per minute
+	epochs := 60 / ts.Epoch
+
+	scores := make([]bool, len(activity))
+	for index := range activity {
+		total := float64(epochs) * activity[index]
+		for offset := 1; offset <= 2*epochs; offset++ {
+			weight := 1.0 / 5.0
+			if offset > epochs {
+				weight = 1.0 / 25.0
+			}
+			if index-offset >= 0 {
+				total += weight * activity[index-offset]
+			}
+			if index+offset < len(activity) {
+				total += weight * activity[index+offset]
+			}
+		}
+		scores[index] = total <= threshold
+	}
+
+	return alignScores(scores, invalid, positions, false), nil
+}
+
+// Oakley scores each epoch as sleep (true) or wake (false) using the algorithm proposed by Oakley (1997) (Actiwatch)
+func Oakley(threshold float64, dateTime []time.Time, data []float64) (sleep []bool, err error) {
+	ts, err := wrapTimeSeries("Oakley", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.Oakley(threshold)
+}
+
+// Agreement calculates the proportion of the records scored equally (sleep or wake) by two algorithms
+// (e.g. the outputs of two SleepScorer on the same time series)
+func Agreement(first []bool, second []bool) (agreement float64, err error) {
+	if len(first) == 0 || len(second) == 0 {
+		err = newError("Agreement", ErrEmpty)
+		return
+	}
+	if len(first) != len(second) {
+		err = newError("Agreement", ErrDifferentSize)
+		return
+	}
+	equal := 0
+	for index := range first {
+		if first[index] == second[index] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(first)), nil
+}
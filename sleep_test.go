@@ -0,0 +1,422 @@
+package chronobiology
+
+import (
+	"encoding/csv"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Returns the positions scored as wake
+func wakePositions(sleep []bool) (positions []int) {
+	for index, value := range sleep {
+		if !value {
+			positions = append(positions, index)
+		}
+	}
+	return
+}
+
+// Compares two int slices (nil and empty slices are equal)
+func sliceIntEquals(a []int, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for index := range a {
+		if a[index] != b[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// Creates the sleep (true) and wake (false) scores from the lengths of the runs, starting with wake
+func scoreRuns(lengths ...int) (sleep []bool) {
+	for run, length := range lengths {
+		sleep = append(sleep, repeatMask(run%2 == 1, length)...)
+	}
+	return
+}
+
+// Reads a CSV file of the testdata directory (a datetime column followed by value columns, see testdata/generate_counts.py)
+func readFixture(name string) (dateTime []time.Time, columns map[string][]float64, err error) {
+	file, err := os.Open(filepath.Join("testdata", name))
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return
+	}
+
+	columns = make(map[string][]float64)
+	for _, row := range rows[1:] {
+		date, parseErr := time.Parse("2006-01-02 15:04:05", row[0])
+		if parseErr != nil {
+			return nil, nil, parseErr
+		}
+		dateTime = append(dateTime, date)
+		for index, name := range rows[0][1:] {
+			value, parseErr := strconv.ParseFloat(row[index+1], 64)
+			if parseErr != nil {
+				return nil, nil, parseErr
+			}
+			columns[name] = append(columns[name], value)
+		}
+	}
+	return
+}
+
+func TestColeKripke(t *testing.T) {
+
+	// Table tests: 20 minutes of zero counts with a single movement at 10 minutes
+	var tTests = []struct {
+		counts float64
+		wake   []int
+	}{
+		{0, nil},
+		{430, nil},                               // D = 0.001 * 230 * 4.30 = 0.989
+		{435, []int{10}},                         // D = 0.001 * 230 * 4.35 = 1.0005
+		{1000, []int{10, 14}},                    // 106 A-4: 0.001 * 106 * 10 = 1.06
+		{1330, []int{10, 11, 14}},                // 76 A-1: 0.001 * 76 * 13.3 = 1.0108
+		{1400, []int{9, 10, 11, 14}},             // 74 A+1: 0.001 * 74 * 14 = 1.036
+		{1500, []int{8, 9, 10, 11, 14}},          // 67 A+2: 0.001 * 67 * 15 = 1.005
+		{1800, []int{8, 9, 10, 11, 12, 14}},      // 58 A-2: 0.001 * 58 * 18 = 1.044
+		{10000, []int{8, 9, 10, 11, 12, 13, 14}}, // The lowest weight: 0.001 * 54 * 100 = 5.4
+		{100000, []int{8, 9, 10, 11, 12, 13, 14}},
+	}
+
+	for _, test := range tTests {
+		data := repeatValue(0, 20)
+		data[10] = test.counts
+		sleep, err := minuteSeries(data).ColeKripke(false)
+		if err != nil || len(sleep) != 20 || !sliceIntEquals(wakePositions(sleep), test.wake) {
+			t.Error("Counts: ", test.counts, "Expected: ", test.wake, "Received: ", wakePositions(sleep), err)
+		}
+	}
+
+	// The ActiGraph counts are limited to 300 after the scaling
+	data := repeatValue(0, 20)
+	data[4] = 100000
+	scaled, _ := minuteSeries(data).ColeKripke(false)
+	data[4] = 30000
+	limited, _ := minuteSeries(data).ColeKripke(false)
+	if !sliceIntEquals(wakePositions(scaled), wakePositions(limited)) {
+		t.Error("Expected the same scores. Received: ", wakePositions(scaled), wakePositions(limited))
+	}
+
+	// The gaps and the masked records are scored as wake (handled as zero activity)
+	ts := minuteSeries(repeatValue(0, 30))
+	ts.Mask = repeatMask(false, ts.Len())
+	ts.Mask[20] = true
+	ts.Data[25] = 10000
+	dateTime := append(ts.DateTime[:5:5], ts.DateTime[8:]...)
+	withGap, _ := NewTimeSeries(dateTime, append(ts.Data[:5:5], ts.Data[8:]...))
+	withGap.Mask = append(ts.Mask[:5:5], ts.Mask[8:]...)
+	sleep, err := withGap.ColeKripke(false)
+	if err != nil || len(sleep) != 27 || !sliceIntEquals(wakePositions(sleep), []int{17, 20, 21, 22, 23, 24, 25, 26}) {
+		t.Error("Unexpected scores: ", wakePositions(sleep), err)
+	}
+
+	// Invalid epoch (2 minutes)
+	var everyOther []time.Time
+	for index := 0; index < ts.Len(); index += 2 {
+		everyOther = append(everyOther, ts.DateTime[index])
+	}
+	if _, err = ColeKripke(false, everyOther, ts.Data[:len(everyOther)]); !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+}
+
+func TestWebster(t *testing.T) {
+
+	// Table tests: 20 minutes of zero activity with a single movement at 10 minutes (each row adds the next highest weight)
+	var tTests = []struct {
+		activity float64
+		wake     []int
+	}{
+		{0, nil},
+		{190, nil},                               // D = 0.025 * 0.21 * 190 = 0.9975
+		{191, []int{10}},                         // 0.21 A0
+		{267, []int{10, 12, 13, 14}},             // 0.15 A-4, A-3 and A-2: 0.025 * 0.15 * 267 = 1.0013
+		{308, []int{8, 10, 12, 13, 14}},          // 0.13 A+2: 0.025 * 0.13 * 308 = 1.001
+		{334, []int{8, 9, 10, 12, 13, 14}},       // 0.12 A+1: 0.025 * 0.12 * 334 = 1.002
+		{501, []int{8, 9, 10, 11, 12, 13, 14}},   // 0.08 A-1: 0.025 * 0.08 * 501 = 1.002
+		{50000, []int{8, 9, 10, 11, 12, 13, 14}}, // The activity is not limited
+	}
+
+	for _, test := range tTests {
+		data := repeatValue(0, 20)
+		data[10] = test.activity
+		sleep, err := Webster(false, minuteSeries(data).DateTime, data)
+		if err != nil || len(sleep) != 20 || !sliceIntEquals(wakePositions(sleep), test.wake) {
+			t.Error("Activity: ", test.activity, "Expected: ", test.wake, "Received: ", wakePositions(sleep), err)
+		}
+	}
+
+	// The rescoring rules (the first 3 minutes of sleep after 11 minutes of wake)
+	data := repeatValue(0, 20)
+	for index := 5; index < 10; index++ {
+		data[index] = 1000
+	}
+	sleep, err := WebsterScorer{Rescore: true}.Score(minuteSeries(data))
+	if err != nil || !sliceIntEquals(wakePositions(sleep), []int{3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}) {
+		t.Error("Expected: [3 ... 16], Received: ", wakePositions(sleep), err)
+	}
+
+	// Invalid epoch (30 seconds)
+	ts := minuteSeries(repeatValue(0, 20))
+	for index := range ts.DateTime {
+		ts.DateTime[index] = ts.DateTime[0].Add(time.Duration(index) * 30 * time.Second)
+	}
+	ts.Epoch = 30
+	if _, err = ts.Webster(false); !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+}
+
+func TestSadeh(t *testing.T) {
+
+	// Without activity all the epochs are scored as sleep
+	sleep, err := Sadeh(false, minuteSeries(repeatValue(0, 20)).DateTime, repeatValue(0, 20))
+	if err != nil || len(wakePositions(sleep)) != 0 {
+		t.Error("Expected: sleep, Received: ", wakePositions(sleep), err)
+	}
+
+	// PS = 7.601 - 0.065 * 60 - 1.08 * 11 - 0.703 * ln(61) = -11.07 in the middle of the time series
+	sleep, _ = minuteSeries(repeatValue(60, 20)).Sadeh(false)
+	if len(wakePositions(sleep)) != 20 {
+		t.Error("Expected: wake, Received: ", wakePositions(sleep))
+	}
+
+	// A single movement increases the standard deviation of the following 5 minutes
+	data := repeatValue(0, 20)
+	data[10] = 1000
+	sleep, _ = minuteSeries(data).Sadeh(false)
+	if !sliceIntEquals(wakePositions(sleep), []int{10, 11, 12, 13, 14, 15}) {
+		t.Error("Expected: [10 11 12 13 14 15], Received: ", wakePositions(sleep))
+	}
+
+	// Constant activity: NAT = 11 from 50 to 100 (not included) and PS = 7.601 - 0.065 * 100 - 0.703 * ln(101) = -2.14 at 100
+	var constantTests = []struct {
+		activity float64
+		wake     int
+	}{
+		{49, 0}, // PS = 7.601 - 0.065 * 49 - 0.703 * ln(50) = 1.666
+		{50, 20},
+		{99, 20},
+		{100, 20},
+	}
+	for _, test := range constantTests {
+		sleep, _ = minuteSeries(repeatValue(test.activity, 20)).Sadeh(false)
+		if len(wakePositions(sleep)) != test.wake {
+			t.Error("Activity: ", test.activity, "Expected: ", test.wake, "Received: ", wakePositions(sleep))
+		}
+	}
+
+	// A single movement (A) during the following 5 minutes: PS = 7.601 - 0.065 A / 11 - 0.056 A / sqrt(6), zero when A = 264.2.
+	// The activity is limited to 300 by default, without the limit the mean of the window (2000 / 11) scores the 5 previous
+	// minutes as wake
+	for _, test := range []struct {
+		activity  float64
+		maxCounts float64
+		wake      []int
+	}{
+		{260, 0, []int{10}},
+		{270, 0, []int{10, 11, 12, 13, 14, 15}},
+		{2000, 0, []int{10, 11, 12, 13, 14, 15}},
+		{2000, math.Inf(1), []int{5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}},
+		{270, 250, []int{10}},
+	} {
+		movement := repeatValue(0, 30)
+		movement[10] = test.activity
+		sleep, err = SadehScorer{MaxCounts: test.maxCounts}.Score(minuteSeries(movement))
+		if err != nil || !sliceIntEquals(wakePositions(sleep), test.wake) {
+			t.Error("Activity: ", test.activity, "MaxCounts: ", test.maxCounts, "Expected: ", test.wake, "Received: ", wakePositions(sleep), err)
+		}
+	}
+	if _, err = (SadehScorer{MaxCounts: -1}).Score(minuteSeries(data)); !errors.Is(err, ErrInvalidThreshold) {
+		t.Error("Expected: ErrInvalidThreshold, Received: ", err)
+	}
+
+	// The rescoring rules (the first minute of sleep after 6 minutes of wake)
+	sleep, _ = minuteSeries(data).Sadeh(true)
+	if !sliceIntEquals(wakePositions(sleep), []int{10, 11, 12, 13, 14, 15, 16}) {
+		t.Error("Expected: [10 11 12 13 14 15 16], Received: ", wakePositions(sleep))
+	}
+}
+
+func TestScoringReference(t *testing.T) {
+
+	dateTime, counts, err := readFixture("counts.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, _ := NewTimeSeries(dateTime, counts["counts"])
+
+	// Scores exported by other software (e.g. ActiLife) for the same count series
+	referenceDateTime, reference, err := readFixture("scoring_reference.csv")
+	if os.IsNotExist(err) {
+		t.Skip("testdata/scoring_reference.csv not found (see testdata/generate_counts.py)")
+	}
+	if err != nil || !sliceTimeEquals(referenceDateTime, dateTime) {
+		t.Fatal("Invalid reference: ", err)
+	}
+
+	var tTests = []struct {
+		column string
+		scorer SleepScorer
+	}{
+		{"cole_kripke", ColeKripkeScorer{}},
+		{"sadeh", SadehScorer{}},
+		{"webster", WebsterScorer{}},
+	}
+
+	for _, test := range tTests {
+		scores, ok := reference[test.column]
+		if !ok {
+			continue
+		}
+		sleep, err := test.scorer.Score(ts)
+		if err != nil {
+			t.Error(test.column, "Expected error = nil. Received: ", err)
+			continue
+		}
+		// The first and last 10 epochs are not compared (the software handle the ends of the series differently)
+		var different []int
+		for index := 10; index < len(sleep)-10; index++ {
+			if sleep[index] != (scores[index] == 1) {
+				different = append(different, index)
+			}
+		}
+		if len(different) > 0 {
+			t.Error(test.column, "Expected the same scores. Different epochs: ", different)
+		}
+	}
+}
+
+func TestRescoreWebster(t *testing.T) {
+
+	var tTests = []struct {
+		name     string
+		sleep    []bool
+		expected []bool
+	}{
+		{"Short wake", scoreRuns(3, 10), scoreRuns(3, 10)},
+		{"After 4 minutes of wake", scoreRuns(4, 10), scoreRuns(5, 9)},
+		{"After 10 minutes of wake", scoreRuns(10, 20), scoreRuns(13, 17)},
+		{"After 15 minutes of wake", scoreRuns(15, 20), scoreRuns(19, 16)},
+		{"Sleep surrounded by 10 minutes of wake", scoreRuns(2, 20, 10, 8, 10), scoreRuns(2, 20, 28)},
+		{"Sleep surrounded by 20 minutes of wake", scoreRuns(2, 20, 20, 13, 20), scoreRuns(2, 20, 53)},
+		{"Long sleep surrounded by wake", scoreRuns(2, 20, 20, 15, 20), scoreRuns(2, 20, 24, 11, 20)},
+		{"Starting with sleep", scoreRuns(0, 5, 3), scoreRuns(0, 5, 3)},
+	}
+
+	for _, test := range tTests {
+		rescored := RescoreWebster(test.sleep)
+		if !sliceIntEquals(wakePositions(rescored), wakePositions(test.expected)) || len(rescored) != len(test.expected) {
+			t.Error(test.name, "Expected: ", wakePositions(test.expected), "Received: ", wakePositions(rescored))
+		}
+	}
+}
+
+func TestOakley(t *testing.T) {
+
+	// Table tests: 20 minutes of zero activity with a single movement at 10 minutes
+	var tTests = []struct {
+		threshold float64
+		activity  float64
+		wake      []int
+	}{
+		{OakleyMedium, 0, nil},
+		{OakleyMedium, 40, nil},
+		{OakleyMedium, 41, []int{10}},
+		{OakleyMedium, 201, []int{9, 10, 11}},         // 1/5 * 201 = 40.2
+		{OakleyMedium, 1001, []int{8, 9, 10, 11, 12}}, // 1/25 * 1001 = 40.04
+		{OakleyLow, 21, []int{10}},
+		{OakleyHigh, 41, nil},
+	}
+
+	for _, test := range tTests {
+		data := repeatValue(0, 20)
+		data[10] = test.activity
+		sleep, err := minuteSeries(data).Oakley(test.threshold)
+		if err != nil || len(sleep) != 20 || !sliceIntEquals(wakePositions(sleep), test.wake) {
+			t.Error("Threshold: ", test.threshold, "Activity: ", test.activity, "Expected: ", test.wake, "Received: ", wakePositions(sleep), err)
+		}
+	}
+
+	// 30 seconds epochs: the activity of the epoch is doubled and the minute before and after has 2 epochs
+	ts := minuteSeries(repeatValue(0, 20))
+	for index := range ts.DateTime {
+		ts.DateTime[index] = ts.DateTime[0].Add(time.Duration(index) * 30 * time.Second)
+	}
+	ts.Epoch = 30
+	ts.Data[10] = 21
+	sleep, err := ts.Oakley(OakleyMedium)
+	if err != nil || !sliceIntEquals(wakePositions(sleep), []int{10}) {
+		t.Error("Expected: [10], Received: ", wakePositions(sleep), err)
+	}
+	ts.Data[10] = 201
+	sleep, _ = OakleyScorer{}.Score(ts)
+	if !sliceIntEquals(wakePositions(sleep), []int{8, 9, 10, 11, 12}) {
+		t.Error("Expected: [8 9 10 11 12], Received: ", wakePositions(sleep))
+	}
+
+	// Invalid threshold and epoch (2 minutes)
+	if _, err = ts.Oakley(0); !errors.Is(err, ErrInvalidThreshold) {
+		t.Error("Expected: ErrInvalidThreshold, Received: ", err)
+	}
+	for index := range ts.DateTime {
+		ts.DateTime[index] = ts.DateTime[0].Add(time.Duration(index) * 2 * time.Minute)
+	}
+	if _, err = Oakley(OakleyMedium, ts.DateTime, ts.Data); !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+}
+
+func TestSleepScorer(t *testing.T) {
+
+	// 3 days with rest from 23h to 7h
+	ts, rest := restSeries(3)
+
+	var tTests = []struct {
+		name   string
+		scorer SleepScorer
+	}{
+		{"ColeKripke", ColeKripkeScorer{}},
+		{"Sadeh", SadehScorer{Rescore: true}},
+		{"Webster", WebsterScorer{}},
+		{"Oakley", OakleyScorer{Threshold: OakleyLow}},
+		{"Crespo", CrespoScorer{}},
+		{"Roenneberg", RoennebergScorer{}},
+	}
+
+	for _, test := range tTests {
+		sleep, err := test.scorer.Score(ts)
+		agreement, _ := Agreement(sleep, rest)
+		if err != nil || agreement < 0.98 {
+			t.Error(test.name, "Expected: agreement above 0.98, Received: ", agreement, err)
+		}
+	}
+}
+
+func TestAgreement(t *testing.T) {
+
+	agreement, err := Agreement(scoreRuns(2, 2), scoreRuns(1, 3))
+	if err != nil || !floatEquals(agreement, 0.75) {
+		t.Error("Expected: 0.75, Received: ", agreement, err)
+	}
+	if _, err = Agreement(scoreRuns(2, 2), scoreRuns(2)); !errors.Is(err, ErrDifferentSize) {
+		t.Error("Expected: ErrDifferentSize, Received: ", err)
+	}
+	if _, err = Agreement(nil, nil); !errors.Is(err, ErrEmpty) {
+		t.Error("Expected: ErrEmpty, Received: ", err)
+	}
+}
@@ -0,0 +1,331 @@
+package chronobiology
+
+import (
+	"math"
+)
+
+// Parameters used by the numerical methods
+const (
+	continuedFractionIterations = 300   // Maximum number of iterations of the continued fractions
+	continuedFractionEpsilon    = 1e-15 // Relative precision of the continued fractions
+	quantileIterations          = 200   // Maximum number of iterations used to find a quantile
+	gammaIterations             = 10000 // Maximum number of iterations of the incomplete gamma series
+)
+
+// Evaluates the continued fraction of the regularized incomplete beta function (Numerical Recipes, betacf)
+func betaContinuedFraction(a float64, b float64, x float64) float64 {
+
+	tiny := 1e-300
+	qab := a + b
+	qap := a + 1.0
+	qam := a - 1.0
+
+	c := 1.0
+	d := 1.0 - qab*x/qap
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1.0 / d
+	h := d
+
+	for m := 1; m <= continuedFractionIterations; m++ {
+		m2 := 2.0 * float64(m)
+
+		// Even step
+		aa := float64(m) * (b - float64(m)) * x / ((qam + m2) * (a + m2))
+		d = 1.0 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1.0 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1.0 / d
+		h *= d * c
+
+		// Odd step
+		aa = -(a + float64(m)) * (qab + float64(m)) * x / ((a + m2) * (qap + m2))
+		d = 1.0 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1.0 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1.0 / d
+		delta := d * c
+		h *= delta
+
+		if math.Abs(delta-1.0) < continuedFractionEpsilon {
+			break
+		}
+	}
+
+	return h
+}
+
+// Calculates the regularized incomplete beta function I_x(a, b)
+func incompleteBeta(a float64, b float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	lgammaAB, _ := math.Lgamma(a + b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1.0-x))
+
+	// The continued fraction converges faster on this side
+	if x < (a+1.0)/(a+b+2.0) {
+		return front * betaContinuedFraction(a, b, x) / a
+	}
+	return 1.0 - front*betaContinuedFraction(b, a, 1.0-x)/b
+}
+
+// Calculates the cumulative distribution function of the Student's t distribution
+func studentCDF(t float64, df float64) float64 {
+	tail := 0.5 * incompleteBeta(df/2.0, 0.5, df/(df+t*t))
+	if t > 0 {
+		return 1.0 - tail
+	}
+	return tail
+}
+
+// Calculates the quantile (inverse of the cumulative distribution function) of the Student's t distribution
+func studentQuantile(p float64, df float64) float64 {
+	if p <= 0 {
+		return math.Inf(-1)
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+	if p < 0.5 {
+		return -studentQuantile(1.0-p, df)
+	}
+
+	// Bisection (the cumulative distribution function is monotonic)
+	low, high := 0.0, 1.0
+	for studentCDF(high, df) < p {
+		high *= 2.0
+	}
+	for iteration := 0; iteration < quantileIterations && high-low > 1e-12*high; iteration++ {
+		middle := (low + high) / 2.0
+		if studentCDF(middle, df) < p {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2.0
+}
+
+// Calculates the cumulative distribution function of the F distribution
+func fisherCDF(f float64, d1 float64, d2 float64) float64 {
+	if f <= 0 {
+		return 0
+	}
+	return incompleteBeta(d1/2.0, d2/2.0, d1*f/(d1*f+d2))
+}
+
+// Calculates the quantile (inverse of the cumulative distribution function) of the F distribution
+func fisherQuantile(p float64, d1 float64, d2 float64) float64 {
+	if p <= 0 {
+		return 0
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+
+	// Bisection (the cumulative distribution function is monotonic)
+	low, high := 0.0, 1.0
+	for fisherCDF(high, d1, d2) < p {
+		high *= 2.0
+	}
+	for iteration := 0; iteration < quantileIterations && high-low > 1e-12*high; iteration++ {
+		middle := (low + high) / 2.0
+		if fisherCDF(middle, d1, d2) < p {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2.0
+}
+
+// Calculates the regularized lower incomplete gamma function P(a, x) (Numerical Recipes, gser and gcf)
+func incompleteGamma(a float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+
+	lgammaA, _ := math.Lgamma(a)
+	front := math.Exp(-x + a*math.Log(x) - lgammaA)
+
+	// Series representation
+	if x < a+1.0 {
+		term := 1.0 / a
+		sum := term
+		for n := 1; n <= gammaIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*continuedFractionEpsilon {
+				break
+			}
+		}
+		return sum * front
+	}
+
+	// Continued fraction representation (Lentz's method)
+	tiny := 1e-300
+	b := x + 1.0 - a
+	c := 1.0 / tiny
+	d := 1.0 / b
+	h := d
+	for n := 1; n <= gammaIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2.0
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1.0 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1.0) < continuedFractionEpsilon {
+			break
+		}
+	}
+	return 1.0 - front*h
+}
+
+// Calculates the cumulative distribution function of the chi-square distribution
+func chiSquareCDF(x float64, df float64) float64 {
+	return incompleteGamma(df/2.0, x/2.0)
+}
+
+// Calculates the quantile (inverse of the cumulative distribution function) of the chi-square distribution
+func chiSquareQuantile(p float64, df float64) float64 {
+	if p <= 0 {
+		return 0
+	}
+	if p >= 1 {
+		return math.Inf(1)
+	}
+
+	// Bisection (the cumulative distribution function is monotonic)
+	low, high := 0.0, math.Max(df, 1.0)
+	for chiSquareCDF(high, df) < p {
+		high *= 2.0
+	}
+	for iteration := 0; iteration < quantileIterations && high-low > 1e-12*high; iteration++ {
+		middle := (low + high) / 2.0
+		if chiSquareCDF(middle, df) < p {
+			low = middle
+		} else {
+			high = middle
+		}
+	}
+	return (low + high) / 2.0
+}
+
+// Inverts a square matrix using the Gauss-Jordan elimination with partial pivoting.
+// It returns false when the matrix is singular
+func invertMatrix(matrix [][]float64) (inverse [][]float64, ok bool) {
+
+	n := len(matrix)
+
+	// Augmented matrix [matrix | identity]
+	augmented := make([][]float64, n)
+	for row := 0; row < n; row++ {
+		augmented[row] = make([]float64, 2*n)
+		copy(augmented[row], matrix[row])
+		augmented[row][n+row] = 1.0
+	}
+
+	for column := 0; column < n; column++ {
+
+		pivot := column
+		for row := column + 1; row < n; row++ {
+			if math.Abs(augmented[row][column]) > math.Abs(augmented[pivot][column]) {
+				pivot = row
+			}
+		}
+		if math.Abs(augmented[pivot][column]) < 1e-12 {
+			return nil, false
+		}
+		augmented[column], augmented[pivot] = augmented[pivot], augmented[column]
+
+		value := augmented[column][column]
+		for index := 0; index < 2*n; index++ {
+			augmented[column][index] /= value
+		}
+
+		for row := 0; row < n; row++ {
+			if row == column {
+				continue
+			}
+			factor := augmented[row][column]
+			for index := 0; index < 2*n; index++ {
+				augmented[row][index] -= factor * augmented[column][index]
+			}
+		}
+	}
+
+	inverse = make([][]float64, n)
+	for row := 0; row < n; row++ {
+		inverse[row] = augmented[row][n:]
+	}
+	return inverse, true
+}
+
+// Fits a linear model (ordinary least squares) where each row of the design matrix holds the regressors of an observation.
+// It returns the coefficients, the inverse of X'X (used to find the covariance) and the residual sum of squares
+func leastSquares(design [][]float64, data []float64) (coefficients []float64, inverse [][]float64, rss float64, ok bool) {
+
+	p := len(design[0])
+
+	// Normal equations: (X'X) b = X'y
+	xtx := make([][]float64, p)
+	xty := make([]float64, p)
+	for row := 0; row < p; row++ {
+		xtx[row] = make([]float64, p)
+	}
+	for index := 0; index < len(data); index++ {
+		for row := 0; row < p; row++ {
+			xty[row] += design[index][row] * data[index]
+			for column := 0; column < p; column++ {
+				xtx[row][column] += design[index][row] * design[index][column]
+			}
+		}
+	}
+
+	if inverse, ok = invertMatrix(xtx); !ok {
+		return
+	}
+
+	coefficients = make([]float64, p)
+	for row := 0; row < p; row++ {
+		for column := 0; column < p; column++ {
+			coefficients[row] += inverse[row][column] * xty[column]
+		}
+	}
+
+	for index := 0; index < len(data); index++ {
+		fitted := 0.0
+		for column := 0; column < p; column++ {
+			fitted += design[index][column] * coefficients[column]
+		}
+		rss += (data[index] - fitted) * (data[index] - fitted)
+	}
+
+	return
+}
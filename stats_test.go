@@ -0,0 +1,58 @@
+package chronobiology
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistributions(t *testing.T) {
+
+	// Table tests
+	var tTests = []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{"incompleteBeta(2, 3, 0.4)", incompleteBeta(2, 3, 0.4), 0.5248},
+		{"incompleteBeta(0.5, 0.5, 0.5)", incompleteBeta(0.5, 0.5, 0.5), 0.5},
+		{"studentCDF(2.228139, 10)", studentCDF(2.228139, 10), 0.975},
+		{"studentCDF(-1, 1)", studentCDF(-1, 1), 0.25},
+		{"studentQuantile(0.975, 10)", studentQuantile(0.975, 10), 2.228139},
+		{"studentQuantile(0.025, 30)", studentQuantile(0.025, 30), -2.042272},
+		{"fisherCDF(3, 2, 10)", fisherCDF(3, 2, 10), 1.0 - math.Pow(1.6, -5)},
+		{"fisherCDF(4.964603, 1, 10)", fisherCDF(4.964603, 1, 10), 0.95},
+		{"fisherQuantile(0.95, 2, 10)", fisherQuantile(0.95, 2, 10), 4.102821},
+		{"chiSquareCDF(3.84146, 1)", chiSquareCDF(3.841459, 1), 0.95},
+		{"chiSquareCDF(2, 2)", chiSquareCDF(2, 2), 1.0 - math.Exp(-1)},
+		{"chiSquareQuantile(0.95, 10)", chiSquareQuantile(0.95, 10), 18.307038},
+		{"chiSquareCDF(chiSquareQuantile(0.99, 1439))", chiSquareCDF(chiSquareQuantile(0.99, 1439), 1439), 0.99},
+	}
+
+	// Test with all values in the table
+	for _, table := range tTests {
+		if math.Abs(table.value-table.expected) > 1e-6 {
+			t.Error(
+				"Function: ", table.name,
+				"Expected: ", table.expected,
+				"Received: ", table.value,
+			)
+		}
+	}
+}
+
+func TestLeastSquares(t *testing.T) {
+
+	// y = 2 + 3x
+	design := [][]float64{{1, 0}, {1, 1}, {1, 2}, {1, 3}}
+	data := []float64{2, 5, 8, 11}
+
+	coefficients, _, rss, ok := leastSquares(design, data)
+	if !ok || !floatEquals(roundPlus(coefficients[0], 6), 2) || !floatEquals(roundPlus(coefficients[1], 6), 3) || rss > 1e-9 {
+		t.Error("Expected: [2 3], Received: ", coefficients, rss)
+	}
+
+	// Singular matrix
+	if _, _, _, ok = leastSquares([][]float64{{1, 2}, {2, 4}}, []float64{1, 2}); ok {
+		t.Error("Expected a singular matrix")
+	}
+}
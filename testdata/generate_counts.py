@@ -0,0 +1,26 @@
+# Generates the synthetic count series (3 days of 1 minute epochs) shared by the reference tests (python3 generate_counts.py).
+# The reference outputs of other software are stored next to it:
+#   scoring_reference.csv: datetime and the sleep (1) / wake (0) score of each epoch in the cole_kripke, sadeh and/or webster
+#                          columns, e.g. exported by ActiLife (without rescoring)
+#   variability_reference.csv: the is and iv columns (one row) of the series with hourly bins, e.g. calculated by nparACT
+#                              or pyActigraphy, and comment lines (#) with the software version and the call used
+import os
+import random
+from datetime import datetime, timedelta
+
+path = os.path.join(os.path.dirname(os.path.abspath(__file__)), "counts.csv")
+
+generator = random.Random(2015)
+start = datetime(2015, 1, 1, 0, 0, 0)
+
+with open(path, "w") as output:
+    output.write("datetime,counts\n")
+    for minute in range(3 * 24 * 60):
+        date = start + timedelta(minutes=minute)
+        if 7 <= date.hour < 23:
+            # Day: activity with some rest
+            counts = 0 if generator.random() < 0.1 else int(generator.expovariate(1.0 / 400.0))
+        else:
+            # Night: few movements
+            counts = int(generator.uniform(20, 400)) if generator.random() < 0.08 else 0
+        output.write("%s,%d\n" % (date.strftime("%Y-%m-%d %H:%M:%S"), counts))
@@ -0,0 +1,968 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Coverage defines the minimum amount of valid data (records not masked and not NaN) required by the analyses.
+// The zero value does not require any coverage
+type Coverage struct {
+	// Day is the minimum valid duration of a calendar day (e.g. 16 hours): the days below it are not used
+	// by AverageDay, IntradailyVariability and InterdailyStability
+	Day time.Duration
+	// Window is the minimum valid fraction (from 0 to 1) of the windows used by HigherActivity and LowerActivity:
+	// the windows below it are not used
+	Window float64
+}
+
+// TimeSeries holds a time series (timestamps and values) together with its epoch,
+// time zone and metadata, so the epoch is found only once and shared by all the analyses
+type TimeSeries struct {
+	// DateTime stores the timestamps of the time series
+	DateTime []time.Time
+	// Data stores the values related to each timestamp
+	Data []float64
+	// Epoch is the interval between two consecutive records (seconds)
+	Epoch int
+	// Location is the time zone of the timestamps
+	Location *time.Location
+	// Metadata can be used to store any information related to the time series (e.g. subject, device)
+	Metadata map[string]string
+	// Mask marks the records that must be excluded from the analyses (e.g. non-wear periods).
+	// When nil, all the records are used. The NaN values are handled as masked records
+	Mask []bool
+	// MinCoverage defines the minimum amount of valid data required by the analyses
+	MinCoverage Coverage
+}
+
+// Checks the slices used to create a time series
+func checkSlices(function string, dateTime []time.Time, data []float64) error {
+	if len(dateTime) == 0 || len(data) == 0 {
+		return newError(function, ErrEmpty)
+	}
+	if len(dateTime) != len(data) {
+		return newError(function, ErrDifferentSize)
+	}
+	return nil
+}
+
+// Checks the slices and the mask of the time series
+func (ts *TimeSeries) check(function string) error {
+	if err := checkSlices(function, ts.DateTime, ts.Data); err != nil {
+		return err
+	}
+	if ts.Mask != nil && len(ts.Mask) != len(ts.Data) {
+		return newError(function, ErrDifferentSize)
+	}
+	return nil
+}
+
+// Reports whether the record is used by the analyses (i.e. it is not masked and not NaN)
+func (ts *TimeSeries) valid(index int) bool {
+	return (ts.Mask == nil || !ts.Mask[index]) && !math.IsNaN(ts.Data[index])
+}
+
+// Returns the mask combined with the NaN values (nil when all the records are valid)
+func (ts *TimeSeries) invalid() (mask []bool) {
+	mask = ts.Mask
+	copied := false
+	for index := 0; index < len(ts.Data); index++ {
+		if math.IsNaN(ts.Data[index]) {
+			// The mask of the time series is not changed
+			if !copied {
+				mask = make([]bool, len(ts.Data))
+				copy(mask, ts.Mask)
+				copied = true
+			}
+			mask[index] = true
+		}
+	}
+	return
+}
+
+// Returns the position of the calendar day of each record (starting at zero) and the date of each day
+func (ts *TimeSeries) days() (positions []int, dates []time.Time) {
+	for index := 0; index < len(ts.DateTime); index++ {
+		year, month, day := ts.DateTime[index].Date()
+		date := time.Date(year, month, day, 0, 0, 0, 0, ts.DateTime[index].Location())
+		if len(dates) == 0 || !date.Equal(dates[len(dates)-1]) {
+			dates = append(dates, date)
+		}
+		positions = append(positions, len(dates)-1)
+	}
+	return
+}
+
+// DailyCoverage returns the date (midnight) and the valid duration (records not masked and not NaN)
+// of each calendar day of the time series
+func (ts *TimeSeries) DailyCoverage() (dates []time.Time, valid []time.Duration, err error) {
+
+	if err = ts.check("DailyCoverage"); err != nil {
+		return
+	}
+	if ts.Epoch == 0 {
+		err = newError("DailyCoverage", ErrInvalidEpoch)
+		return
+	}
+
+	positions, dates := ts.days()
+	valid = make([]time.Duration, len(dates))
+	for index := 0; index < len(ts.Data); index++ {
+		if ts.valid(index) {
+			valid[positions[index]] += time.Duration(ts.Epoch) * time.Second
+		}
+	}
+
+	return
+}
+
+// Returns a copy of the time series where the mask also includes the NaN values and the days below the minimum coverage.
+// It returns ErrInsufficientCoverage when no day reaches the minimum coverage
+func (ts *TimeSeries) withCoverage(function string) (newTs *TimeSeries, err error) {
+
+	mask := ts.invalid()
+
+	if ts.MinCoverage.Day > 0 {
+		dates, valid, coverageErr := ts.DailyCoverage()
+		if coverageErr != nil {
+			return nil, newError(function, coverageErr)
+		}
+
+		positions, _ := ts.days()
+		used := 0
+		for day := 0; day < len(dates); day++ {
+			if valid[day] >= ts.MinCoverage.Day {
+				used++
+			}
+		}
+		if used == 0 {
+			return nil, newError(function, ErrInsufficientCoverage)
+		}
+
+		newMask := make([]bool, len(ts.Data))
+		for index := 0; index < len(ts.Data); index++ {
+			newMask[index] = (mask != nil && mask[index]) || valid[positions[index]] < ts.MinCoverage.Day
+		}
+		mask = newMask
+	}
+
+	newTs = ts.derive(ts.DateTime, ts.Data, ts.Epoch)
+	newTs.Mask = mask
+	newTs.MinCoverage.Day = 0
+	return
+}
+
+// Creates a time series that shares the slices passed by parameter (used internally to avoid copies)
+func wrapTimeSeries(function string, dateTime []time.Time, data []float64) (ts *TimeSeries, err error) {
+	if err = checkSlices(function, dateTime, data); err != nil {
+		return
+	}
+	ts = &TimeSeries{
+		DateTime: dateTime,
+		Data:     data,
+		Epoch:    FindEpoch(dateTime),
+		Location: dateTime[0].Location(),
+	}
+	return
+}
+
+// Creates a new time series based on another one, keeping its time zone and metadata (the mask is not kept)
+func (ts *TimeSeries) derive(dateTime []time.Time, data []float64, epoch int) *TimeSeries {
+	return &TimeSeries{
+		DateTime:    dateTime,
+		Data:        data,
+		Epoch:       epoch,
+		Location:    ts.Location,
+		Metadata:    ts.Metadata,
+		MinCoverage: ts.MinCoverage,
+	}
+}
+
+// NewTimeSeries creates a time series from the dateTime and data slices, finding its epoch.
+// The timestamps must be in ascending order
+func NewTimeSeries(dateTime []time.Time, data []float64) (ts *TimeSeries, err error) {
+	if err = checkSlices("NewTimeSeries", dateTime, data); err != nil {
+		return
+	}
+	for index := 1; index < len(dateTime); index++ {
+		if dateTime[index].Before(dateTime[index-1]) {
+			err = indexError("NewTimeSeries", ErrUnsorted, index)
+			return
+		}
+	}
+
+	newDateTime := make([]time.Time, len(dateTime))
+	copy(newDateTime, dateTime)
+	newData := make([]float64, len(data))
+	copy(newData, data)
+
+	ts, err = wrapTimeSeries("NewTimeSeries", newDateTime, newData)
+	if err == nil {
+		ts.Metadata = make(map[string]string)
+	}
+	return
+}
+
+// Len returns the number of records in the time series
+func (ts *TimeSeries) Len() int {
+	return len(ts.DateTime)
+}
+
+// Duration returns the time elapsed between the first and the last records
+func (ts *TimeSeries) Duration() time.Duration {
+	if len(ts.DateTime) == 0 {
+		return 0
+	}
+	return time.Duration(secondsTo(ts.DateTime[0], ts.DateTime[len(ts.DateTime)-1])) * time.Second
+}
+
+// In returns a copy of the time series with the timestamps converted to the location passed by parameter
+func (ts *TimeSeries) In(location *time.Location) *TimeSeries {
+	newDateTime := make([]time.Time, len(ts.DateTime))
+	for index := 0; index < len(ts.DateTime); index++ {
+		newDateTime[index] = ts.DateTime[index].In(location)
+	}
+	newData := make([]float64, len(ts.Data))
+	copy(newData, ts.Data)
+
+	newTs := ts.derive(newDateTime, newData, ts.Epoch)
+	newTs.Location = location
+	if ts.Mask != nil {
+		newTs.Mask = make([]bool, len(ts.Mask))
+		copy(newTs.Mask, ts.Mask)
+	}
+	return newTs
+}
+
+// Reports whether a window has the minimum coverage (count is the number of valid records in the window)
+func (ts *TimeSeries) covered(count int, duration time.Duration) bool {
+	if count == 0 {
+		return false
+	}
+	if ts.MinCoverage.Window <= 0 {
+		return true
+	}
+	return float64(time.Duration(count*ts.Epoch)*time.Second) >= ts.MinCoverage.Window*float64(duration)
+}
+
+// Returns the error used when no window has the minimum coverage
+func (ts *TimeSeries) uncoveredError() error {
+	if ts.MinCoverage.Window > 0 {
+		return ErrInsufficientCoverage
+	}
+	return ErrMasked
+}
+
+// Calculates the activity average of each window of X hours starting at each record, using prefix sums of the valid records,
+// so the time series is scanned only once. The window includes the records from its start until the start + X hours
+// (based on the timestamps, so gaps are respected) and only the windows that end within the time series are returned.
+// The windows below the minimum coverage are not used (covered is false)
+func (ts *TimeSeries) windowAverages(hours int) (onsets []time.Time, averages []float64, covered []bool) {
+
+	dateTime := ts.DateTime
+	duration := time.Duration(hours) * time.Hour
+
+	// The position zero is allocated, so the sum of the records from i to j - 1 is sums[j] - sums[i]
+	sums := make([]float64, len(dateTime)+1)
+	counts := make([]int, len(dateTime)+1)
+	for index := 0; index < len(dateTime); index++ {
+		sums[index+1] = sums[index]
+		counts[index+1] = counts[index]
+		if ts.valid(index) {
+			sums[index+1] += ts.Data[index]
+			counts[index+1]++
+		}
+	}
+
+	last := dateTime[len(dateTime)-1]
+	end := 0
+
+	for index := 0; index < len(dateTime); index++ {
+
+		finalDateTime := dateTime[index].Add(duration)
+		if finalDateTime.After(last) {
+			break
+		}
+
+		count := 0
+		average := 0.0
+
+		if dateTime[index].Before(finalDateTime) {
+
+			// The window includes its first record and the end only moves forward
+			if end <= index {
+				end = index + 1
+			}
+			for end < len(dateTime) && dateTime[end].Before(finalDateTime) {
+				end++
+			}
+
+			count = counts[end] - counts[index]
+			if count > 0 {
+				average = (sums[end] - sums[index]) / float64(count)
+			}
+		}
+
+		onsets = append(onsets, dateTime[index])
+		averages = append(averages, average)
+		covered = append(covered, ts.covered(count, duration))
+	}
+
+	return
+}
+
+// Checks the parameters of the HigherActivity and LowerActivity
+func (ts *TimeSeries) checkActivity(function string, hours int) error {
+	if hours <= 0 {
+		return newError(function, ErrInvalidHours)
+	}
+	if err := ts.check(function); err != nil {
+		return err
+	}
+	if ts.MinCoverage.Window > 0 && ts.Epoch == 0 {
+		return newError(function, ErrInvalidEpoch)
+	}
+	if ts.DateTime[0].Add(time.Duration(hours) * time.Hour).After(ts.DateTime[len(ts.DateTime)-1]) {
+		return durationError(function, time.Duration(hours)*time.Hour, ts.Duration())
+	}
+	return nil
+}
+
+// HigherActivity finds the highest activity average of the followed X hours (defined by parameter).
+// The masked records and the NaN values are not used, neither the windows below the minimum coverage
+// (it returns ErrMasked or ErrInsufficientCoverage when no window can be used)
+func (ts *TimeSeries) HigherActivity(hours int) (higherActivity float64, onsetHigherActivity time.Time, err error) {
+
+	// Check the parameters
+	if err = ts.checkActivity("HigherActivity", hours); err != nil {
+		return
+	}
+
+	onsets, averages, covered := ts.windowAverages(hours)
+
+	found := false
+
+	for index, currentActivity := range averages {
+
+		// Windows below the minimum coverage are not used
+		if !covered[index] {
+			continue
+		}
+		found = true
+
+		if currentActivity > higherActivity || floatEquals(higherActivity, 0.0) {
+			higherActivity = roundPlus(currentActivity, 4)
+			onsetHigherActivity = onsets[index]
+		}
+	}
+
+	if !found {
+		err = newError("HigherActivity", ts.uncoveredError())
+	}
+
+	return
+}
+
+// LowerActivity finds the lowest activity average of the followed X hours (defined by parameter).
+// The masked records and the NaN values are not used, neither the windows below the minimum coverage
+// (it returns ErrMasked or ErrInsufficientCoverage when no window can be used)
+func (ts *TimeSeries) LowerActivity(hours int) (lowerActivity float64, onsetLowerActivity time.Time, err error) {
+
+	// Check the parameters
+	if err = ts.checkActivity("LowerActivity", hours); err != nil {
+		return
+	}
+
+	onsets, averages, covered := ts.windowAverages(hours)
+
+	firstTime := true
+
+	for index, currentActivity := range averages {
+
+		// Windows below the minimum coverage are not used
+		if !covered[index] {
+			continue
+		}
+
+		if currentActivity < lowerActivity || firstTime == true {
+			lowerActivity = roundPlus(currentActivity, 4)
+			onsetLowerActivity = onsets[index]
+			firstTime = false
+		}
+	}
+
+	if firstTime {
+		err = newError("LowerActivity", ts.uncoveredError())
+	}
+
+	return
+}
+
+// M10 finds the highest activity average of the followed 10 hours
+func (ts *TimeSeries) M10() (higherActivity float64, onsetHigherActivity time.Time, err error) {
+	return ts.HigherActivity(10)
+}
+
+// L5 finds the lowest activity average of the following 5 hours
+func (ts *TimeSeries) L5() (lowerActivity float64, onsetLowerActivity time.Time, err error) {
+	return ts.LowerActivity(5)
+}
+
+// RelativeAmplitude calculates the relative amplitude of the time series based on its M10 and L5
+func (ts *TimeSeries) RelativeAmplitude() (RA float64, err error) {
+	m10, _, err := ts.M10()
+	if err != nil {
+		return
+	}
+	l5, _, err := ts.L5()
+	if err != nil {
+		return
+	}
+	return RelativeAmplitude(m10, l5)
+}
+
+// IntradailyVariability calculates the intradaily variability of the time series. The masked records,
+// the NaN values and the days below the minimum coverage are not used
+func (ts *TimeSeries) IntradailyVariability() (iv []float64, err error) {
+
+	prepared, err := ts.prepareVariability("IntradailyVariability")
+	if err != nil {
+		return
+	}
+
+	// The zero position is allocated to store the average value of the iv vector
+	iv = append(iv, 0.0)
+
+	for mainIndex := 1; mainIndex <= 60; mainIndex++ {
+
+		converted, convertErr := prepared.ConvertDataBasedOnEpoch(mainIndex * 60)
+
+		if convertErr != nil {
+			err = newError("IntradailyVariability", convertErr)
+			return nil, err
+		}
+
+		iv = append(iv, converted.variability())
+	}
+
+	// Calculates the IV average
+	var average float64
+	for index := 1; index < len(iv); index++ {
+		average += iv[index]
+	}
+	average = average / float64(len(iv)-1)
+	iv[0] = average
+
+	return
+}
+
+// Prepares the time series used by the intradaily variability (the days below the minimum coverage are masked)
+func (ts *TimeSeries) prepareVariability(function string) (prepared *TimeSeries, err error) {
+
+	dateTime := ts.DateTime
+
+	if err = ts.check(function); err != nil {
+		return
+	}
+	if secondsTo(dateTime[0], dateTime[len(dateTime)-1]) < (2 * 60 * 60) {
+		err = durationError(function, 2*time.Hour, ts.Duration())
+		return
+	}
+
+	return ts.withCoverage(function)
+}
+
+// Calculates the intradaily variability of the time series (already converted to the bin).
+// The masked records are not used (nor the differences involving them) and it returns zero when there is no valid data
+func (ts *TimeSeries) variability() float64 {
+
+	tempData := ts.Data
+
+	var validData []float64
+	for index := 0; index < len(tempData); index++ {
+		if ts.valid(index) {
+			validData = append(validData, tempData[index])
+		}
+	}
+
+	if len(validData) == 0 {
+		return 0.0
+	}
+
+	average := average(validData)
+
+	// Calculates the numerator
+	var numerator float64
+	pairs := 0
+	for index := 1; index < len(tempData); index++ {
+		if ts.valid(index) && ts.valid(index-1) {
+			tempValue := tempData[index] - tempData[index-1]
+			numerator += math.Pow(tempValue, 2)
+			pairs++
+		}
+	}
+	numerator = numerator * float64(len(validData))
+
+	// Calculates the denominator
+	var denominator float64
+	for index := 0; index < len(validData); index++ {
+		tempValue := average - validData[index]
+		denominator += math.Pow(tempValue, 2)
+	}
+	denominator = denominator * float64(pairs)
+
+	return roundPlus((numerator / denominator), 4)
+}
+
+// ConvertDataBasedOnEpoch converts the time series to the new epoch passed by parameter.
+// The masked records and the NaN values are not used and a new record is masked when all its records are invalid
+func (ts *TimeSeries) ConvertDataBasedOnEpoch(newEpoch int) (newTs *TimeSeries, err error) {
+
+	dateTime := ts.DateTime
+	data := ts.Data
+
+	// Check the parameters
+	if err = ts.check("ConvertDataBasedOnEpoch"); err != nil {
+		return
+	}
+	if newEpoch <= 0 {
+		err = newError("ConvertDataBasedOnEpoch", ErrInvalidEpoch)
+		return
+	}
+
+	currentEpoch := ts.Epoch
+
+	// Could not find the epoch
+	if currentEpoch == 0 {
+		err = newError("ConvertDataBasedOnEpoch", ErrInvalidEpoch)
+		return
+	}
+	if newEpoch == currentEpoch {
+		return ts, nil
+	}
+
+	var newDateTime []time.Time
+	var newData []float64
+	var newMask []bool
+	mask := ts.invalid()
+
+	// If the new Epoch is not divisible or multipliable by the currentEpoch
+	// It needs to be decreased to 1 second to then increase to the newEpoch
+	if (newEpoch > currentEpoch && newEpoch%currentEpoch != 0) ||
+		(currentEpoch > newEpoch && currentEpoch%newEpoch != 0) {
+
+		// Decrease to 1 second
+		dateTime, data, mask = decrease(dateTime, data, mask, currentEpoch, 1)
+
+		// Increase to the newEpoch
+		newDateTime, newData, newMask = increase(dateTime, data, mask, 1, newEpoch)
+
+	} else {
+		// Increase
+		if newEpoch > currentEpoch {
+			newDateTime, newData, newMask = increase(dateTime, data, mask, currentEpoch, newEpoch)
+
+			// Decrease
+		} else {
+			newDateTime, newData, newMask = decrease(dateTime, data, mask, currentEpoch, newEpoch)
+		}
+	}
+
+	newTs = ts.derive(newDateTime, newData, newEpoch)
+	newTs.Mask = newMask
+	return
+}
+
+// FilterDataByDateTime filters the time series based on the startTime and endTime passed as parameter
+func (ts *TimeSeries) FilterDataByDateTime(startTime time.Time, endTime time.Time) (newTs *TimeSeries, err error) {
+
+	dateTime := ts.DateTime
+	data := ts.Data
+
+	// Check the parameters
+	if err = ts.check("FilterDataByDateTime"); err != nil {
+		return
+	}
+	if endTime.Before(startTime) {
+		err = newError("FilterDataByDateTime", ErrInvalidTimeRange)
+		return
+	}
+
+	var newDateTime []time.Time
+	var newData []float64
+	var newMask []bool
+
+	// Filter the data based on the startTime and endTime
+	for index := 0; index < len(dateTime); index++ {
+
+		if (dateTime[index].After(startTime) || dateTime[index].Equal(startTime)) &&
+			(dateTime[index].Before(endTime) || dateTime[index].Equal(endTime)) {
+
+			newDateTime = append(newDateTime, dateTime[index])
+			newData = append(newData, data[index])
+			if ts.Mask != nil {
+				newMask = append(newMask, ts.Mask[index])
+			}
+		}
+	}
+
+	newTs = ts.derive(newDateTime, newData, ts.Epoch)
+	newTs.Mask = newMask
+	return
+}
+
+// InterdailyStability calculates the interdaily stability of the time series. The masked records,
+// the NaN values and the days below the minimum coverage are not used
+func (ts *TimeSeries) InterdailyStability() (is []float64, err error) {
+	return ts.interdailyStability("InterdailyStability", DefaultPeriod)
+}
+
+// InterdailyStabilityPeriod calculates the interdaily stability folding the time series modulo the period passed
+// by parameter (e.g. the free-running period estimated by a periodogram) instead of 24 hours. The period should be
+// a whole number of minutes and it is DefaultPeriod when zero
+func (ts *TimeSeries) InterdailyStabilityPeriod(period time.Duration) (is []float64, err error) {
+	if period == 0 {
+		period = DefaultPeriod
+	}
+	return ts.interdailyStability("InterdailyStabilityPeriod", period)
+}
+
+// Calculates the interdaily stability using the period passed by parameter as the "day"
+func (ts *TimeSeries) interdailyStability(function string, period time.Duration) (is []float64, err error) {
+
+	minutes, err := ts.prepareStability(function, period)
+	if err != nil {
+		return
+	}
+	periodMinutes := int(period / time.Minute)
+
+	// The zero position is allocated to store the average value of the IS vector
+	is = append(is, 0.0)
+
+	// Calculate all 60 IS values
+	for isIndex := 1; isIndex <= 60; isIndex++ {
+
+		if periodMinutes%isIndex == 0 {
+			value, stabilityErr := minutes.stabilityBin(isIndex, period)
+			if stabilityErr != nil {
+				return nil, newError(function, stabilityErr)
+			}
+			is = append(is, value)
+		} else {
+			// Append -1 in the positions that will not be used
+			is = append(is, -1.0)
+		}
+	}
+
+	// Calculates the IS average of all "valid" values
+	average := 0.0
+	count := 0
+
+	for index := 1; index < len(is); index++ {
+		if is[index] > -1.0 {
+			average += is[index]
+			count++
+		}
+	}
+
+	if count > 0 {
+		is[0] = average / float64(count)
+	} else {
+		is[0] = -1.0
+	}
+
+	return
+}
+
+// Prepares the time series used by the interdaily stability: the days below the minimum coverage are masked,
+// the data is converted to 1 minute epoch and only the entire periods are kept
+func (ts *TimeSeries) prepareStability(function string, period time.Duration) (minutes *TimeSeries, err error) {
+
+	dateTime := ts.DateTime
+	data := ts.Data
+
+	// Check the parameters
+	if err = ts.check(function); err != nil {
+		return
+	}
+	if period <= 0 || period%time.Minute != 0 {
+		err = newError(function, ErrInvalidPeriod)
+		return
+	}
+	if ts.Duration() < 2*period {
+		err = durationError(function, 2*period, ts.Duration())
+		return
+	}
+
+	currentEpoch := ts.Epoch
+
+	// Could not find the epoch
+	if currentEpoch == 0 {
+		err = newError(function, ErrInvalidEpoch)
+		return
+	}
+
+	// The days below the minimum coverage are masked
+	prepared, err := ts.withCoverage(function)
+	if err != nil {
+		return
+	}
+	mask := prepared.Mask
+
+	if currentEpoch != 60 {
+		converted, convertErr := prepared.ConvertDataBasedOnEpoch(60)
+		if convertErr != nil {
+			err = newError(function, convertErr)
+			return
+		}
+
+		dateTime = converted.DateTime
+		data = converted.Data
+		mask = converted.Mask
+	}
+
+	// The data should be divisible by the period (entire cycles)
+	periodMinutes := int(period / time.Minute)
+	for len(dateTime)%periodMinutes != 0 {
+		// Remove the last data
+		dateTime = dateTime[:len(dateTime)-1]
+		data = data[:len(data)-1]
+		if mask != nil {
+			mask = mask[:len(mask)-1]
+		}
+	}
+
+	minutes = prepared.derive(dateTime, data, 60)
+	minutes.Mask = mask
+	return
+}
+
+// Calculates the interdaily stability of the 1 minute time series normalized to the bin (minutes) passed by parameter
+func (ts *TimeSeries) stabilityBin(bin int, period time.Duration) (is float64, err error) {
+
+	// Normalizes data to the new epoch (minutes)
+	temporaryDateTime, temporaryData, temporaryMask, _ := normalizeMaskedIS(ts.DateTime, ts.Data, ts.Mask, bin)
+
+	normalized := ts.derive(temporaryDateTime, temporaryData, bin*60)
+	normalized.Mask = temporaryMask
+	return normalized.stability(period)
+}
+
+// Calculates the interdaily stability of the time series (already normalized to the bin) using the period passed
+// by parameter. It returns -1 when the data does not vary
+func (ts *TimeSeries) stability(period time.Duration) (is float64, err error) {
+
+	// Calculate the average day
+	averageDay, err := ts.averageCycle("AverageDay", period)
+	if err != nil {
+		return
+	}
+
+	// Only the valid records and the points of the average day with data are used
+	var validData []float64
+	for index := 0; index < len(ts.Data); index++ {
+		if ts.valid(index) {
+			validData = append(validData, ts.Data[index])
+		}
+	}
+	var averageDayData []float64
+	for index := 0; index < len(averageDay.Data); index++ {
+		if !math.IsNaN(averageDay.Data[index]) {
+			averageDayData = append(averageDayData, averageDay.Data[index])
+		}
+	}
+
+	// Get the new N (length)
+	n := len(validData)
+
+	// Calculate the number of points per day
+	p := len(averageDayData)
+
+	// Calculate the new average (Xm)
+	average := average(validData)
+
+	numerator := 0.0
+	denominator := 0.0
+
+	// The "h" value represents the same "h" from the IS calculation formula
+	for h := 0; h < p; h++ {
+		numerator += math.Pow((averageDayData[h] - average), 2)
+	}
+
+	// The "i" value represents the same "i" from the IS calculation formula
+	for i := 0; i < n; i++ {
+		denominator += math.Pow((validData[i] - average), 2)
+	}
+
+	numerator = float64(n) * numerator
+	denominator = float64(p) * denominator
+
+	// Prevent NaN
+	if denominator == 0 {
+		return -1.0, nil
+	}
+	return numerator / denominator, nil
+}
+
+// FillGapsInData searches for gaps in the time series and fills it with a specific value passed as parameter (usually zero).
+// The mask is kept and the new records are not masked
+func (ts *TimeSeries) FillGapsInData(value float64) (newTs *TimeSeries, err error) {
+
+	dateTime := ts.DateTime
+	data := ts.Data
+
+	// Check the parameters
+	if err = ts.check("FillGapsInData"); err != nil {
+		return
+	}
+
+	currentEpoch := ts.Epoch
+
+	// Could not find the epoch
+	if currentEpoch == 0 {
+		err = newError("FillGapsInData", ErrInvalidEpoch)
+		return
+	}
+
+	var newDateTime []time.Time
+	var newData []float64
+	var newMask []bool
+
+	for index := 0; index < len(dateTime)-1; index++ {
+
+		newDateTime = append(newDateTime, dateTime[index])
+		newData = append(newData, data[index])
+		if ts.Mask != nil {
+			newMask = append(newMask, ts.Mask[index])
+		}
+
+		// If this condition is true, then this is a gap
+		if secondsTo(dateTime[index], dateTime[index+1]) >= (currentEpoch * 2) {
+
+			tempDateTime := dateTime[index]
+			count := (secondsTo(dateTime[index], dateTime[index+1]) / currentEpoch) - 1
+
+			for tempIndex := 0; tempIndex < count; tempIndex++ {
+				tempDateTime = tempDateTime.Add(time.Duration(currentEpoch) * time.Second)
+				newDateTime = append(newDateTime, tempDateTime)
+				newData = append(newData, value)
+				if ts.Mask != nil {
+					newMask = append(newMask, false)
+				}
+			}
+		}
+	}
+
+	newDateTime = append(newDateTime, dateTime[len(dateTime)-1])
+	newData = append(newData, data[len(dateTime)-1])
+	if ts.Mask != nil {
+		newMask = append(newMask, ts.Mask[len(dateTime)-1])
+	}
+
+	newTs = ts.derive(newDateTime, newData, currentEpoch)
+	newTs.Mask = newMask
+	return
+}
+
+// AverageDay creates an average day based on the time series. The gaps, the masked records, the NaN values
+// and the days below the minimum coverage are not used
+func (ts *TimeSeries) AverageDay() (newTs *TimeSeries, err error) {
+	return ts.averageCycle("AverageDay", DefaultPeriod)
+}
+
+// AverageCycle creates an average cycle folding the time series modulo the period passed by parameter
+// (e.g. 23.6 hours for an animal in free-run). The period should be a multiple of the epoch and it is DefaultPeriod when zero
+// (as AverageDay, the default period is truncated when the epoch does not divide it).
+// The gaps, the masked records, the NaN values and the days below the minimum coverage are not used
+func (ts *TimeSeries) AverageCycle(period time.Duration) (newTs *TimeSeries, err error) {
+	if period == 0 {
+		period = DefaultPeriod
+	}
+
+	// The other periods must be a multiple of the epoch
+	epoch := time.Duration(ts.Epoch) * time.Second
+	if period < 0 || (period != DefaultPeriod && ts.Epoch > 0 && period%epoch != 0) {
+		err = newError("AverageCycle", ErrInvalidPeriod)
+		return
+	}
+	return ts.averageCycle("AverageCycle", period)
+}
+
+// Creates the average cycle using the period passed by parameter
+func (ts *TimeSeries) averageCycle(function string, period time.Duration) (newTs *TimeSeries, err error) {
+
+	dateTime := ts.DateTime
+	data := ts.Data
+
+	// Check the parameters
+	if err = ts.check(function); err != nil {
+		return
+	}
+
+	currentEpoch := ts.Epoch
+
+	// Could not find the epoch
+	if currentEpoch == 0 {
+		err = newError(function, ErrInvalidEpoch)
+		return
+	}
+
+	// The number of points is truncated when the epoch does not divide the period (e.g. 17 seconds in 24 hours)
+	epoch := time.Duration(currentEpoch) * time.Second
+	if period < epoch {
+		err = newError(function, ErrInvalidPeriod)
+		return
+	}
+
+	if ts.Duration() < period {
+		err = durationError(function, period, ts.Duration())
+		return
+	}
+
+	// The days below the minimum coverage are masked
+	prepared, err := ts.withCoverage(function)
+	if err != nil {
+		return
+	}
+
+	// The gaps are filled with NaN, so they are not used
+	filled, _ := prepared.FillGapsInData(math.NaN())
+	dateTime = filled.DateTime
+	data = filled.Data
+
+	pointsPerDay := int(period / epoch)
+
+	var newDateTime []time.Time
+	var newData []float64
+	var countPoints []int
+
+	for index := 0; index < pointsPerDay; index++ {
+		newData = append(newData, 0.0)
+		countPoints = append(countPoints, 0)
+	}
+
+	pointIndex := 0
+	for index := 0; index < len(data); index++ {
+		if pointIndex >= pointsPerDay {
+			pointIndex = 0
+		}
+
+		if filled.valid(index) {
+			newData[pointIndex] += data[index]
+			countPoints[pointIndex] += 1
+		}
+
+		pointIndex++
+	}
+
+	tempDateTime := dateTime[0]
+	for index := 0; index < len(newData); index++ {
+		newDateTime = append(newDateTime, tempDateTime)
+		tempDateTime = tempDateTime.Add(time.Duration(currentEpoch) * time.Second)
+		newData[index] = roundPlus((newData[index] / float64(countPoints[index])), 4)
+	}
+
+	newTs = ts.derive(newDateTime, newData, currentEpoch)
+	return
+}
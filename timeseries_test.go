@@ -0,0 +1,462 @@
+package chronobiology
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewTimeSeries(t *testing.T) {
+
+	utc, _ := time.LoadLocation("UTC")
+	tempDateTime := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	// Empty slices
+	_, err := NewTimeSeries(nil, nil)
+
+	if err == nil {
+		t.Error("Expected: Empty")
+	}
+
+	// Different sizes
+	_, err = NewTimeSeries([]time.Time{tempDateTime}, []float64{10.0, 20.0})
+
+	if err == nil {
+		t.Error("Expected: DifferentSize")
+	}
+
+	var dateTime []time.Time
+	var data []float64
+
+	for index := 0; index < 120; index++ {
+		dateTime = append(dateTime, tempDateTime)
+		data = append(data, float64(index))
+		tempDateTime = tempDateTime.Add(30 * time.Second)
+	}
+
+	ts, err := NewTimeSeries(dateTime, data)
+
+	if err != nil {
+		t.Error("Expected error = nil.")
+	}
+	if ts.Epoch != 30 {
+		t.Error(
+			"Expected: 30",
+			"Received: ", ts.Epoch,
+		)
+	}
+	if ts.Len() != 120 {
+		t.Error(
+			"Expected: 120",
+			"Received: ", ts.Len(),
+		)
+	}
+	if ts.Duration() != 119*30*time.Second {
+		t.Error(
+			"Expected: ", 119*30*time.Second,
+			"Received: ", ts.Duration(),
+		)
+	}
+	if ts.Location != utc {
+		t.Error("Expected location: UTC")
+	}
+	if ts.Metadata == nil {
+		t.Error("Expected metadata not nil")
+	}
+
+	// The time series must own its slices
+	data[0] = 999.0
+	if floatEquals(ts.Data[0], 999.0) {
+		t.Error("Expected the time series to copy the data slice")
+	}
+
+	// Single record (the epoch cannot be found)
+	ts, err = NewTimeSeries(dateTime[:1], data[:1])
+
+	if err != nil {
+		t.Error("Expected error = nil.")
+	}
+	if ts.Epoch != 0 {
+		t.Error(
+			"Expected: 0",
+			"Received: ", ts.Epoch,
+		)
+	}
+}
+
+func TestTimeSeriesIn(t *testing.T) {
+
+	utc, _ := time.LoadLocation("UTC")
+	location := time.FixedZone("UTC-3", -3*60*60)
+
+	dateTime := []time.Time{
+		time.Date(2015, 1, 1, 0, 0, 0, 0, utc),
+		time.Date(2015, 1, 1, 0, 1, 0, 0, utc),
+	}
+	data := []float64{10.0, 20.0}
+
+	ts, _ := NewTimeSeries(dateTime, data)
+	ts.Metadata["subject"] = "S01"
+
+	newTs := ts.In(location)
+
+	if newTs.Location != location {
+		t.Error("Expected location: UTC-3")
+	}
+	if newTs.DateTime[0].Hour() != 21 {
+		t.Error(
+			"Expected: 21",
+			"Received: ", newTs.DateTime[0].Hour(),
+		)
+	}
+	if !sliceTimeEquals(newTs.DateTime, ts.DateTime) {
+		t.Error("Expected the same instants in time")
+	}
+	if newTs.Epoch != 60 || newTs.Metadata["subject"] != "S01" {
+		t.Error("Expected the epoch and metadata to be kept")
+	}
+	if ts.DateTime[0].Location() != utc {
+		t.Error("Expected the original time series to be kept")
+	}
+}
+
+func TestTimeSeriesMethods(t *testing.T) {
+
+	utc, _ := time.LoadLocation("UTC")
+	tempDateTime := time.Date(2015, 1, 1, 0, 0, 0, 0, utc)
+
+	var dateTime []time.Time
+	var data []float64
+
+	// 3 days of data with 2 minutes epoch
+	for index := 0; index < 3*720; index++ {
+		if index < 1000 || index > 1010 {
+			dateTime = append(dateTime, tempDateTime)
+			data = append(data, float64((index%720)/60)*10.0+5.0)
+		}
+		tempDateTime = tempDateTime.Add(2 * time.Minute)
+	}
+
+	ts, err := NewTimeSeries(dateTime, data)
+
+	if err != nil {
+		t.Error("Expected error = nil.")
+	}
+
+	m10, onsetM10, _ := M10(dateTime, data)
+	tsM10, tsOnsetM10, _ := ts.M10()
+	if !floatEquals(m10, tsM10) || !onsetM10.Equal(tsOnsetM10) {
+		t.Error("Different M10 results.")
+	}
+
+	l5, onsetL5, _ := L5(dateTime, data)
+	tsL5, tsOnsetL5, _ := ts.L5()
+	if !floatEquals(l5, tsL5) || !onsetL5.Equal(tsOnsetL5) {
+		t.Error("Different L5 results.")
+	}
+
+	ra, _ := RelativeAmplitude(m10, l5)
+	tsRA, err := ts.RelativeAmplitude()
+	if err != nil || !floatEquals(ra, tsRA) {
+		t.Error("Different RA results.")
+	}
+
+	iv, _ := IntradailyVariability(dateTime, data)
+	tsIV, _ := ts.IntradailyVariability()
+	if !sliceFloatEquals(iv, tsIV) {
+		t.Error("Different IV results.")
+	}
+
+	is, _ := InterdailyStability(dateTime, data)
+	tsIS, _ := ts.InterdailyStability()
+	if !sliceFloatEquals(is, tsIS) {
+		t.Error("Different IS results.")
+	}
+
+	newDateTime, newData, _ := ConvertDataBasedOnEpoch(dateTime, data, 60)
+	converted, _ := ts.ConvertDataBasedOnEpoch(60)
+	if !sliceTimeEquals(newDateTime, converted.DateTime) || !sliceFloatEquals(newData, converted.Data) {
+		t.Error("Different ConvertDataBasedOnEpoch results.")
+	}
+	if converted.Epoch != 60 {
+		t.Error(
+			"Expected: 60",
+			"Received: ", converted.Epoch,
+		)
+	}
+
+	startTime := time.Date(2015, 1, 2, 0, 0, 0, 0, utc)
+	endTime := time.Date(2015, 1, 2, 12, 0, 0, 0, utc)
+	newDateTime, newData, _ = FilterDataByDateTime(dateTime, data, startTime, endTime)
+	filtered, _ := ts.FilterDataByDateTime(startTime, endTime)
+	if !sliceTimeEquals(newDateTime, filtered.DateTime) || !sliceFloatEquals(newData, filtered.Data) {
+		t.Error("Different FilterDataByDateTime results.")
+	}
+
+	newDateTime, newData, _ = FillGapsInData(dateTime, data, 0.0)
+	filled, _ := ts.FillGapsInData(0.0)
+	if !sliceTimeEquals(newDateTime, filled.DateTime) || !sliceFloatEquals(newData, filled.Data) {
+		t.Error("Different FillGapsInData results.")
+	}
+	if filled.Len() != 3*720 {
+		t.Error(
+			"Expected: ", 3*720,
+			"Received: ", filled.Len(),
+		)
+	}
+
+	newDateTime, newData, _ = AverageDay(dateTime, data)
+	averageDay, _ := ts.AverageDay()
+	if !sliceTimeEquals(newDateTime, averageDay.DateTime) || !sliceFloatEquals(newData, averageDay.Data) {
+		t.Error("Different AverageDay results.")
+	}
+}
+
+func TestCoverage(t *testing.T) {
+
+	// 4 days with a daily pattern
+	var data []float64
+	for index := 0; index < 4*1440; index++ {
+		data = append(data, 100.0+50.0*math.Sin(2.0*math.Pi*float64(index)/1440.0)+float64(index%7))
+	}
+
+	// NaN values are handled as masked records
+	withNaN := minuteSeries(data)
+	masked := minuteSeries(data)
+	masked.Mask = make([]bool, len(data))
+	for index := 1440; index < 1440+14*60; index += 2 {
+		withNaN.Data[index] = math.NaN()
+		masked.Mask[index] = true
+	}
+
+	m10, onsetM10, err1 := withNaN.M10()
+	maskedM10, maskedOnsetM10, err2 := masked.M10()
+	if err1 != nil || err2 != nil || !floatEquals(m10, maskedM10) || !onsetM10.Equal(maskedOnsetM10) {
+		t.Error("Expected the same M10. Received: ", m10, maskedM10, err1, err2)
+	}
+
+	iv, err1 := withNaN.IntradailyVariability()
+	maskedIV, err2 := masked.IntradailyVariability()
+	if err1 != nil || err2 != nil || !sliceFloatEquals(iv, maskedIV) {
+		t.Error("Expected the same IV. Received: ", iv[0], maskedIV[0], err1, err2)
+	}
+
+	is, err1 := withNaN.InterdailyStability()
+	maskedIS, err2 := masked.InterdailyStability()
+	if err1 != nil || err2 != nil || !sliceFloatEquals(is, maskedIS) || math.IsNaN(is[0]) {
+		t.Error("Expected the same IS. Received: ", is[0], maskedIS[0], err1, err2)
+	}
+
+	// The second day has 17 hours of valid data
+	dates, valid, err := withNaN.DailyCoverage()
+	if err != nil || len(dates) != 4 || valid[0] != 24*time.Hour || valid[1] != 17*time.Hour {
+		t.Error("Unexpected daily coverage: ", dates, valid, err)
+	}
+
+	// Days below the minimum coverage are not used
+	withNaN.MinCoverage = Coverage{Day: 20 * time.Hour}
+	dayMasked := minuteSeries(data)
+	dayMasked.Mask = make([]bool, len(data))
+	for index := 1440; index < 2*1440; index++ {
+		dayMasked.Mask[index] = true
+	}
+
+	averageDay, err1 := withNaN.AverageDay()
+	dayMaskedAverageDay, err2 := dayMasked.AverageDay()
+	if err1 != nil || err2 != nil || !sliceFloatEquals(averageDay.Data, dayMaskedAverageDay.Data) {
+		t.Error("Expected the same average day. Received: ", err1, err2)
+	}
+
+	is, err1 = withNaN.InterdailyStability()
+	dayMaskedIS, err2 := dayMasked.InterdailyStability()
+	if err1 != nil || err2 != nil || !sliceFloatEquals(is, dayMaskedIS) {
+		t.Error("Expected the same IS. Received: ", is[0], dayMaskedIS[0], err1, err2)
+	}
+
+	withNaN.MinCoverage = Coverage{Day: 25 * time.Hour}
+	if _, err = withNaN.IntradailyVariability(); !errors.Is(err, ErrInsufficientCoverage) {
+		t.Error("Expected: ErrInsufficientCoverage, Received: ", err)
+	}
+
+	// The epochs without valid data are NaN (the missing data is not counted as zero)
+	missing := append(repeatValue(math.NaN(), 5), repeatValue(10.0, 5)...)
+	_, converted, err := ConvertDataBasedOnEpoch(minuteSeries(missing).DateTime, missing, 300)
+	if err != nil || len(converted) != 2 || !math.IsNaN(converted[0]) || converted[1] != 10.0 {
+		t.Error("Expected: [NaN 10], Received: ", converted, err)
+	}
+	_, converted, err = ConvertDataBasedOnEpoch(minuteSeries(missing).DateTime, missing, 150)
+	if err != nil || len(converted) != 4 || !math.IsNaN(converted[0]) || !math.IsNaN(converted[1]) || converted[3] != 10.0 {
+		t.Error("Expected: [NaN NaN 10 10], Received: ", converted, err)
+	}
+	convertedTs, err := minuteSeries(missing).ConvertDataBasedOnEpoch(300)
+	if err != nil || !convertedTs.Mask[0] || convertedTs.Mask[1] || !math.IsNaN(convertedTs.Data[0]) {
+		t.Error("Expected the first record masked. Received: ", convertedTs.Data, convertedTs.Mask, err)
+	}
+
+	// 5 hours of activity (10), the third hour has high activity (100) but half of it is missing
+	data = repeatValue(10.0, 5*60)
+	for index := 120; index < 180; index++ {
+		data[index] = 100.0
+		if index%2 == 0 {
+			data[index] = math.NaN()
+		}
+	}
+	windows := minuteSeries(data)
+
+	higherActivity, _, err := windows.HigherActivity(1)
+	if err != nil || !floatEquals(higherActivity, 100.0) {
+		t.Error("Expected: 100, Received: ", higherActivity, err)
+	}
+
+	// Only the windows with at most 6 missing minutes can be used (e.g. from 02:47 to 03:47)
+	windows.MinCoverage = Coverage{Window: 0.9}
+	higherActivity, onsetHigherActivity, err := windows.HigherActivity(1)
+	if err != nil || !floatEquals(higherActivity, 21.6667) || onsetHigherActivity.Hour() != 2 || onsetHigherActivity.Minute() != 47 {
+		t.Error("Expected: 21.6667 at 02:47, Received: ", higherActivity, onsetHigherActivity, err)
+	}
+
+	windows.MinCoverage = Coverage{Window: 1.0}
+	for index := 0; index < windows.Len(); index += 30 {
+		windows.Data[index] = math.NaN()
+	}
+	if _, _, err = windows.LowerActivity(1); !errors.Is(err, ErrInsufficientCoverage) {
+		t.Error("Expected: ErrInsufficientCoverage, Received: ", err)
+	}
+
+	// Invalid number of hours
+	for _, hours := range []int{0, -1} {
+		if _, _, err = windows.HigherActivity(hours); !errors.Is(err, ErrInvalidHours) {
+			t.Error("Expected: ErrInvalidHours, Received: ", err)
+		}
+		if _, _, err = windows.LowerActivity(hours); !errors.Is(err, ErrInvalidHours) {
+			t.Error("Expected: ErrInvalidHours, Received: ", err)
+		}
+	}
+}
+
+func TestPeriod(t *testing.T) {
+
+	// 6 cycles of 23.6 hours (1416 minutes) of a free-running rhythm
+	var data []float64
+	for index := 0; index < 6*1416; index++ {
+		phase := index % 1416
+		data = append(data, 100.0+50.0*math.Sin(2.0*math.Pi*float64(phase)/1416.0)+float64(phase%7))
+	}
+	ts := minuteSeries(data)
+
+	// The data repeats exactly with the free-running period
+	is, err := ts.InterdailyStabilityPeriod(23*time.Hour + 36*time.Minute)
+	if err != nil || math.Abs(is[0]-1.0) > 1e-6 || math.Abs(is[59]-1.0) > 1e-6 || is[5] != -1.0 || is[60] != -1.0 {
+		t.Error("Expected: 1, Received: ", is, err)
+	}
+	dayIS, err := ts.InterdailyStabilityPeriod(0)
+	defaultIS, _ := ts.InterdailyStability()
+	if err != nil || !sliceFloatEquals(dayIS, defaultIS) || dayIS[0] > 0.98 {
+		t.Error("Expected the same IS (below 0.98). Received: ", dayIS[0], defaultIS[0], err)
+	}
+
+	cycle := make([]float64, 1416)
+	for index := range cycle {
+		cycle[index] = roundPlus(data[index], 4)
+	}
+	averageCycle, err := ts.AverageCycle(23*time.Hour + 36*time.Minute)
+	if err != nil || !sliceFloatEquals(averageCycle.Data, cycle) || averageCycle.Epoch != 60 {
+		t.Error("Unexpected average cycle: ", averageCycle.Len(), err)
+	}
+	newDateTime, newData, err := AverageCycle(0, ts.DateTime, ts.Data)
+	averageDay, _ := ts.AverageDay()
+	if err != nil || !sliceTimeEquals(newDateTime, averageDay.DateTime) || !sliceFloatEquals(newData, averageDay.Data) {
+		t.Error("Different AverageDay results.")
+	}
+
+	// Invalid parameters
+	if _, err = InterdailyStabilityPeriod(23*time.Hour+30*time.Second, ts.DateTime, ts.Data); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = ts.InterdailyStabilityPeriod(-time.Hour); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = ts.InterdailyStabilityPeriod(72 * time.Hour); !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+	if _, err = ts.AverageCycle(90 * time.Second); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = ts.AverageCycle(240 * time.Hour); !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+
+	// The average day is truncated when the epoch does not divide 24 hours (86400 / 17 = 5082.35)
+	start := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	var oddDateTime []time.Time
+	var oddData []float64
+	for index := 0; index < 2*5083; index++ {
+		oddDateTime = append(oddDateTime, start.Add(time.Duration(17*index)*time.Second))
+		oddData = append(oddData, float64(index%5082))
+	}
+	odd, _ := NewTimeSeries(oddDateTime, oddData)
+	oddDay, err := odd.AverageDay()
+	if err != nil || oddDay.Len() != 5082 || oddDay.Epoch != 17 {
+		t.Error("Expected: 5082 points, Received: ", oddDay, err)
+	}
+	for _, period := range []time.Duration{0, DefaultPeriod} {
+		oddCycle, err := odd.AverageCycle(period)
+		if err != nil || !sliceTimeEquals(oddCycle.DateTime, oddDay.DateTime) || !sliceFloatEquals(oddCycle.Data, oddDay.Data) {
+			t.Error("Period: ", period, "Expected the same average day. Received: ", oddCycle, err)
+		}
+	}
+	if _, err = odd.AverageCycle(12 * time.Hour); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+}
+
+func TestWindowAverages(t *testing.T) {
+
+	// 2 days (30 seconds epoch) with gaps, NaN values and masked records
+	utc, _ := time.LoadLocation("UTC")
+	var dateTime []time.Time
+	var data []float64
+	for index := 0; index < 2*2880; index++ {
+		if index%97 < 5 || (index > 1000 && index < 1300) {
+			continue
+		}
+		dateTime = append(dateTime, time.Date(2015, 1, 1, 0, 0, index*30, 0, utc))
+		data = append(data, 100.0+80.0*noise(index))
+		if index%13 == 0 {
+			data[len(data)-1] = math.NaN()
+		}
+	}
+	ts, _ := NewTimeSeries(dateTime, data)
+	ts.Mask = make([]bool, ts.Len())
+	for index := 0; index < ts.Len(); index += 17 {
+		ts.Mask[index] = true
+	}
+	ts.MinCoverage = Coverage{Window: 0.8}
+
+	for _, hours := range []int{1, 5, 10} {
+		onsets, averages, covered := ts.windowAverages(hours)
+
+		// Scans each window (from the start until the start + hours)
+		for index := range onsets {
+			sum := 0.0
+			count := 0
+			for tempIndex := index; ts.DateTime[tempIndex].Before(onsets[index].Add(time.Duration(hours) * time.Hour)); tempIndex++ {
+				if ts.valid(tempIndex) {
+					sum += ts.Data[tempIndex]
+					count++
+				}
+			}
+			if !onsets[index].Equal(ts.DateTime[index]) || math.Abs(averages[index]-sum/float64(count)) > 1e-9 ||
+				covered[index] != ts.covered(count, time.Duration(hours)*time.Hour) {
+				t.Error("Unexpected window: ", hours, index, averages[index], sum/float64(count), covered[index])
+			}
+		}
+
+		// Only the windows that end within the time series
+		last := ts.DateTime[ts.Len()-1]
+		if onsets[len(onsets)-1].Add(time.Duration(hours)*time.Hour).After(last) || !ts.DateTime[len(onsets)].Add(time.Duration(hours)*time.Hour).After(last) {
+			t.Error("Unexpected number of windows: ", hours, len(onsets))
+		}
+	}
+}
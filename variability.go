@@ -0,0 +1,249 @@
+package chronobiology
+
+import (
+	"math"
+	"time"
+)
+
+// Binning represents the bins (epochs) used to calculate the interdaily stability and the intradaily variability
+type Binning int
+
+// Binnings used to calculate the interdaily stability and the intradaily variability
+const (
+	// Hourly uses 60 minutes bins, the classic definition (Witting et al., 1990) used by nparACT and pyActigraphy by default
+	Hourly Binning = iota
+	// Averaged averages the results of the bins from 1 to 60 minutes (ISm and IVm, Gonçalves et al., 2014). The IS only uses
+	// the bins that divide the period (as InterdailyStability) and the IV uses all of them (as IntradailyVariability)
+	Averaged
+	// Fixed uses the bin defined by the options
+	Fixed
+	// Native uses the epoch of the time series as the bin
+	Native
+)
+
+// VariabilityOptions defines the variant of the interdaily stability and intradaily variability
+type VariabilityOptions struct {
+	// Binning selects the bins used (Hourly by default)
+	Binning Binning
+	// Bin is the bin used by the Fixed binning
+	Bin time.Duration
+	// Period is the "day" used by the interdaily stability (DefaultPeriod when zero)
+	Period time.Duration
+}
+
+// VariabilityResult stores the interdaily stability or the intradaily variability
+type VariabilityResult struct {
+	// Value is the result (the average of the bins for the Averaged binning).
+	// It is NaN when the data does not vary
+	Value float64
+	// Bins stores the bins used
+	Bins []time.Duration
+	// Values stores the result of each bin (NaN when the data does not vary)
+	Values []float64
+}
+
+// Returns the bins defined by the options (the IS only uses the bins that divide the period)
+func (options VariabilityOptions) bins(function string, epoch int, period time.Duration) (bins []time.Duration, err error) {
+	switch options.Binning {
+	case Hourly:
+		bins = []time.Duration{time.Hour}
+	case Averaged:
+		for minutes := 1; minutes <= 60; minutes++ {
+			bin := time.Duration(minutes) * time.Minute
+			if period == 0 || period%bin == 0 {
+				bins = append(bins, bin)
+			}
+		}
+	case Fixed:
+		bins = []time.Duration{options.Bin}
+	case Native:
+		bins = []time.Duration{time.Duration(epoch) * time.Second}
+	default:
+		err = newError(function, ErrInvalidBinning)
+		return
+	}
+
+	// The period passed by the options must be divisible by the bins (the default period is checked as the bins)
+	if options.Period != 0 && len(bins) == 0 {
+		err = newError(function, ErrInvalidPeriod)
+		return
+	}
+	for _, bin := range bins {
+		if bin < time.Second || bin%time.Second != 0 {
+			err = newError(function, ErrInvalidEpoch)
+			return
+		}
+		if period > 0 && period%bin != 0 {
+			if options.Period != 0 {
+				err = newError(function, ErrInvalidPeriod)
+			} else {
+				err = newError(function, ErrInvalidEpoch)
+			}
+			return
+		}
+	}
+	return
+}
+
+// Stores the result of each bin and averages the defined results
+func (result *VariabilityResult) summarize() {
+	result.Value = 0.0
+	count := 0
+	for _, value := range result.Values {
+		if !math.IsNaN(value) {
+			result.Value += value
+			count++
+		}
+	}
+	if count == 0 {
+		result.Value = math.NaN()
+		return
+	}
+	result.Value /= float64(count)
+}
+
+// IS calculates the interdaily stability using the variant defined by the options (e.g. the classic hourly definition or ISm).
+// The bins of whole minutes give the same results as InterdailyStability. The other bins (e.g. the 30 seconds epoch of the time series)
+// use the data converted to the bin. The masked records, the NaN values and the days below the minimum coverage are not used
+func (ts *TimeSeries) IS(options VariabilityOptions) (result VariabilityResult, err error) {
+
+	period := options.Period
+	if period == 0 {
+		period = DefaultPeriod
+	}
+
+	// Check the parameters
+	if err = ts.check("IS"); err != nil {
+		return
+	}
+	if period < 0 || period%time.Second != 0 {
+		err = newError("IS", ErrInvalidPeriod)
+		return
+	}
+	if ts.Epoch == 0 {
+		err = newError("IS", ErrInvalidEpoch)
+		return
+	}
+	if result.Bins, err = options.bins("IS", ts.Epoch, period); err != nil {
+		return
+	}
+
+	var minutes *TimeSeries
+
+	for _, bin := range result.Bins {
+
+		value := 0.0
+		var stabilityErr error
+
+		if bin%time.Minute == 0 && period%time.Minute == 0 {
+
+			// The data is converted to 1 minute epoch only once
+			if minutes == nil {
+				if minutes, err = ts.prepareStability("IS", period); err != nil {
+					return
+				}
+			}
+			value, stabilityErr = minutes.stabilityBin(int(bin/time.Minute), period)
+
+		} else {
+
+			var converted *TimeSeries
+			if converted, err = ts.prepareStabilityBin("IS", period, bin); err != nil {
+				return
+			}
+			value, stabilityErr = converted.stability(period)
+		}
+
+		if stabilityErr != nil {
+			err = newError("IS", stabilityErr)
+			return
+		}
+		if value == -1.0 {
+			value = math.NaN()
+		}
+		result.Values = append(result.Values, value)
+	}
+
+	result.summarize()
+	return
+}
+
+// Prepares the time series used by the interdaily stability when the bin is not a whole number of minutes:
+// the days below the minimum coverage are masked, the data is converted to the bin and only the entire periods are kept
+func (ts *TimeSeries) prepareStabilityBin(function string, period time.Duration, bin time.Duration) (converted *TimeSeries, err error) {
+
+	if ts.Duration() < 2*period {
+		err = durationError(function, 2*period, ts.Duration())
+		return
+	}
+
+	prepared, err := ts.withCoverage(function)
+	if err != nil {
+		return
+	}
+
+	converted, err = prepared.ConvertDataBasedOnEpoch(int(bin / time.Second))
+	if err != nil {
+		err = newError(function, err)
+		return
+	}
+
+	// The data should be divisible by the period (entire cycles)
+	points := int(period / bin)
+	length := converted.Len() - converted.Len()%points
+	trimmed := converted.derive(converted.DateTime[:length], converted.Data[:length], converted.Epoch)
+	if converted.Mask != nil {
+		trimmed.Mask = converted.Mask[:length]
+	}
+	return trimmed, nil
+}
+
+// IV calculates the intradaily variability using the variant defined by the options (e.g. the classic hourly definition or IVm).
+// The data is converted to each bin and the results are the same as IntradailyVariability for the bins from 1 to 60 minutes.
+// The masked records, the NaN values and the days below the minimum coverage are not used
+func (ts *TimeSeries) IV(options VariabilityOptions) (result VariabilityResult, err error) {
+
+	prepared, err := ts.prepareVariability("IV")
+	if err != nil {
+		return
+	}
+	if ts.Epoch == 0 {
+		err = newError("IV", ErrInvalidEpoch)
+		return
+	}
+	if result.Bins, err = options.bins("IV", ts.Epoch, 0); err != nil {
+		return
+	}
+
+	for _, bin := range result.Bins {
+
+		converted, convertErr := prepared.ConvertDataBasedOnEpoch(int(bin / time.Second))
+		if convertErr != nil {
+			err = newError("IV", convertErr)
+			return
+		}
+
+		result.Values = append(result.Values, converted.variability())
+	}
+
+	result.summarize()
+	return
+}
+
+// IS calculates the interdaily stability using the variant defined by the options
+func IS(options VariabilityOptions, dateTime []time.Time, data []float64) (result VariabilityResult, err error) {
+	ts, err := wrapTimeSeries("IS", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.IS(options)
+}
+
+// IV calculates the intradaily variability using the variant defined by the options
+func IV(options VariabilityOptions, dateTime []time.Time, data []float64) (result VariabilityResult, err error) {
+	ts, err := wrapTimeSeries("IV", dateTime, data)
+	if err != nil {
+		return
+	}
+	return ts.IV(options)
+}
@@ -0,0 +1,243 @@
+package chronobiology
+
+import (
+	"encoding/csv"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Creates 2 days (and a masked record) where the activity is constant in each hour
+func hourlySeries(epoch int) *TimeSeries {
+	hourly := []float64{
+		0, 9, 18, 16, 5, 14, 12, 21, 10, 8, 17, 15, 4, 13, 11, 20, 9, 7, 16, 25, 3, 12, 10, 19,
+		0, 8, 16, 3, 11, 19, 6, 14, 22, 9, 17, 25, 12, 7, 15, 2, 10, 18, 5, 13, 21, 8, 16, 24,
+	}
+
+	utc, _ := time.LoadLocation("UTC")
+	var dateTime []time.Time
+	var data []float64
+	for index := 0; index <= 48*3600/epoch; index++ {
+		dateTime = append(dateTime, time.Date(2015, 1, 1, 0, 0, index*epoch, 0, utc))
+		data = append(data, hourly[(index*epoch/3600)%48])
+	}
+
+	ts, _ := NewTimeSeries(dateTime, data)
+	ts.Mask = make([]bool, ts.Len())
+	ts.Mask[ts.Len()-1] = true
+	return ts
+}
+
+func TestIS(t *testing.T) {
+
+	// Reference values calculated using the formula proposed by Witting et al. (1990)
+	ts := hourlySeries(60)
+	result, err := ts.IS(VariabilityOptions{})
+	if err != nil || !floatEquals(roundPlus(result.Value, 4), 0.5516) || len(result.Bins) != 1 || result.Bins[0] != time.Hour {
+		t.Error("Expected: 0.5516, Received: ", result, err)
+	}
+
+	// The IS does not change when the hours are divided in smaller bins (the activity is constant in each hour)
+	var tTests = []struct {
+		epoch   int
+		options VariabilityOptions
+		bins    int
+	}{
+		{60, VariabilityOptions{Binning: Fixed, Bin: 15 * time.Minute}, 1},
+		{60, VariabilityOptions{Binning: Native}, 1},
+		{30, VariabilityOptions{Binning: Native}, 1},
+		{30, VariabilityOptions{Binning: Fixed, Bin: 90 * time.Second}, 1},
+	}
+
+	for _, test := range tTests {
+		series := hourlySeries(test.epoch)
+		result, err = IS(test.options, series.DateTime, series.Data)
+		if err != nil || !floatEquals(roundPlus(result.Value, 4), 0.5516) || len(result.Bins) != test.bins || len(result.Values) != test.bins {
+			t.Error("Epoch: ", test.epoch, "Options: ", test.options, "Expected: 0.5516, Received: ", result.Value, len(result.Bins), err)
+		}
+	}
+
+	// The same results as InterdailyStability (22 bins divide 24 hours)
+	is, _ := ts.InterdailyStability()
+	result, _ = ts.IS(VariabilityOptions{Binning: Averaged})
+	if len(result.Bins) != 22 || !floatEquals(result.Value, is[0]) || !floatEquals(result.Values[21], is[60]) || result.Bins[6] != 8*time.Minute {
+		t.Error("Expected: ", is[0], "Received: ", result.Value)
+	}
+
+	// The data does not vary
+	for index := range ts.Data {
+		ts.Data[index] = 10.0
+	}
+	if result, err = ts.IS(VariabilityOptions{}); err != nil || !math.IsNaN(result.Value) || !math.IsNaN(result.Values[0]) {
+		t.Error("Expected: NaN, Received: ", result, err)
+	}
+
+	// Invalid parameters
+	if _, err = ts.IS(VariabilityOptions{Binning: Fixed, Bin: 7 * time.Minute}); !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+	if _, err = ts.IS(VariabilityOptions{Binning: Binning(7)}); !errors.Is(err, ErrInvalidBinning) {
+		t.Error("Expected: ErrInvalidBinning, Received: ", err)
+	}
+	if _, err = ts.IS(VariabilityOptions{Period: 24*time.Hour + 30*time.Minute}); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = ts.IS(VariabilityOptions{Binning: Averaged, Period: 24*time.Hour + 30*time.Second}); !errors.Is(err, ErrInvalidPeriod) {
+		t.Error("Expected: ErrInvalidPeriod, Received: ", err)
+	}
+	if _, err = ts.IS(VariabilityOptions{Binning: Native, Period: 25 * time.Hour}); !errors.Is(err, ErrInsufficientDuration) {
+		t.Error("Expected: ErrInsufficientDuration, Received: ", err)
+	}
+}
+
+func TestIV(t *testing.T) {
+
+	// Reference value calculated using the formula proposed by Witting et al. (1990)
+	ts := hourlySeries(60)
+	result, err := IV(VariabilityOptions{}, ts.DateTime[:2880], ts.Data[:2880])
+	if err != nil || !floatEquals(result.Value, 2.2556) || len(result.Bins) != 1 || result.Bins[0] != time.Hour {
+		t.Error("Expected: 2.2556, Received: ", result, err)
+	}
+
+	// The masked record is not used
+	result, err = ts.IV(VariabilityOptions{Binning: Fixed, Bin: time.Hour})
+	if err != nil || !floatEquals(result.Value, 2.2556) {
+		t.Error("Expected: 2.2556, Received: ", result, err)
+	}
+
+	// The same results as IntradailyVariability
+	iv, _ := ts.IntradailyVariability()
+	result, err = ts.IV(VariabilityOptions{Binning: Averaged})
+	if err != nil || len(result.Values) != 60 || !floatEquals(result.Value, iv[0]) || !sliceFloatEquals(result.Values, iv[1:]) {
+		t.Error("Expected: ", iv[0], "Received: ", result.Value, err)
+	}
+
+	// The native epoch of the time series
+	native, err := hourlySeries(30).IV(VariabilityOptions{Binning: Native})
+	if err != nil || native.Bins[0] != 30*time.Second || native.Value >= result.Values[0] {
+		t.Error("Unexpected IV: ", native.Value, native.Bins, err)
+	}
+
+	if _, err = ts.IV(VariabilityOptions{Binning: Fixed}); !errors.Is(err, ErrInvalidEpoch) {
+		t.Error("Expected: ErrInvalidEpoch, Received: ", err)
+	}
+}
+
+// Calculates the IS and the IV of the hourly means following the equations of Witting et al. (1990) literally
+func wittingVariability(hourly []float64) (is float64, iv float64) {
+	n := float64(len(hourly))
+	mean := average(hourly)
+
+	var total float64
+	for _, value := range hourly {
+		total += (value - mean) * (value - mean)
+	}
+
+	var between float64
+	for hour := 0; hour < 24; hour++ {
+		var values []float64
+		for index := hour; index < len(hourly); index += 24 {
+			values = append(values, hourly[index])
+		}
+		between += (average(values) - mean) * (average(values) - mean)
+	}
+
+	var successive float64
+	for index := 1; index < len(hourly); index++ {
+		successive += (hourly[index] - hourly[index-1]) * (hourly[index] - hourly[index-1])
+	}
+
+	return n * between / (24.0 * total), n * successive / ((n - 1.0) * total)
+}
+
+func TestVariabilityReference(t *testing.T) {
+
+	// Known values: a periodic square wave (active from 08:00 to 20:00) has IS = 1 and
+	// IV = 72 * 6 / (71 * 18) (6 transitions in 72 hours) and alternating hours have IS = 1 and IV = 4
+	square := make([]float64, 3*1440)
+	alternating := make([]float64, 3*1440)
+	for index := range square {
+		hour := index / 60
+		if hour%24 >= 8 && hour%24 < 20 {
+			square[index] = 100.0
+		}
+		alternating[index] = float64(hour % 2)
+	}
+
+	var tTests = []struct {
+		data   []float64
+		is, iv float64
+	}{
+		{square, 1.0, 72.0 * 6.0 / (71.0 * 18.0)},
+		{alternating, 1.0, 4.0},
+	}
+
+	for _, test := range tTests {
+		ts := minuteSeries(test.data)
+		is, err1 := ts.IS(VariabilityOptions{})
+		iv, err2 := ts.IV(VariabilityOptions{})
+		if err1 != nil || err2 != nil || math.Abs(is.Value-test.is) > 1e-4 || math.Abs(iv.Value-test.iv) > 1e-4 {
+			t.Error("Expected: ", test.is, test.iv, "Received: ", is.Value, iv.Value, err1, err2)
+		}
+	}
+
+	// 7 days of 1 minute epoch with a daily pattern and noise
+	data := make([]float64, 7*1440)
+	for index := range data {
+		data[index] = 200.0 + 150.0*math.Sin(2.0*math.Pi*float64(index)/1440.0) + 100.0*noise(index)
+	}
+	hourly := make([]float64, 7*24)
+	for index := range hourly {
+		hourly[index] = average(data[index*60 : (index+1)*60])
+	}
+	expectedIS, expectedIV := wittingVariability(hourly)
+
+	ts := minuteSeries(data)
+	is, err1 := ts.IS(VariabilityOptions{Binning: Hourly})
+	iv, err2 := ts.IV(VariabilityOptions{Binning: Hourly})
+	if err1 != nil || err2 != nil || math.Abs(is.Value-expectedIS) > 1e-4 || math.Abs(iv.Value-expectedIV) > 1e-4 {
+		t.Error("Expected: ", expectedIS, expectedIV, "Received: ", is.Value, iv.Value, err1, err2)
+	}
+}
+
+func TestVariabilityReferenceFile(t *testing.T) {
+
+	dateTime, counts, err := readFixture("counts.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts, _ := NewTimeSeries(dateTime, counts["counts"])
+
+	// IS and IV calculated by other software (e.g. nparACT or pyActigraphy) for the same count series.
+	// The comment lines of the file record the software version and the call used
+	file, err := os.Open(filepath.Join("testdata", "variability_reference.csv"))
+	if os.IsNotExist(err) {
+		t.Skip("testdata/variability_reference.csv not found (see testdata/generate_counts.py)")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.Comment = '#'
+	rows, err := reader.ReadAll()
+	if err != nil || len(rows) != 2 || len(rows[1]) != 2 {
+		t.Fatal("Invalid reference: ", rows, err)
+	}
+	expectedIS, err1 := strconv.ParseFloat(rows[1][0], 64)
+	expectedIV, err2 := strconv.ParseFloat(rows[1][1], 64)
+	if err1 != nil || err2 != nil {
+		t.Fatal("Invalid reference: ", err1, err2)
+	}
+
+	is, err1 := ts.IS(VariabilityOptions{Binning: Hourly})
+	iv, err2 := ts.IV(VariabilityOptions{Binning: Hourly})
+	if err1 != nil || err2 != nil || math.Abs(is.Value-expectedIS) > 1e-3 || math.Abs(iv.Value-expectedIV) > 1e-3 {
+		t.Error("Expected: ", expectedIS, expectedIV, "Received: ", is.Value, iv.Value, err1, err2)
+	}
+}